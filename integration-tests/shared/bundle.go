@@ -2,6 +2,7 @@ package shared
 
 import (
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -247,5 +248,18 @@ func TestRunBundle(t *testing.T, dir string, cifuzz string, bundlePath string, e
 	// Verify that a build log has been added to the archive
 	require.FileExists(t, filepath.Join(archiveDir, "build.log"))
 
+	// Verify that an SBOM has been added to the archive
+	sbomJSON, err := os.ReadFile(filepath.Join(archiveDir, archive.SBOMFileName))
+	require.NoError(t, err)
+	sbom := &archive.SBOM{}
+	err = json.Unmarshal(sbomJSON, sbom)
+	require.NoError(t, err)
+	require.Equal(t, "CycloneDX", sbom.BOMFormat)
+
+	// Verify that the files of the archive match the checksum manifest
+	require.FileExists(t, filepath.Join(archiveDir, archive.ManifestFileName))
+	err = archive.VerifyIntegrity(archiveDir, &archive.IntegrityOpts{})
+	require.NoError(t, err)
+
 	return metadata, archiveDir
 }
@@ -231,6 +231,12 @@ func RemoteToLocalFinding(finding *Finding) (*findingPkg.Finding, error) {
 		Tag:                finding.ErrorReport.Tag,
 		CreatedAt:          timeStamp,
 		FuzzTest:           finding.FuzzTargetDisplayName,
+		// The exploitability is only shown, the severity in the error
+		// report is kept as is. It was only adjusted to the exploitability
+		// if the finding was uploaded by 'cifuzz run', which can't be told
+		// apart from findings of other origins, so adjusting it here could
+		// adjust it twice.
+		Exploitability: findingPkg.ClassifyExploitability(finding.ErrorReport.Logs),
 	}
 
 	for _, breakPoint := range finding.ErrorReport.DebuggingInfo.BreakPoints {
@@ -2,11 +2,16 @@ package archive
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -49,43 +54,119 @@ func (w *NullArchiveWriter) Headers() []*tar.Header {
 	return []*tar.Header{}
 }
 
-// TarArchiveWriter provides functions to create a gzip-compressed tar archive.
+// TarArchiveWriter provides functions to create a (compressed) tar archive.
 type TarArchiveWriter struct {
 	*tar.Writer
 	manifest   map[string]string
 	headers    []*tar.Header
-	gzipWriter *gzip.Writer
+	compressor io.WriteCloser
+
+	// The SHA-256 checksums of the regular files written to the
+	// archive and the targets of the hard links, used to create the
+	// checksum manifest
+	checksums map[string]string
+	links     map[string]string
+
+	// In reproducible mode, entries are only recorded when they are
+	// added and written in a deterministic order on Close
+	reproducible bool
+	modTime      time.Time
+	entries      []*tarEntry
 }
 
+type tarEntry struct {
+	header     *tar.Header
+	sourcePath string
+}
+
+// NewTarArchiveWriter returns a TarArchiveWriter which creates a
+// gzip-compressed tar archive if compress is true and an uncompressed
+// one otherwise.
 func NewTarArchiveWriter(w io.Writer, compress bool) *TarArchiveWriter {
-	var gzipWriter *gzip.Writer
-	var writer *tar.Writer
+	if !compress {
+		return newTarArchiveWriter(w, nil)
+	}
+	// Creating a gzip compressor never fails
+	compressor, _ := newCompressor(w, CompressionGzip, false)
+	return newTarArchiveWriter(w, compressor)
+}
 
-	if compress {
-		gzipWriter = gzip.NewWriter(w)
-		writer = tar.NewWriter(gzipWriter)
-	} else {
-		writer = tar.NewWriter(w)
+// NewCompressedTarArchiveWriter returns a TarArchiveWriter which
+// creates a tar archive compressed with the given format.
+func NewCompressedTarArchiveWriter(w io.Writer, compression Compression) (*TarArchiveWriter, error) {
+	compressor, err := newCompressor(w, compression, false)
+	if err != nil {
+		return nil, err
 	}
+	return newTarArchiveWriter(w, compressor), nil
+}
+
+// NewReproducibleTarArchiveWriter returns a TarArchiveWriter which
+// creates byte-identical archives for identical inputs: entries are
+// sorted by name, all modification times are set to modTime, owner
+// information is removed and file modes are normalized to 0644 or
+// 0755 (for directories and executables).
+func NewReproducibleTarArchiveWriter(w io.Writer, compression Compression, modTime time.Time) (*TarArchiveWriter, error) {
+	compressor, err := newCompressor(w, compression, true)
+	if err != nil {
+		return nil, err
+	}
+	writer := newTarArchiveWriter(w, compressor)
+	writer.reproducible = true
+	writer.modTime = modTime.UTC().Truncate(time.Second)
+	return writer, nil
+}
 
-	return &TarArchiveWriter{
-		Writer:     writer,
+func newTarArchiveWriter(w io.Writer, compressor io.WriteCloser) *TarArchiveWriter {
+	writer := &TarArchiveWriter{
 		manifest:   make(map[string]string),
-		gzipWriter: gzipWriter,
+		compressor: compressor,
+		checksums:  make(map[string]string),
+		links:      make(map[string]string),
 	}
+	if compressor != nil {
+		writer.Writer = tar.NewWriter(compressor)
+	} else {
+		writer.Writer = tar.NewWriter(w)
+	}
+	return writer
+}
+
+// ReproducibleModTime returns the modification time to use for the
+// entries of reproducible archives, which is taken from the
+// SOURCE_DATE_EPOCH environment variable (see
+// https://reproducible-builds.org/specs/source-date-epoch/) and
+// defaults to the Unix epoch.
+func ReproducibleModTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "Invalid value for SOURCE_DATE_EPOCH: %q", epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
 }
 
-// Close closes the tar writer and the gzip writer. It does not close
+// Close closes the tar writer and the compressor. It does not close
 // the underlying io.Writer.
 func (w *TarArchiveWriter) Close() error {
 	var err error
+	if w.reproducible {
+		err = w.writeEntries()
+		if err != nil {
+			return err
+		}
+	}
+
 	err = w.Writer.Close()
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if w.gzipWriter != nil {
-		err = w.gzipWriter.Close()
+	if w.compressor != nil {
+		err = w.compressor.Close()
 	}
 
 	if err != nil {
@@ -141,6 +222,20 @@ func (w *TarArchiveWriter) writeFileOrEmptyDir(archivePath string, sourcePath st
 		return errors.WithStack(err)
 	}
 	header.Name = archivePath
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return errors.Errorf("not a regular file: %s", sourcePath)
+	}
+
+	if w.reproducible {
+		w.normalizeHeader(header)
+		w.entries = append(w.entries, &tarEntry{header: header, sourcePath: sourcePath})
+		w.headers = append(w.headers, header)
+		if !info.IsDir() {
+			w.manifest[archivePath] = sourcePath
+		}
+		return nil
+	}
+
 	err = w.WriteHeader(header)
 	if err != nil {
 		return errors.WithStack(err)
@@ -150,14 +245,13 @@ func (w *TarArchiveWriter) writeFileOrEmptyDir(archivePath string, sourcePath st
 	if info.IsDir() {
 		return nil
 	}
-	if !info.Mode().IsRegular() {
-		return errors.Errorf("not a regular file: %s", sourcePath)
-	}
 
-	_, err = io.Copy(w.Writer, f)
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(w.Writer, h), f)
 	if err != nil {
 		return errors.Wrapf(err, "failed to add file to archive: %s", sourcePath)
 	}
+	w.checksums[archivePath] = hex.EncodeToString(h.Sum(nil))
 
 	w.manifest[archivePath] = sourcePath
 	return nil
@@ -177,6 +271,13 @@ func (w *TarArchiveWriter) WriteHardLink(target string, linkname string) error {
 		Name:     linkname,
 		Linkname: target,
 	}
+	w.links[linkname] = target
+	if w.reproducible {
+		w.normalizeHeader(header)
+		w.entries = append(w.entries, &tarEntry{header: header})
+		w.manifest[target] = linkname
+		return nil
+	}
 	err := w.WriteHeader(header)
 	if err != nil {
 		return errors.WithStack(err)
@@ -214,6 +315,145 @@ func (w *TarArchiveWriter) WriteDir(archiveBasePath string, sourceDir string) er
 	return nil
 }
 
+func (w *TarArchiveWriter) normalizeHeader(header *tar.Header) {
+	header.ModTime = w.modTime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	// Let the tar writer choose the format based on the header fields
+	// only, not on how the header was created
+	header.Format = tar.FormatUnknown
+	header.PAXRecords = nil
+
+	switch {
+	case header.Typeflag == tar.TypeDir, header.Mode&0o111 != 0:
+		header.Mode = 0o755
+	default:
+		header.Mode = 0o644
+	}
+}
+
+// writeEntries writes the recorded entries sorted by name. Hard links
+// are written after all other entries, so that their targets always
+// exist when the archive is extracted sequentially.
+func (w *TarArchiveWriter) writeEntries() error {
+	sort.SliceStable(w.entries, func(i, j int) bool {
+		a, b := w.entries[i].header, w.entries[j].header
+		if (a.Typeflag == tar.TypeLink) != (b.Typeflag == tar.TypeLink) {
+			return b.Typeflag == tar.TypeLink
+		}
+		return a.Name < b.Name
+	})
+
+	for _, entry := range w.entries {
+		err := w.WriteHeader(entry.header)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if entry.header.Typeflag != tar.TypeReg {
+			continue
+		}
+		err = w.copyFile(entry.header, entry.sourcePath)
+		if err != nil {
+			return err
+		}
+	}
+	w.entries = nil
+	return nil
+}
+
+// copyFile writes the content of the file to the archive. Only the
+// size recorded in the header is copied, because files like the build
+// log can still grow after they were added to the archive.
+func (w *TarArchiveWriter) copyFile(header *tar.Header, sourcePath string) error {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.CopyN(io.MultiWriter(w.Writer, h), f, header.Size)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add file to archive: %s", sourcePath)
+	}
+	w.checksums[header.Name] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// WriteManifest writes a manifest with the SHA-256 checksums of all
+// files which were added to the archive so far (see ManifestFileName).
+// If signingKey is not nil, the manifest is signed and the signature
+// is returned. Unless detached is true, the signature is also written
+// to the archive (see SignatureFileName). No files must be added to
+// the archive after the manifest.
+func (w *TarArchiveWriter) WriteManifest(signingKey ed25519.PrivateKey, detached bool) ([]byte, error) {
+	// In reproducible mode, the files are only written (and their
+	// checksums computed) when the recorded entries are written
+	if w.reproducible {
+		err := w.writeEntries()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := &Manifest{Checksums: make(map[string]string)}
+	for name, checksum := range w.checksums {
+		manifest.Checksums[name] = checksum
+	}
+	for linkname, target := range w.links {
+		checksum, ok := w.checksums[target]
+		if !ok {
+			return nil, errors.Errorf("hard link %q points to %q, which is not a file in the archive", linkname, target)
+		}
+		manifest.Checksums[linkname] = checksum
+	}
+	manifestData := manifest.Bytes()
+	err := w.writeBytes(ManifestFileName, manifestData)
+	if err != nil {
+		return nil, err
+	}
+
+	if signingKey == nil {
+		return nil, nil
+	}
+	signature := SignManifest(signingKey, manifestData)
+	if !detached {
+		err = w.writeBytes(SignatureFileName, signature)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signature, nil
+}
+
+// writeBytes writes a regular file with the given content to the
+// archive.
+func (w *TarArchiveWriter) writeBytes(archivePath string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     archivePath,
+		Size:     int64(len(data)),
+		Mode:     0o644,
+		ModTime:  time.Now(),
+	}
+	if w.reproducible {
+		w.normalizeHeader(header)
+	}
+	err := w.WriteHeader(header)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	w.headers = append(w.headers, header)
+	return nil
+}
+
 func (w *TarArchiveWriter) GetSourcePath(archivePath string) string {
 	return w.manifest[archivePath]
 }
@@ -227,16 +467,18 @@ func (w *TarArchiveWriter) Headers() []*tar.Header {
 	return w.headers
 }
 
-// Extract extracts the gzip-compressed tar archive bundle into dir.
+// Extract extracts the tar archive bundle into dir. The compression
+// format is detected automatically.
 func Extract(bundle, dir string) error {
 	f, err := os.Open(bundle)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	gr, err := gzip.NewReader(f)
+	defer f.Close()
+	r, err := NewReader(f)
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
-	defer gr.Close()
-	return archiveutil.Untar(gr, dir)
+	defer r.Close()
+	return archiveutil.Untar(r, dir)
 }
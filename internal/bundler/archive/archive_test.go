@@ -3,8 +3,10 @@ package archive
 import (
 	"archive/tar"
 	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -12,9 +14,11 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"code-intelligence.com/cifuzz/internal/testutil"
@@ -235,3 +239,82 @@ func createArchive(t *testing.T, files []fileEntry) *os.File {
 	t.Logf("Created archive at: %s", archiveFile.Name())
 	return archiveFile
 }
+
+func TestReproducibleTarArchiveWriter(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "reproducible-archive-test-*")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "a"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "sub", "b"), []byte("b"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c"), []byte("c"), 0o640))
+
+	modTime := time.Unix(1700000000, 0)
+	createArchive := func(reverse bool) []byte {
+		buf := &bytes.Buffer{}
+		w, err := NewReproducibleTarArchiveWriter(buf, CompressionGzip, modTime)
+		require.NoError(t, err)
+		if reverse {
+			require.NoError(t, w.WriteFile("c", filepath.Join(dir, "c")))
+			require.NoError(t, w.WriteHardLink("c", "link"))
+			require.NoError(t, w.WriteDir("src", filepath.Join(dir, "src")))
+		} else {
+			require.NoError(t, w.WriteDir("src", filepath.Join(dir, "src")))
+			require.NoError(t, w.WriteFile("c", filepath.Join(dir, "c")))
+			require.NoError(t, w.WriteHardLink("c", "link"))
+		}
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	first := createArchive(false)
+	// Change the modification times of the inputs and add the entries
+	// in a different order, which must not change the archive
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "src", "a"), later, later))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "c"), later, later))
+	second := createArchive(true)
+	require.Equal(t, first, second, "archives are not byte-identical")
+
+	gr, err := gzip.NewReader(bytes.NewReader(first))
+	require.NoError(t, err)
+	assert.True(t, gr.ModTime.IsZero(), "gzip header contains a timestamp")
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+		assert.Equal(t, modTime.UTC(), header.ModTime.UTC(), header.Name)
+		assert.Equal(t, 0, header.Uid, header.Name)
+		assert.Equal(t, 0, header.Gid, header.Name)
+		assert.Empty(t, header.Uname, header.Name)
+		assert.Empty(t, header.Gname, header.Name)
+
+		switch header.Name {
+		case "src", "src/sub", "src/sub/b":
+			assert.Equal(t, int64(0o755), header.Mode, header.Name)
+		case "c", "src/a":
+			assert.Equal(t, int64(0o644), header.Mode, header.Name)
+		}
+	}
+	// Entries are sorted by name, hard links come last
+	assert.Equal(t, []string{"c", "src", "src/a", "src/sub", "src/sub/b", "link"}, names)
+}
+
+func TestReproducibleModTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	modTime, err := ReproducibleModTime()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), modTime.Unix())
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	modTime, err = ReproducibleModTime()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), modTime.Unix())
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = ReproducibleModTime()
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+	"github.com/pkg/errors"
+)
+
+// Compression is the compression format of a bundle archive.
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+	CompressionNone Compression = "none"
+)
+
+var Compressions = []Compression{CompressionGzip, CompressionZstd, CompressionNone}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseCompression returns the compression format with the given name.
+// An empty name selects the default format, gzip.
+func ParseCompression(name string) (Compression, error) {
+	if name == "" {
+		return CompressionGzip, nil
+	}
+	for _, c := range Compressions {
+		if string(c) == strings.ToLower(name) {
+			return c, nil
+		}
+	}
+	return "", errors.Errorf("Unsupported compression %q, supported are: %s", name, compressionNames())
+}
+
+func compressionNames() string {
+	var names []string
+	for _, c := range Compressions {
+		names = append(names, string(c))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Extension returns the file extension of bundles with this
+// compression format.
+func (c Compression) Extension() string {
+	switch c {
+	case CompressionZstd:
+		return ".tar.zst"
+	case CompressionNone:
+		return ".tar"
+	default:
+		return ".tar.gz"
+	}
+}
+
+// newCompressor returns a writer which compresses the data written to
+// it and writes it to w, or nil if no compression is used. Both gzip
+// and zstd compress multiple blocks in parallel. The output of pgzip
+// only depends on its block size, but the zstd encoder splits its input
+// differently depending on the number of goroutines, so if reproducible
+// is set, it uses a single goroutine and a fixed level.
+func newCompressor(w io.Writer, compression Compression, reproducible bool) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		// Don't store a modification time in the gzip header, which
+		// keeps the compressed output deterministic. Unlike the
+		// standard library, pgzip doesn't handle the zero time, so we
+		// set the Unix epoch, which is encoded as "no timestamp".
+		gzipWriter := pgzip.NewWriter(w)
+		gzipWriter.ModTime = time.Unix(0, 0)
+		return gzipWriter, nil
+	case CompressionZstd:
+		var opts []zstd.EOption
+		if reproducible {
+			opts = append(opts,
+				zstd.WithEncoderConcurrency(1),
+				zstd.WithEncoderLevel(zstd.SpeedDefault))
+		}
+		encoder, err := zstd.NewWriter(w, opts...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return encoder, nil
+	case CompressionNone:
+		return nil, nil
+	}
+	return nil, errors.Errorf("Unsupported compression %q", compression)
+}
+
+// NewReader returns a reader for the tar stream of a bundle read from
+// r. The compression format is detected automatically.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, errors.WithStack(err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return gr, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		// Assume an uncompressed tar archive, if it's something else
+		// the tar reader will fail
+		return io.NopCloser(br), nil
+	}
+}
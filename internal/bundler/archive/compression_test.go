@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestCompressedTarArchiveWriter(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "compression-")
+	writeFile(t, filepath.Join(dir, MetadataFileName), "fuzzers: []\n", 0o644)
+	writeFile(t, filepath.Join(dir, "work_dir", "file"), "content", 0o644)
+
+	for _, compression := range Compressions {
+		t.Run(string(compression), func(t *testing.T) {
+			bundle := filepath.Join(testutil.MkdirTemp(t, "", "bundle-"), "bundle"+compression.Extension())
+			f, err := os.Create(bundle)
+			require.NoError(t, err)
+			w, err := NewCompressedTarArchiveWriter(f, compression)
+			require.NoError(t, err)
+			require.NoError(t, w.WriteFile(MetadataFileName, filepath.Join(dir, MetadataFileName)))
+			require.NoError(t, w.WriteDir("work_dir", filepath.Join(dir, "work_dir")))
+			require.NoError(t, w.Close())
+			require.NoError(t, f.Close())
+
+			data, err := os.ReadFile(bundle)
+			require.NoError(t, err)
+			switch compression {
+			case CompressionGzip:
+				assert.True(t, bytes.HasPrefix(data, gzipMagic))
+			case CompressionZstd:
+				assert.True(t, bytes.HasPrefix(data, zstdMagic))
+			}
+
+			// The format is detected when extracting the bundle
+			extractDir := testutil.MkdirTemp(t, "", "extract-")
+			require.NoError(t, Extract(bundle, extractDir))
+			content, err := os.ReadFile(filepath.Join(extractDir, "work_dir", "file"))
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+
+			contents, err := ReadContents(bundle)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len("fuzzers: []\n")+len("content")), contents.TotalSize)
+		})
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	compression, err := ParseCompression("")
+	require.NoError(t, err)
+	assert.Equal(t, CompressionGzip, compression)
+
+	compression, err = ParseCompression("ZSTD")
+	require.NoError(t, err)
+	assert.Equal(t, CompressionZstd, compression)
+
+	_, err = ParseCompression("bzip2")
+	assert.Error(t, err)
+}
+
+func TestReproducibleCompression(t *testing.T) {
+	// Create an input which is large enough to be split into multiple
+	// blocks by the compressors
+	dir := testutil.MkdirTemp(t, "", "compression-")
+	data := make([]byte, 16<<20)
+	rng := rand.New(rand.NewSource(0))
+	for i := range data {
+		data[i] = byte('a' + rng.Intn(4))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), data, 0o644))
+
+	createArchive := func(compression Compression, procs int) []byte {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+		buf := &bytes.Buffer{}
+		w, err := NewReproducibleTarArchiveWriter(buf, compression, time.Unix(0, 0))
+		require.NoError(t, err)
+		require.NoError(t, w.WriteFile("file", filepath.Join(dir, "file")))
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	for _, compression := range Compressions {
+		t.Run(string(compression), func(t *testing.T) {
+			first := createArchive(compression, 1)
+			second := createArchive(compression, 8)
+			require.Equal(t, first, second, "archives are not byte-identical")
+		})
+	}
+}
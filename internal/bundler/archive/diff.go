@@ -0,0 +1,298 @@
+package archive
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Diff describes the differences between two bundles.
+type Diff struct {
+	DockerImage    *ValueChange  `json:"docker_image,omitempty"`
+	Commit         *ValueChange  `json:"commit,omitempty"`
+	Branch         *ValueChange  `json:"branch,omitempty"`
+	FuzzersAdded   []string      `json:"fuzzers_added,omitempty"`
+	FuzzersRemoved []string      `json:"fuzzers_removed,omitempty"`
+	FuzzersChanged []*FuzzerDiff `json:"fuzzers_changed,omitempty"`
+	Files          *FilesDiff    `json:"files,omitempty"`
+	SeedCorpora    []*SeedsDiff  `json:"seed_corpora,omitempty"`
+}
+
+// ValueChange is a value which differs between the two bundles.
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// FuzzerDiff lists the properties of a fuzzer which differ between the
+// two bundles.
+type FuzzerDiff struct {
+	Name    string                  `json:"name"`
+	Changes map[string]*ValueChange `json:"changes"`
+}
+
+// FilesDiff lists the archive paths of the files which were added,
+// removed or modified.
+type FilesDiff struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// SeedsDiff describes the changes of a seed corpus directory. Seed
+// files are not included in the FilesDiff.
+type SeedsDiff struct {
+	Dir string `json:"dir"`
+	FilesDiff
+}
+
+// Empty returns true if the bundles don't differ.
+func (d *Diff) Empty() bool {
+	return d.DockerImage == nil && d.Commit == nil && d.Branch == nil &&
+		len(d.FuzzersAdded) == 0 && len(d.FuzzersRemoved) == 0 && len(d.FuzzersChanged) == 0 &&
+		d.Files == nil && len(d.SeedCorpora) == 0
+}
+
+func (d *FilesDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// snapshot is the metadata and the checksums of all files of a bundle.
+type snapshot struct {
+	metadata  *Metadata
+	checksums map[string]string
+}
+
+// DiffBundles compares the metadata and the files of two bundles.
+func DiffBundles(oldBundle, newBundle string) (*Diff, error) {
+	oldSnapshot, err := readSnapshot(oldBundle)
+	if err != nil {
+		return nil, err
+	}
+	newSnapshot, err := readSnapshot(newBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{}
+	oldMetadata, newMetadata := oldSnapshot.metadata, newSnapshot.metadata
+	d.DockerImage = valueChange(dockerImage(oldMetadata), dockerImage(newMetadata))
+	oldCommit, oldBranch := gitRevision(oldMetadata)
+	newCommit, newBranch := gitRevision(newMetadata)
+	d.Commit = valueChange(oldCommit, newCommit)
+	d.Branch = valueChange(oldBranch, newBranch)
+
+	oldFuzzers := fuzzersByName(oldMetadata)
+	newFuzzers := fuzzersByName(newMetadata)
+	for _, name := range sortedKeys(newFuzzers) {
+		if _, ok := oldFuzzers[name]; !ok {
+			d.FuzzersAdded = append(d.FuzzersAdded, name)
+		}
+	}
+	for _, name := range sortedKeys(oldFuzzers) {
+		newFuzzer, ok := newFuzzers[name]
+		if !ok {
+			d.FuzzersRemoved = append(d.FuzzersRemoved, name)
+			continue
+		}
+		changes := diffFuzzers(oldFuzzers[name], newFuzzer)
+		if len(changes) > 0 {
+			d.FuzzersChanged = append(d.FuzzersChanged, &FuzzerDiff{Name: name, Changes: changes})
+		}
+	}
+
+	// The seed corpus directories are compared separately, so that
+	// changed seeds are summarized per corpus instead of being listed
+	// among the other files
+	seedDirs := map[string]bool{}
+	for _, m := range []*Metadata{oldMetadata, newMetadata} {
+		for _, fuzzer := range m.Fuzzers {
+			if fuzzer.Seeds != "" {
+				seedDirs[path.Clean(fuzzer.Seeds)] = true
+			}
+		}
+	}
+	for _, dir := range sortedKeys(seedDirs) {
+		seedsDiff := &SeedsDiff{Dir: dir}
+		seedsDiff.FilesDiff = *diffFiles(oldSnapshot.checksums, newSnapshot.checksums, func(name string) bool {
+			return strings.HasPrefix(name, dir+"/")
+		})
+		if !seedsDiff.empty() {
+			d.SeedCorpora = append(d.SeedCorpora, seedsDiff)
+		}
+	}
+
+	files := diffFiles(oldSnapshot.checksums, newSnapshot.checksums, func(name string) bool {
+		for dir := range seedDirs {
+			if strings.HasPrefix(name, dir+"/") {
+				return false
+			}
+		}
+		return true
+	})
+	if !files.empty() {
+		d.Files = files
+	}
+
+	return d, nil
+}
+
+// readSnapshot reads the metadata of the bundle and computes the
+// checksums of its files without extracting it.
+func readSnapshot(bundle string) (*snapshot, error) {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	r, err := NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	s := &snapshot{checksums: map[string]string{}}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read bundle %s", bundle)
+		}
+
+		name := path.Clean(header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg:
+			h := sha256.New()
+			var data []byte
+			if name == MetadataFileName {
+				data, err = io.ReadAll(io.TeeReader(tr, h))
+			} else {
+				_, err = io.Copy(h, tr)
+			}
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			s.checksums[name] = hex.EncodeToString(h.Sum(nil))
+
+			if name == MetadataFileName {
+				s.metadata = &Metadata{}
+				err = s.metadata.FromYaml(data)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case tar.TypeLink:
+			// Hard links are always written after their targets
+			s.checksums[name] = s.checksums[path.Clean(header.Linkname)]
+		}
+	}
+
+	if s.metadata == nil {
+		return nil, errors.Errorf("Bundle %s doesn't contain a %s file", bundle, MetadataFileName)
+	}
+	return s, nil
+}
+
+func diffFiles(oldChecksums, newChecksums map[string]string, include func(string) bool) *FilesDiff {
+	d := &FilesDiff{}
+	for _, name := range sortedKeys(newChecksums) {
+		if !include(name) {
+			continue
+		}
+		oldChecksum, ok := oldChecksums[name]
+		if !ok {
+			d.Added = append(d.Added, name)
+		} else if oldChecksum != newChecksums[name] {
+			d.Modified = append(d.Modified, name)
+		}
+	}
+	for _, name := range sortedKeys(oldChecksums) {
+		if _, ok := newChecksums[name]; !ok && include(name) {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// fuzzersByName groups the fuzzers of the metadata by their name and
+// engine. The libFuzzer bundler creates one fuzzer per sanitizer, which
+// are grouped together, so that changed sanitizers are reported as a
+// change of the fuzzer instead of added and removed fuzzers.
+func fuzzersByName(m *Metadata) map[string][]*Fuzzer {
+	fuzzers := map[string][]*Fuzzer{}
+	for _, fuzzer := range m.Fuzzers {
+		name := fuzzer.Name
+		if name == "" {
+			name = fuzzer.Target
+		}
+		key := fmt.Sprintf("%s (%s)", name, fuzzer.Engine)
+		fuzzers[key] = append(fuzzers[key], fuzzer)
+	}
+	return fuzzers
+}
+
+func diffFuzzers(oldFuzzers, newFuzzers []*Fuzzer) map[string]*ValueChange {
+	changes := map[string]*ValueChange{}
+	add := func(field, oldValue, newValue string) {
+		if c := valueChange(oldValue, newValue); c != nil {
+			changes[field] = c
+		}
+	}
+
+	var oldSanitizers, newSanitizers []string
+	for _, fuzzer := range oldFuzzers {
+		oldSanitizers = append(oldSanitizers, fuzzer.Sanitizer)
+	}
+	for _, fuzzer := range newFuzzers {
+		newSanitizers = append(newSanitizers, fuzzer.Sanitizer)
+	}
+	sort.Strings(oldSanitizers)
+	sort.Strings(newSanitizers)
+	add("sanitizers", strings.Join(oldSanitizers, ", "), strings.Join(newSanitizers, ", "))
+
+	// Apart from the sanitizer, the fuzzers of a group only differ if
+	// the bundle was created manually, so comparing the first ones is
+	// good enough
+	oldFuzzer, newFuzzer := oldFuzzers[0], newFuzzers[0]
+	add("path", oldFuzzer.Path, newFuzzer.Path)
+	add("dictionary", oldFuzzer.Dictionary, newFuzzer.Dictionary)
+	add("seeds", oldFuzzer.Seeds, newFuzzer.Seeds)
+	add("engine_options.flags", strings.Join(oldFuzzer.EngineOptions.Flags, " "), strings.Join(newFuzzer.EngineOptions.Flags, " "))
+	add("engine_options.env", strings.Join(oldFuzzer.EngineOptions.Env, " "), strings.Join(newFuzzer.EngineOptions.Env, " "))
+	add("library_paths", strings.Join(oldFuzzer.LibraryPaths, ":"), strings.Join(newFuzzer.LibraryPaths, ":"))
+	add("runtime_paths", strings.Join(oldFuzzer.RuntimePaths, ":"), strings.Join(newFuzzer.RuntimePaths, ":"))
+	add("max_run_time", fmt.Sprint(oldFuzzer.MaxRunTime), fmt.Sprint(newFuzzer.MaxRunTime))
+	return changes
+}
+
+func valueChange(oldValue, newValue string) *ValueChange {
+	if oldValue == newValue {
+		return nil
+	}
+	return &ValueChange{Old: oldValue, New: newValue}
+}
+
+func dockerImage(m *Metadata) string {
+	if m.RunEnvironment == nil {
+		return ""
+	}
+	return m.Docker
+}
+
+func gitRevision(m *Metadata) (string, string) {
+	if m.CodeRevision == nil || m.CodeRevision.Git == nil {
+		return "", ""
+	}
+	return m.CodeRevision.Git.Commit, m.CodeRevision.Git.Branch
+}
@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+// writeBundle creates a bundle with the given metadata from the files in
+// dir.
+func writeBundle(t *testing.T, dir string, metadata *Metadata) string {
+	metadataYaml, err := metadata.ToYaml()
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dir, MetadataFileName), string(metadataYaml), 0o644)
+
+	bundle := filepath.Join(testutil.MkdirTemp(t, "", "bundle-"), "bundle.tar.gz")
+	f, err := os.Create(bundle)
+	require.NoError(t, err)
+	w := NewTarArchiveWriter(f, true)
+	require.NoError(t, w.WriteDir("", dir))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+	return bundle
+}
+
+func TestDiffBundles(t *testing.T) {
+	fuzzerPath := "libfuzzer/address/my_fuzz_test/bin/my_fuzz_test"
+	seeds := "libfuzzer/address/my_fuzz_test/seeds"
+
+	oldDir := testutil.MkdirTemp(t, "", "diff-old-")
+	writeFile(t, filepath.Join(oldDir, filepath.FromSlash(fuzzerPath)), "old", 0o755)
+	writeFile(t, filepath.Join(oldDir, filepath.FromSlash(seeds), "a", "seed1"), "1", 0o644)
+	writeFile(t, filepath.Join(oldDir, filepath.FromSlash(seeds), "a", "seed2"), "2", 0o644)
+	writeFile(t, filepath.Join(oldDir, "work_dir", "removed"), "", 0o644)
+	oldBundle := writeBundle(t, oldDir, &Metadata{
+		RunEnvironment: &RunEnvironment{Docker: "cifuzz/cifuzz-ubuntu:latest"},
+		CodeRevision:   &CodeRevision{Git: &GitRevision{Commit: "1111", Branch: "main"}},
+		Fuzzers: []*Fuzzer{
+			{Target: "my_fuzz_test", Path: fuzzerPath, Engine: "LIBFUZZER", Sanitizer: "ADDRESS", Seeds: seeds},
+			{Target: "removed_fuzz_test", Path: fuzzerPath, Engine: "LIBFUZZER", Sanitizer: "ADDRESS"},
+		},
+	})
+
+	newDir := testutil.MkdirTemp(t, "", "diff-new-")
+	writeFile(t, filepath.Join(newDir, filepath.FromSlash(fuzzerPath)), "new", 0o755)
+	writeFile(t, filepath.Join(newDir, filepath.FromSlash(seeds), "a", "seed1"), "1", 0o644)
+	writeFile(t, filepath.Join(newDir, filepath.FromSlash(seeds), "a", "seed3"), "3", 0o644)
+	writeFile(t, filepath.Join(newDir, "work_dir", "added"), "", 0o644)
+	newBundle := writeBundle(t, newDir, &Metadata{
+		RunEnvironment: &RunEnvironment{Docker: "cifuzz/cifuzz-ubuntu:latest"},
+		CodeRevision:   &CodeRevision{Git: &GitRevision{Commit: "2222", Branch: "main"}},
+		Fuzzers: []*Fuzzer{
+			{
+				Target: "my_fuzz_test", Path: fuzzerPath, Engine: "LIBFUZZER", Sanitizer: "ADDRESS", Seeds: seeds,
+				EngineOptions: EngineOptions{Flags: []string{"-use_value_profile=1"}},
+			},
+			{Target: "my_fuzz_test", Path: fuzzerPath, Engine: "LIBFUZZER", Sanitizer: "UNDEFINED", Seeds: seeds},
+			{Target: "added_fuzz_test", Path: fuzzerPath, Engine: "LIBFUZZER", Sanitizer: "ADDRESS"},
+		},
+	})
+
+	d, err := DiffBundles(oldBundle, newBundle)
+	require.NoError(t, err)
+	assert.False(t, d.Empty())
+	assert.Nil(t, d.DockerImage)
+	assert.Equal(t, &ValueChange{Old: "1111", New: "2222"}, d.Commit)
+	assert.Nil(t, d.Branch)
+	assert.Equal(t, []string{"added_fuzz_test (LIBFUZZER)"}, d.FuzzersAdded)
+	assert.Equal(t, []string{"removed_fuzz_test (LIBFUZZER)"}, d.FuzzersRemoved)
+	require.Len(t, d.FuzzersChanged, 1)
+	assert.Equal(t, "my_fuzz_test (LIBFUZZER)", d.FuzzersChanged[0].Name)
+	assert.Equal(t, map[string]*ValueChange{
+		"sanitizers":           {Old: "ADDRESS", New: "ADDRESS, UNDEFINED"},
+		"engine_options.flags": {Old: "", New: "-use_value_profile=1"},
+	}, d.FuzzersChanged[0].Changes)
+
+	require.Len(t, d.SeedCorpora, 1)
+	assert.Equal(t, seeds, d.SeedCorpora[0].Dir)
+	assert.Equal(t, []string{seeds + "/a/seed3"}, d.SeedCorpora[0].Added)
+	assert.Equal(t, []string{seeds + "/a/seed2"}, d.SeedCorpora[0].Removed)
+	assert.Empty(t, d.SeedCorpora[0].Modified)
+
+	require.NotNil(t, d.Files)
+	assert.Equal(t, []string{"work_dir/added"}, d.Files.Added)
+	assert.Equal(t, []string{"work_dir/removed"}, d.Files.Removed)
+	assert.Equal(t, []string{MetadataFileName, fuzzerPath}, d.Files.Modified)
+
+	// A bundle doesn't differ from itself
+	d, err = DiffBundles(oldBundle, oldBundle)
+	require.NoError(t, err)
+	assert.True(t, d.Empty())
+}
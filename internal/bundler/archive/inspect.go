@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Contents describes the entries of a bundle archive.
+type Contents struct {
+	Metadata   *Metadata
+	Components []*Component
+	TotalSize  int64
+}
+
+// Component is a group of archive entries which belong together, e.g.
+// all files of a fuzz test built with a specific sanitizer.
+type Component struct {
+	Path  string
+	Files int
+	Size  int64
+}
+
+// ReadContents reads the metadata and the sizes of all entries of the
+// bundle without extracting it.
+func ReadContents(bundle string) (*Contents, error) {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	r, err := NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	contents := &Contents{}
+	components := map[string]*Component{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		name := path.Clean(header.Name)
+		if name == MetadataFileName {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			contents.Metadata = &Metadata{}
+			err = contents.Metadata.FromYaml(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		componentPath := ComponentPath(name)
+		c, ok := components[componentPath]
+		if !ok {
+			c = &Component{Path: componentPath}
+			components[componentPath] = c
+		}
+		c.Files++
+		// Hard links don't take up any space in the archive
+		c.Size += header.Size
+		contents.TotalSize += header.Size
+	}
+
+	if contents.Metadata == nil {
+		return nil, errors.Errorf("Bundle %s doesn't contain a %s file", bundle, MetadataFileName)
+	}
+
+	for _, c := range components {
+		contents.Components = append(contents.Components, c)
+	}
+	sort.Slice(contents.Components, func(i, j int) bool {
+		return contents.Components[i].Path < contents.Components[j].Path
+	})
+	return contents, nil
+}
+
+// ComponentPath returns the component to which an archive entry is
+// attributed. The files of libFuzzer and coverage fuzz tests are stored
+// below <engine>/<sanitizers>/<fuzz test>, all other files are grouped
+// by their top-level directory.
+func ComponentPath(name string) string {
+	segments := strings.Split(name, "/")
+	switch segments[0] {
+	case "libfuzzer", "replayer":
+		if len(segments) > 3 {
+			return strings.Join(segments[:3], "/")
+		}
+	}
+	return segments[0]
+}
+
+// FormatSize returns a human-readable representation of a size in
+// bytes.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
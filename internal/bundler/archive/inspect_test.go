@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestReadContents(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "inspect-")
+	writeFile(t, filepath.Join(dir, "fuzz_test"), "0123456789", 0o755)
+	metadata := &Metadata{
+		RunEnvironment: &RunEnvironment{Docker: "ubuntu:rolling"},
+		Fuzzers: []*Fuzzer{{
+			Target:    "my_fuzz_test",
+			Path:      "libfuzzer/address/my_fuzz_test/bin/my_fuzz_test",
+			Engine:    "LIBFUZZER",
+			Sanitizer: "ADDRESS",
+		}},
+	}
+	data, err := metadata.ToYaml()
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dir, MetadataFileName), string(data), 0o644)
+
+	bundle := filepath.Join(dir, "bundle.tar.gz")
+	f, err := os.Create(bundle)
+	require.NoError(t, err)
+	w := NewTarArchiveWriter(f, true)
+	require.NoError(t, w.WriteFile(MetadataFileName, filepath.Join(dir, MetadataFileName)))
+	require.NoError(t, w.WriteFile("libfuzzer/address/my_fuzz_test/bin/my_fuzz_test", filepath.Join(dir, "fuzz_test")))
+	require.NoError(t, w.WriteFile("libfuzzer/address/my_fuzz_test/seeds/a", filepath.Join(dir, "fuzz_test")))
+	require.NoError(t, w.WriteFile("cas/ab/cdef/libfoo.so", filepath.Join(dir, "fuzz_test")))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	contents, err := ReadContents(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, contents.Metadata)
+	assert.Equal(t, []*Component{
+		{Path: MetadataFileName, Files: 1, Size: int64(len(data))},
+		{Path: "cas", Files: 1, Size: 10},
+		{Path: "libfuzzer/address/my_fuzz_test", Files: 2, Size: 20},
+	}, contents.Components)
+	assert.Equal(t, int64(len(data))+30, contents.TotalSize)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KiB", FormatSize(1536))
+	assert.Equal(t, "2.0 GiB", FormatSize(2*1024*1024*1024))
+}
@@ -0,0 +1,299 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManifestFileName is the name of the file in the bundle which
+	// lists the SHA-256 checksums of all other files of the bundle, in
+	// the format produced by the sha256sum tool.
+	ManifestFileName = "manifest.sha256"
+	// SignatureFileName is the name of the file in the bundle which
+	// contains the base64 encoded ed25519 signature of the manifest.
+	SignatureFileName = ManifestFileName + ".sig"
+)
+
+// Manifest maps the archive paths of the files of a bundle to their
+// hex encoded SHA-256 checksums.
+type Manifest struct {
+	Checksums map[string]string
+}
+
+// ParseManifest parses a manifest in the format produced by sha256sum.
+func ParseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{Checksums: map[string]string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		checksum, name, found := strings.Cut(line, "  ")
+		if !found || len(checksum) != 2*sha256.Size || name == "" {
+			return nil, errors.Errorf("Invalid line in %s: %q", ManifestFileName, line)
+		}
+		if _, err := hex.DecodeString(checksum); err != nil {
+			return nil, errors.Errorf("Invalid checksum in %s: %q", ManifestFileName, line)
+		}
+		m.Checksums[name] = checksum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return m, nil
+}
+
+// Bytes returns the manifest in the format produced by sha256sum,
+// sorted by path, so that it can be checked with "sha256sum -c".
+func (m *Manifest) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	for _, name := range sortedKeys(m.Checksums) {
+		fmt.Fprintf(buf, "%s  %s\n", m.Checksums[name], name)
+	}
+	return buf.Bytes()
+}
+
+// Verify checks that all files listed in the manifest exist in dir and
+// have the expected checksums. It returns a description of each file
+// which doesn't match. Files which are not listed in the manifest are
+// not checked, see Unlisted.
+func (m *Manifest) Verify(dir string) ([]string, error) {
+	var problems []string
+	for _, name := range sortedKeys(m.Checksums) {
+		cleaned := path.Clean(name)
+		if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			problems = append(problems, fmt.Sprintf("%s points outside of the bundle", name))
+			continue
+		}
+		checksum, err := sha256sum(filepath.Join(dir, filepath.FromSlash(name)))
+		if os.IsNotExist(errors.Cause(err)) {
+			problems = append(problems, fmt.Sprintf("%s is missing", name))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if checksum != m.Checksums[name] {
+			problems = append(problems, fmt.Sprintf("%s has been modified (checksum %s, expected %s)", name, checksum, m.Checksums[name]))
+		}
+	}
+	return problems, nil
+}
+
+// Unlisted returns the archive paths of the files in dir which are not
+// listed in the manifest, apart from the manifest and its signature.
+func (m *Manifest) Unlisted(dir string) ([]string, error) {
+	var unlisted []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		name := filepath.ToSlash(rel)
+		if name == ManifestFileName || name == SignatureFileName {
+			return nil
+		}
+		if _, ok := m.Checksums[name]; !ok {
+			unlisted = append(unlisted, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unlisted, nil
+}
+
+// ReadSigningKey reads an ed25519 private key from a PEM encoded PKCS #8
+// file, as created by "openssl genpkey -algorithm ed25519".
+func ReadSigningKey(keyPath string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse signing key %s", keyPath)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("Signing key %s is not an ed25519 key", keyPath)
+	}
+	return privateKey, nil
+}
+
+// ReadPublicKey reads an ed25519 public key from a PEM encoded PKIX
+// file, as created by "openssl pkey -pubout".
+func ReadPublicKey(keyPath string) (ed25519.PublicKey, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse public key %s", keyPath)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("Public key %s is not an ed25519 key", keyPath)
+	}
+	return publicKey, nil
+}
+
+func readPEM(keyPath string) (*pem.Block, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("%s does not contain a PEM encoded key", keyPath)
+	}
+	return block, nil
+}
+
+// SignManifest returns the base64 encoded ed25519 signature of the
+// manifest.
+func SignManifest(key ed25519.PrivateKey, manifest []byte) []byte {
+	signature := ed25519.Sign(key, manifest)
+	return []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+}
+
+// VerifyManifestSignature checks that signature is a valid base64
+// encoded ed25519 signature of the manifest.
+func VerifyManifestSignature(key ed25519.PublicKey, manifest, signature []byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode the manifest signature")
+	}
+	if !ed25519.Verify(key, manifest, decoded) {
+		return errors.New("The manifest signature is invalid")
+	}
+	return nil
+}
+
+// IntegrityOpts configure how VerifyIntegrity checks a bundle.
+type IntegrityOpts struct {
+	// If set, the manifest must be signed by the corresponding private
+	// key
+	PublicKey ed25519.PublicKey
+	// Path to a detached signature file. If empty, the signature
+	// contained in the bundle is used.
+	SignaturePath string
+	// If set, files in the bundle directory which are not listed in the
+	// manifest are accepted. That's the case when the bundle is run in
+	// a container, whose build context adds the Dockerfile and the
+	// cifuzz binaries to the bundle directory.
+	AllowUnlisted bool
+}
+
+// VerifyIntegrity checks the checksum manifest of the bundle which was
+// extracted to dir and, if a public key is provided, the signature of
+// the manifest. Bundles without a manifest are only accepted if no
+// public key is provided. Unless opts.AllowUnlisted is set, dir must not
+// contain any files apart from the ones listed in the manifest and the
+// manifest and its signature.
+func VerifyIntegrity(dir string, opts *IntegrityOpts) error {
+	manifestData, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if os.IsNotExist(err) {
+		if opts.PublicKey != nil {
+			return errors.Errorf("The bundle is not signed, it doesn't contain a %s file", ManifestFileName)
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Check the signature before the checksums, the checksums can only
+	// be trusted if the manifest itself can be trusted
+	if opts.PublicKey != nil {
+		signaturePath := opts.SignaturePath
+		if signaturePath == "" {
+			signaturePath = filepath.Join(dir, SignatureFileName)
+		}
+		signature, err := os.ReadFile(signaturePath)
+		if os.IsNotExist(err) {
+			return errors.Errorf("The bundle is not signed, signature file %s doesn't exist", signaturePath)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = VerifyManifestSignature(opts.PublicKey, manifestData, signature)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, err := ParseManifest(manifestData)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	if !opts.AllowUnlisted {
+		unlisted, err := manifest.Unlisted(dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range unlisted {
+			problems = append(problems, fmt.Sprintf("%s is not listed in %s", name, ManifestFileName))
+		}
+	}
+	modified, err := manifest.Verify(dir)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, modified...)
+	if len(problems) > 0 {
+		return errors.Errorf("The bundle doesn't match its checksum manifest:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func sha256sum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
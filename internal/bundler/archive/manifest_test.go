@@ -0,0 +1,172 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/util/archiveutil"
+)
+
+func writeKeys(t *testing.T, dir string) (string, string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "key.pem")
+	writeFile(t, privatePath, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})), 0o600)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "key.pub")
+	writeFile(t, publicPath, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})), 0o644)
+
+	return privatePath, publicPath
+}
+
+func TestManifest_RoundTrip(t *testing.T) {
+	manifest := &Manifest{Checksums: map[string]string{
+		"b/file":     "0000000000000000000000000000000000000000000000000000000000000001",
+		"a file.txt": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}}
+	data := manifest.Bytes()
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  a file.txt\n"+
+		"0000000000000000000000000000000000000000000000000000000000000001  b/file\n", string(data))
+
+	parsed, err := ParseManifest(data)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, parsed)
+
+	_, err = ParseManifest([]byte("1234  file\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "manifest-")
+	signingKey, publicKeyPath := writeKeys(t, dir)
+	privateKey, err := ReadSigningKey(signingKey)
+	require.NoError(t, err)
+	publicKey, err := ReadPublicKey(publicKeyPath)
+	require.NoError(t, err)
+
+	writeFile(t, filepath.Join(dir, "src", "fuzz_test"), "#!/bin/sh", 0o755)
+	writeFile(t, filepath.Join(dir, "src", "seeds", "seed"), "seed", 0o644)
+
+	for _, reproducible := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		var w *TarArchiveWriter
+		if reproducible {
+			w, err = NewReproducibleTarArchiveWriter(buf, CompressionNone, time.Unix(0, 0))
+			require.NoError(t, err)
+		} else {
+			w = NewTarArchiveWriter(buf, false)
+		}
+		require.NoError(t, w.WriteDir("fuzz_test", filepath.Join(dir, "src")))
+		require.NoError(t, w.WriteHardLink("fuzz_test/fuzz_test", "link"))
+		signature, err := w.WriteManifest(privateKey, false)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		extractDir := testutil.MkdirTemp(t, "", "manifest-extract-")
+		require.NoError(t, archiveutil.Untar(buf, extractDir))
+
+		manifestData, err := os.ReadFile(filepath.Join(extractDir, ManifestFileName))
+		require.NoError(t, err)
+		manifest, err := ParseManifest(manifestData)
+		require.NoError(t, err)
+		assert.Len(t, manifest.Checksums, 3)
+		assert.Equal(t, manifest.Checksums["fuzz_test/fuzz_test"], manifest.Checksums["link"])
+		assert.FileExists(t, filepath.Join(extractDir, SignatureFileName))
+		require.NoError(t, VerifyManifestSignature(publicKey, manifestData, signature))
+
+		opts := &IntegrityOpts{PublicKey: publicKey}
+		require.NoError(t, VerifyIntegrity(extractDir, opts))
+
+		// Files which are not listed in the manifest are rejected
+		writeFile(t, filepath.Join(extractDir, "fuzz_test", "injected.so"), "", 0o644)
+		unlisted, err := manifest.Unlisted(extractDir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fuzz_test/injected.so"}, unlisted)
+		err = VerifyIntegrity(extractDir, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fuzz_test/injected.so is not listed in "+ManifestFileName)
+		// ...unless unlisted files are allowed
+		require.NoError(t, VerifyIntegrity(extractDir, &IntegrityOpts{PublicKey: publicKey, AllowUnlisted: true}))
+		require.NoError(t, os.Remove(filepath.Join(extractDir, "fuzz_test", "injected.so")))
+		require.NoError(t, VerifyIntegrity(extractDir, opts))
+
+		writeFile(t, filepath.Join(extractDir, "fuzz_test", "seeds", "seed"), "modified", 0o644)
+		err = VerifyIntegrity(extractDir, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fuzz_test/seeds/seed has been modified")
+
+		// A manifest which was modified to match the files is detected
+		// by the signature check
+		writeFile(t, filepath.Join(extractDir, ManifestFileName), "", 0o644)
+		err = VerifyIntegrity(extractDir, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signature is invalid")
+		// Without a public key, the empty manifest doesn't cover the
+		// files of the bundle
+		err = VerifyIntegrity(extractDir, &IntegrityOpts{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "link is not listed in "+ManifestFileName)
+	}
+}
+
+func TestWriteManifest_DetachedSignature(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "manifest-")
+	signingKey, publicKeyPath := writeKeys(t, dir)
+	privateKey, err := ReadSigningKey(signingKey)
+	require.NoError(t, err)
+	publicKey, err := ReadPublicKey(publicKeyPath)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dir, "file"), "content", 0o644)
+
+	buf := &bytes.Buffer{}
+	w := NewTarArchiveWriter(buf, false)
+	require.NoError(t, w.WriteFile("file", filepath.Join(dir, "file")))
+	signature, err := w.WriteManifest(privateKey, true)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	extractDir := testutil.MkdirTemp(t, "", "manifest-extract-")
+	require.NoError(t, archiveutil.Untar(buf, extractDir))
+	assert.NoFileExists(t, filepath.Join(extractDir, SignatureFileName))
+
+	// Without the detached signature, the bundle is not considered
+	// signed
+	err = VerifyIntegrity(extractDir, &IntegrityOpts{PublicKey: publicKey})
+	require.Error(t, err)
+
+	signaturePath := filepath.Join(dir, "bundle.sig")
+	writeFile(t, signaturePath, string(signature), 0o644)
+	err = VerifyIntegrity(extractDir, &IntegrityOpts{PublicKey: publicKey, SignaturePath: signaturePath})
+	require.NoError(t, err)
+
+	// A signature created with another key is rejected
+	_, otherPublicKeyPath := writeKeys(t, testutil.MkdirTemp(t, "", "other-key-"))
+	otherPublicKey, err := ReadPublicKey(otherPublicKeyPath)
+	require.NoError(t, err)
+	err = VerifyIntegrity(extractDir, &IntegrityOpts{PublicKey: otherPublicKey, SignaturePath: signaturePath})
+	require.Error(t, err)
+}
+
+func TestReadSigningKey_Invalid(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "manifest-")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeFile(t, keyPath, "not a key", 0o600)
+	_, err := ReadSigningKey(keyPath)
+	assert.Error(t, err)
+}
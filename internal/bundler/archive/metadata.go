@@ -2,6 +2,8 @@ package archive
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -15,6 +17,7 @@ type Metadata struct {
 	*RunEnvironment `yaml:"run_environment"`
 	CodeRevision    *CodeRevision `yaml:"code_revision,omitempty"`
 	Fuzzers         []*Fuzzer     `yaml:"fuzzers"`
+	Sources         *Sources      `yaml:"sources,omitempty"`
 }
 
 // Fuzzer specifies the type and locations of fuzzers contained in the archive.
@@ -42,6 +45,35 @@ type RunEnvironment struct {
 	Docker string
 }
 
+// Sources describes the source files of the project which are contained
+// in the archive. They are only included if the bundle was created with
+// --include-sources.
+type Sources struct {
+	// The directory inside the archive which contains the source files
+	Dir string `yaml:"dir"`
+	// The directory on the build machine which corresponds to Dir.
+	// Source paths in the debug info of the fuzzers and in stack traces
+	// are below this directory.
+	BuildDir string `yaml:"build_dir"`
+	// The roots of the Java package hierarchies, relative to Dir
+	JavaSourceRoots []string `yaml:"java_source_roots,omitempty"`
+}
+
+// LocalPath returns the path of the source file inside the archive
+// which corresponds to the given path on the build machine. Relative
+// paths are interpreted as relative to BuildDir. The second return
+// value is false if the path is not below BuildDir.
+func (s *Sources) LocalPath(buildPath string) (string, bool) {
+	if !filepath.IsAbs(buildPath) {
+		buildPath = filepath.Join(s.BuildDir, buildPath)
+	}
+	relPath, err := filepath.Rel(s.BuildDir, buildPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(s.Dir, relPath), true
+}
+
 type CodeRevision struct {
 	Git *GitRevision `yaml:"git,omitempty" json:"git_revision,omitempty"`
 }
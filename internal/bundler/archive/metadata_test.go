@@ -0,0 +1,44 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources_LocalPath(t *testing.T) {
+	buildDir, err := filepath.Abs(filepath.Join("build", "machine", "project"))
+	require.NoError(t, err)
+	sources := &Sources{Dir: "src", BuildDir: buildDir}
+
+	path, ok := sources.LocalPath(filepath.Join(buildDir, "lib", "api.cpp"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("src", "lib", "api.cpp"), path)
+
+	path, ok = sources.LocalPath(filepath.Join("lib", "api.cpp"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("src", "lib", "api.cpp"), path)
+
+	_, ok = sources.LocalPath(filepath.Join(filepath.Dir(buildDir), "other", "api.cpp"))
+	assert.False(t, ok)
+}
+
+func TestMetadata_Sources(t *testing.T) {
+	metadata := &Metadata{
+		RunEnvironment: &RunEnvironment{Docker: "eclipse-temurin:20"},
+		Sources: &Sources{
+			Dir:             "src",
+			BuildDir:        "/home/user/project",
+			JavaSourceRoots: []string{"src/main/java", "src/test/java"},
+		},
+	}
+	out, err := metadata.ToYaml()
+	require.NoError(t, err)
+
+	parsed := &Metadata{}
+	err = parsed.FromYaml(out)
+	require.NoError(t, err)
+	assert.Equal(t, metadata.Sources, parsed.Sources)
+}
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// SBOMFileName is the name of the file in the bundle which contains the
+// software bill of materials in the CycloneDX JSON format.
+const SBOMFileName = "sbom.cdx.json"
+
+// SBOM is a CycloneDX software bill of materials which lists the third
+// party components contained in a bundle. Only the subset of the
+// CycloneDX 1.5 specification which is needed for that is supported.
+type SBOM struct {
+	BOMFormat    string           `json:"bomFormat"`
+	SpecVersion  string           `json:"specVersion"`
+	SerialNumber string           `json:"serialNumber,omitempty"`
+	Version      int              `json:"version"`
+	Metadata     *SBOMMetadata    `json:"metadata,omitempty"`
+	Components   []*SBOMComponent `json:"components"`
+}
+
+type SBOMMetadata struct {
+	Timestamp string         `json:"timestamp,omitempty"`
+	Tools     *SBOMTools     `json:"tools,omitempty"`
+	Component *SBOMComponent `json:"component,omitempty"`
+}
+
+type SBOMTools struct {
+	Components []*SBOMComponent `json:"components"`
+}
+
+// SBOMComponent is a CycloneDX component. The BOMRef uniquely identifies
+// the component within the SBOM.
+type SBOMComponent struct {
+	BOMRef  string     `json:"bom-ref,omitempty"`
+	Type    string     `json:"type"`
+	Group   string     `json:"group,omitempty"`
+	Name    string     `json:"name"`
+	Version string     `json:"version,omitempty"`
+	PURL    string     `json:"purl,omitempty"`
+	Hashes  []SBOMHash `json:"hashes,omitempty"`
+}
+
+type SBOMHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+// NewSBOM creates an empty SBOM. If reproducible is false, it's assigned
+// a random serial number.
+func NewSBOM(reproducible bool) (*SBOM, error) {
+	s := &SBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Components:  []*SBOMComponent{},
+	}
+	if !reproducible {
+		serial, err := randomUUID()
+		if err != nil {
+			return nil, err
+		}
+		s.SerialNumber = "urn:uuid:" + serial
+	}
+	return s, nil
+}
+
+// AddComponent adds a component to the SBOM, unless a component with the
+// same BOMRef was already added. If the BOMRef is empty, it's set to the
+// package URL or, if that is empty too, to the name and version.
+func (s *SBOM) AddComponent(c *SBOMComponent) {
+	if c.BOMRef == "" {
+		c.BOMRef = c.PURL
+	}
+	if c.BOMRef == "" {
+		c.BOMRef = c.Name
+		if c.Version != "" {
+			c.BOMRef += "@" + c.Version
+		}
+	}
+	for _, existing := range s.Components {
+		if existing.BOMRef == c.BOMRef {
+			return
+		}
+	}
+	s.Components = append(s.Components, c)
+}
+
+// ToJSON returns the SBOM in the CycloneDX JSON format. The components
+// are sorted, so that the output only depends on the set of components.
+func (s *SBOM) ToJSON() ([]byte, error) {
+	sort.Slice(s.Components, func(i, j int) bool {
+		return s.Components[i].BOMRef < s.Components[j].BOMRef
+	})
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(out, '\n'), nil
+}
+
+// randomUUID returns a random (version 4) UUID.
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,37 @@
+package archive
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSBOM(t *testing.T) {
+	sbom, err := NewSBOM(false)
+	require.NoError(t, err)
+	assert.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, sbom.SerialNumber)
+
+	sbom, err = NewSBOM(true)
+	require.NoError(t, err)
+	assert.Empty(t, sbom.SerialNumber)
+
+	sbom.AddComponent(&SBOMComponent{Type: "library", Name: "zlib", Version: "1.2.13"})
+	sbom.AddComponent(&SBOMComponent{Type: "library", Group: "com.google.guava", Name: "guava", Version: "32.1.2-jre",
+		PURL: "pkg:maven/com.google.guava/guava@32.1.2-jre"})
+	// Components are only added once
+	sbom.AddComponent(&SBOMComponent{Type: "library", Name: "zlib", Version: "1.2.13"})
+	require.Len(t, sbom.Components, 2)
+
+	out, err := sbom.ToJSON()
+	require.NoError(t, err)
+	parsed := &SBOM{}
+	require.NoError(t, json.Unmarshal(out, parsed))
+	assert.Equal(t, "CycloneDX", parsed.BOMFormat)
+	assert.Equal(t, "1.5", parsed.SpecVersion)
+	require.Len(t, parsed.Components, 2)
+	// The components are sorted by their BOM reference
+	assert.Equal(t, "pkg:maven/com.google.guava/guava@32.1.2-jre", parsed.Components[0].BOMRef)
+	assert.Equal(t, "zlib@1.2.13", parsed.Components[1].BOMRef)
+}
@@ -0,0 +1,320 @@
+package archive
+
+import (
+	"archive/zip"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VerificationResult contains the problems found by Verify. Errors
+// make the bundle unusable, warnings point out things which have to be
+// provided by the run environment.
+type VerificationResult struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (r *VerificationResult) OK() bool {
+	return len(r.Errors) == 0
+}
+
+func (r *VerificationResult) errorf(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *VerificationResult) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Libraries which are part of any reasonable Docker image, so we don't
+// warn if they are not contained in the bundle.
+var wellKnownSystemLibraries = regexp.MustCompile(
+	`^(ld-linux.*|linux-vdso|libc|libdl|libgcc_s|libm|libpthread|libresolv|librt|libstdc\+\+|libutil)\.so[.0-9]*$`)
+
+// Verify checks that the bundle which was extracted to dir is complete,
+// i.e. that all files referenced by the fuzzers of the metadata exist,
+// that fuzzer binaries are executable and that their shared library
+// dependencies can be resolved within the bundle.
+func Verify(dir string, metadata *Metadata) (*VerificationResult, error) {
+	result := &VerificationResult{}
+	if len(metadata.Fuzzers) == 0 {
+		result.errorf("%s doesn't contain any fuzzers", MetadataFileName)
+	}
+
+	for _, fuzzer := range metadata.Fuzzers {
+		v := &fuzzerVerifier{dir: dir, fuzzer: fuzzer, result: result}
+		err := v.verify()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type fuzzerVerifier struct {
+	dir    string
+	fuzzer *Fuzzer
+	result *VerificationResult
+}
+
+func (v *fuzzerVerifier) verify() error {
+	if v.fuzzer.Path != "" {
+		path, ok := v.resolve("path", v.fuzzer.Path, false)
+		// The path of a Jazzer.js fuzzer is the fuzz test file, which
+		// is run via Jest, not executed
+		if ok && v.fuzzer.Engine != "JAZZER_JS" {
+			err := v.verifyBinary(path)
+			if err != nil {
+				return err
+			}
+		}
+	} else if v.fuzzer.Engine != "JAVA_LIBFUZZER" {
+		v.errorf("no path to the fuzzer executable")
+	}
+
+	if v.fuzzer.Seeds != "" {
+		v.resolve("seeds", v.fuzzer.Seeds, true)
+	}
+	if v.fuzzer.Dictionary != "" {
+		v.resolve("dictionary", v.fuzzer.Dictionary, false)
+	}
+	for _, libraryPath := range v.fuzzer.LibraryPaths {
+		v.resolve("library path", libraryPath, true)
+	}
+
+	var runtimePaths []string
+	for _, runtimePath := range v.fuzzer.RuntimePaths {
+		path, ok := v.resolveAny("runtime path", runtimePath)
+		if ok {
+			runtimePaths = append(runtimePaths, path)
+		}
+	}
+	if v.fuzzer.Engine == "JAVA_LIBFUZZER" {
+		return v.verifyTargetClass(runtimePaths)
+	}
+	return nil
+}
+
+func (v *fuzzerVerifier) errorf(format string, args ...any) {
+	v.result.errorf("%s: %s", v.name(), fmt.Sprintf(format, args...))
+}
+
+func (v *fuzzerVerifier) name() string {
+	name := v.fuzzer.Name
+	if name == "" {
+		name = v.fuzzer.Target
+	}
+	if name == "" {
+		name = v.fuzzer.Path
+	}
+	if v.fuzzer.Sanitizer != "" {
+		return fmt.Sprintf("%s (%s, %s)", name, v.fuzzer.Engine, v.fuzzer.Sanitizer)
+	}
+	return fmt.Sprintf("%s (%s)", name, v.fuzzer.Engine)
+}
+
+// resolve returns the path of an entry of the bundle and checks that
+// it exists and is a directory or a file, as requested.
+func (v *fuzzerVerifier) resolve(kind, archivePath string, isDir bool) (string, bool) {
+	path, ok := v.resolveAny(kind, archivePath)
+	if !ok {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.errorf("%s %s: %v", kind, archivePath, err)
+		return "", false
+	}
+	if isDir && !info.IsDir() {
+		v.errorf("%s %s is not a directory", kind, archivePath)
+		return "", false
+	}
+	if !isDir && info.IsDir() {
+		v.errorf("%s %s is a directory", kind, archivePath)
+		return "", false
+	}
+	return path, true
+}
+
+func (v *fuzzerVerifier) resolveAny(kind, archivePath string) (string, bool) {
+	if filepath.IsAbs(archivePath) || strings.HasPrefix(archivePath, "/") {
+		v.errorf("%s %s is not relative to the bundle root", kind, archivePath)
+		return "", false
+	}
+	path := filepath.Join(v.dir, filepath.FromSlash(archivePath))
+	rel, err := filepath.Rel(v.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		v.errorf("%s %s points outside of the bundle", kind, archivePath)
+		return "", false
+	}
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		v.errorf("%s %s does not exist in the bundle", kind, archivePath)
+		return "", false
+	}
+	if err != nil {
+		v.errorf("%s %s: %v", kind, archivePath, err)
+		return "", false
+	}
+	return path, true
+}
+
+func (v *fuzzerVerifier) verifyBinary(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Bundles are executed on Linux, but file modes are not preserved
+	// when extracting them on Windows
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		v.errorf("%s is not executable", v.fuzzer.Path)
+	}
+
+	// Check the shared library dependencies of the binary and the
+	// libraries it depends on
+	checked := map[string]bool{}
+	queue := []string{path}
+	for len(queue) > 0 {
+		object := queue[0]
+		queue = queue[1:]
+		if checked[object] {
+			continue
+		}
+		checked[object] = true
+
+		needed, searchDirs, err := v.dynamicDependencies(object)
+		if err != nil {
+			return err
+		}
+		for _, lib := range needed {
+			libPath := findLibrary(lib, searchDirs)
+			if libPath != "" {
+				queue = append(queue, libPath)
+				continue
+			}
+			if wellKnownSystemLibraries.MatchString(lib) {
+				continue
+			}
+			rel, _ := filepath.Rel(v.dir, object)
+			v.result.warnf("%s: shared library %s required by %s is not contained in the bundle and must be provided by the docker image",
+				v.name(), lib, filepath.ToSlash(rel))
+		}
+	}
+	return nil
+}
+
+// dynamicDependencies returns the shared libraries required by an ELF
+// object and the directories of the bundle in which they are searched.
+// Files which are not ELF objects (e.g. scripts) have no dependencies.
+func (v *fuzzerVerifier) dynamicDependencies(object string) ([]string, []string, error) {
+	f, err := elf.Open(object)
+	if err != nil {
+		var formatErr *elf.FormatError
+		if errors.As(err, &formatErr) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	needed, err := f.ImportedLibraries()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	var searchDirs []string
+	for _, tag := range []elf.DynTag{elf.DT_RPATH, elf.DT_RUNPATH} {
+		values, err := f.DynString(tag)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		for _, value := range values {
+			for _, dir := range strings.Split(value, ":") {
+				// Absolute search paths refer to the machine on which the
+				// bundle was created, only paths relative to the object
+				// point into the bundle
+				if !strings.HasPrefix(dir, "$ORIGIN") && !strings.HasPrefix(dir, "${ORIGIN}") {
+					continue
+				}
+				dir = strings.TrimPrefix(strings.TrimPrefix(dir, "${ORIGIN}"), "$ORIGIN")
+				searchDirs = append(searchDirs, filepath.Join(filepath.Dir(object), filepath.FromSlash(dir)))
+			}
+		}
+	}
+	for _, libraryPath := range v.fuzzer.LibraryPaths {
+		searchDirs = append(searchDirs, filepath.Join(v.dir, filepath.FromSlash(libraryPath)))
+	}
+	return needed, searchDirs, nil
+}
+
+func findLibrary(lib string, searchDirs []string) string {
+	for _, dir := range searchDirs {
+		path := filepath.Join(dir, lib)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// verifyTargetClass checks that the class of the Java fuzz test is
+// contained in one of the runtime paths, either as class file in a
+// directory or as an entry of a JAR.
+func (v *fuzzerVerifier) verifyTargetClass(runtimePaths []string) error {
+	// The name can include the name of the fuzz test method
+	class, _, _ := strings.Cut(v.fuzzer.Name, "::")
+	if class == "" {
+		v.errorf("no target class")
+		return nil
+	}
+	classFile := strings.ReplaceAll(class, ".", "/") + ".class"
+
+	for _, runtimePath := range runtimePaths {
+		info, err := os.Stat(runtimePath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.IsDir() {
+			_, err = os.Stat(filepath.Join(runtimePath, filepath.FromSlash(classFile)))
+			if err == nil {
+				return nil
+			}
+			continue
+		}
+		if filepath.Ext(runtimePath) != ".jar" {
+			continue
+		}
+		found, err := jarContains(runtimePath, classFile)
+		if err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+	}
+
+	v.errorf("target class %s not found in the runtime paths", class)
+	return nil
+}
+
+func jarContains(jar, name string) (bool, error) {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to open %s", jar)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
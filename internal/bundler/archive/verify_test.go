@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func writeFile(t *testing.T, path string, content string, perm os.FileMode) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+}
+
+func TestVerify(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "verify-")
+	writeFile(t, filepath.Join(dir, "libfuzzer", "address", "my_fuzz_test", "bin", "my_fuzz_test"), "#!/bin/sh", 0o755)
+	writeFile(t, filepath.Join(dir, "libfuzzer", "address", "my_fuzz_test", "dict"), "", 0o644)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "libfuzzer", "address", "my_fuzz_test", "seeds"), 0o755))
+
+	metadata := &Metadata{
+		Fuzzers: []*Fuzzer{{
+			Target:     "my_fuzz_test",
+			Path:       "libfuzzer/address/my_fuzz_test/bin/my_fuzz_test",
+			Engine:     "LIBFUZZER",
+			Sanitizer:  "ADDRESS",
+			Dictionary: "libfuzzer/address/my_fuzz_test/dict",
+			Seeds:      "libfuzzer/address/my_fuzz_test/seeds",
+		}},
+	}
+	result, err := Verify(dir, metadata)
+	require.NoError(t, err)
+	assert.True(t, result.OK(), result.Errors)
+	assert.Empty(t, result.Warnings)
+
+	// Missing and invalid entries are reported
+	metadata.Fuzzers[0].Seeds = "libfuzzer/address/my_fuzz_test/dict"
+	metadata.Fuzzers[0].Dictionary = "libfuzzer/address/my_fuzz_test/missing.dict"
+	metadata.Fuzzers[0].LibraryPaths = []string{"../outside"}
+	result, err = Verify(dir, metadata)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"my_fuzz_test (LIBFUZZER, ADDRESS): seeds libfuzzer/address/my_fuzz_test/dict is not a directory",
+		"my_fuzz_test (LIBFUZZER, ADDRESS): dictionary libfuzzer/address/my_fuzz_test/missing.dict does not exist in the bundle",
+		"my_fuzz_test (LIBFUZZER, ADDRESS): library path ../outside points outside of the bundle",
+	}, result.Errors)
+}
+
+func TestVerify_NotExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes are not checked on Windows")
+	}
+	dir := testutil.MkdirTemp(t, "", "verify-")
+	writeFile(t, filepath.Join(dir, "fuzz_test"), "#!/bin/sh", 0o644)
+
+	result, err := Verify(dir, &Metadata{Fuzzers: []*Fuzzer{{Target: "fuzz_test", Path: "fuzz_test", Engine: "LIBFUZZER"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fuzz_test (LIBFUZZER): fuzz_test is not executable"}, result.Errors)
+}
+
+func TestVerify_JavaTargetClass(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "verify-")
+	writeFile(t, filepath.Join(dir, "runtime_deps", "classes", "com", "example", "FuzzTest.class"), "", 0o644)
+
+	// Create a JAR containing another fuzz test class
+	jarPath := filepath.Join(dir, "runtime_deps", "fuzz.jar")
+	jar, err := os.Create(jarPath)
+	require.NoError(t, err)
+	zw := zip.NewWriter(jar)
+	_, err = zw.Create("com/example/JarFuzzTest.class")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, jar.Close())
+
+	runtimePaths := []string{"runtime_deps/classes", "runtime_deps/fuzz.jar"}
+	metadata := &Metadata{Fuzzers: []*Fuzzer{
+		{Name: "com.example.FuzzTest::myFuzzTest", Engine: "JAVA_LIBFUZZER", RuntimePaths: runtimePaths},
+		{Name: "com.example.JarFuzzTest", Engine: "JAVA_LIBFUZZER", RuntimePaths: runtimePaths},
+		{Name: "com.example.MissingFuzzTest", Engine: "JAVA_LIBFUZZER", RuntimePaths: runtimePaths},
+	}}
+	result, err := Verify(dir, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"com.example.MissingFuzzTest (JAVA_LIBFUZZER): target class com.example.MissingFuzzTest not found in the runtime paths",
+	}, result.Errors)
+}
@@ -2,16 +2,19 @@ package bundler
 
 import (
 	"bufio"
+	"crypto/ed25519"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/internal/bundler/archive"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/version"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/vcs"
 	"code-intelligence.com/cifuzz/util/fileutil"
@@ -38,6 +41,16 @@ func New(opts *Opts) *Bundler {
 func (b *Bundler) Bundle() (*BundleResult, error) {
 	var err error
 
+	// Read the signing key before building anything, so that we fail
+	// early if it's invalid
+	var signingKey ed25519.PrivateKey
+	if b.opts.SigningKey != "" {
+		signingKey, err = archive.ReadSigningKey(b.opts.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create temp dir
 	b.opts.tempDir, err = os.MkdirTemp("", "cifuzz-bundle-")
 	if err != nil {
@@ -53,15 +66,43 @@ func (b *Bundler) Bundle() (*BundleResult, error) {
 	// if an error occurs during bundling we should make sure that
 	// the bundle gets removed
 	defer func() {
+		if bundle == os.Stdout {
+			return
+		}
 		bundle.Close()
 		if err != nil {
 			os.Remove(bundle.Name())
 		}
 	}()
 
+	compression, err := archive.ParseCompression(b.opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create archive writer
 	bufWriter := bufio.NewWriter(bundle)
-	archiveWriter := archive.NewTarArchiveWriter(bufWriter, true)
+	var archiveWriter *archive.TarArchiveWriter
+	if b.opts.Reproducible {
+		var modTime time.Time
+		modTime, err = archive.ReproducibleModTime()
+		if err != nil {
+			return nil, err
+		}
+		archiveWriter, err = archive.NewReproducibleTarArchiveWriter(bufWriter, compression, modTime)
+	} else {
+		archiveWriter, err = archive.NewCompressedTarArchiveWriter(bufWriter, compression)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The bundlers add the third party components they bundle to the
+	// SBOM
+	b.opts.sbom, err = archive.NewSBOM(b.opts.Reproducible)
+	if err != nil {
+		return nil, err
+	}
 
 	var fuzzers []*archive.Fuzzer
 	switch b.opts.BuildSystem {
@@ -69,6 +110,8 @@ func (b *Bundler) Bundle() (*BundleResult, error) {
 		fuzzers, err = newLibfuzzerBundler(b.opts, archiveWriter).bundle()
 	case config.BuildSystemMaven, config.BuildSystemGradle:
 		fuzzers, err = newJazzerBundler(b.opts, archiveWriter).bundle()
+	case config.BuildSystemNodeJS:
+		fuzzers, err = newNodeJSBundler(b.opts, archiveWriter).bundle()
 	default:
 		err = errors.Errorf("Unknown build system for bundler: %s", b.opts.BuildSystem)
 	}
@@ -99,6 +142,23 @@ func (b *Bundler) Bundle() (*BundleResult, error) {
 		}
 	}
 
+	err = b.createSBOMFileInArchive(archiveWriter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manifest must be written after all other files were added
+	signature, err := archiveWriter.WriteManifest(signingKey, b.opts.DetachedSignature != "")
+	if err != nil {
+		return nil, err
+	}
+	if b.opts.DetachedSignature != "" {
+		err = os.WriteFile(b.opts.DetachedSignature, signature, 0o644)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to write detached signature")
+		}
+	}
+
 	// List contents of archive in verbose mode for easier debugging
 	// when we do not have access to the bundle itself
 	tableBuf := &strings.Builder{}
@@ -113,7 +173,7 @@ func (b *Bundler) Bundle() (*BundleResult, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	log.Debugf("Content of bundle %s:\n%s", bundle.Name(), tableBuf.String())
+	log.Debugf("Content of bundle %s:\n%s", b.opts.OutputPath, tableBuf.String())
 
 	err = archiveWriter.Close()
 	if err != nil {
@@ -123,25 +183,38 @@ func (b *Bundler) Bundle() (*BundleResult, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	err = bundle.Close()
-	if err != nil {
-		return nil, errors.WithStack(err)
+	if bundle != os.Stdout {
+		err = bundle.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 	}
 
 	fuzzTestNames := []string{}
 	for _, fuzzer := range fuzzers {
 		if fuzzer.Engine == "LIBFUZZER" {
 			fuzzTestNames = append(fuzzTestNames, fuzzer.Target)
-		} else if fuzzer.Engine == "JAVA_LIBFUZZER" {
+		} else if fuzzer.Engine == "JAVA_LIBFUZZER" || fuzzer.Engine == "JAZZER_JS" {
 			fuzzTestNames = append(fuzzTestNames, fuzzer.Name)
 		}
 	}
 
-	return &BundleResult{bundle.Name(), fuzzTestNames}, nil
+	return &BundleResult{b.opts.OutputPath, fuzzTestNames}, nil
 }
 
+// createEmptyBundle creates the bundle file. If the output path is
+// "-", the bundle is written to stdout.
 func (b *Bundler) createEmptyBundle() (*os.File, error) {
-	archiveExt := ".tar.gz"
+	if b.opts.OutputPath == "-" {
+		log.Debugf("Writing bundle to stdout")
+		return os.Stdout, nil
+	}
+
+	compression, err := archive.ParseCompression(b.opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+	archiveExt := compression.Extension()
 
 	if b.opts.OutputPath != "" {
 		// Check that outpath path makes sense
@@ -177,6 +250,9 @@ func (b *Bundler) determineDockerImageForBundle() string {
 		case config.BuildSystemMaven, config.BuildSystemGradle:
 			// Maven and Gradle should use a Docker image with Java
 			dockerImageUsedInBundle = "eclipse-temurin:20"
+		case config.BuildSystemNodeJS:
+			// Node.js projects need a Docker image with Node.js and npm
+			dockerImageUsedInBundle = "node:20"
 		}
 	}
 
@@ -193,6 +269,7 @@ func (b *Bundler) createMetadataFileInArchive(fuzzers []*archive.Fuzzer, archive
 			Docker: dockerImageUsedInBundle,
 		},
 		CodeRevision: b.getCodeRevision(),
+		Sources:      b.opts.sources,
 	}
 
 	metadataYamlContent, err := metadata.ToYaml()
@@ -215,6 +292,55 @@ func (b *Bundler) createMetadataFileInArchive(fuzzers []*archive.Fuzzer, archive
 	return nil
 }
 
+func (b *Bundler) createSBOMFileInArchive(archiveWriter archive.ArchiveWriter) error {
+	sbom := b.opts.sbom
+	sbom.Metadata = &archive.SBOMMetadata{
+		Tools: &archive.SBOMTools{Components: []*archive.SBOMComponent{
+			{Type: "application", Name: "cifuzz", Version: version.Version},
+		}},
+		Component: &archive.SBOMComponent{
+			Type: "application",
+			Name: filepath.Base(b.opts.ProjectDir),
+		},
+	}
+	if !b.opts.Reproducible {
+		sbom.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	sbomContent, err := sbom.ToJSON()
+	if err != nil {
+		return err
+	}
+	sbomPath := filepath.Join(b.opts.tempDir, archive.SBOMFileName)
+	err = os.WriteFile(sbomPath, sbomContent, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", archive.SBOMFileName)
+	}
+	err = archiveWriter.WriteFile(archive.SBOMFileName, sbomPath)
+	if err != nil {
+		return err
+	}
+
+	if b.opts.SBOMOutput != "" {
+		err = os.WriteFile(b.opts.SBOMOutput, sbomContent, 0o644)
+		if err != nil {
+			return errors.Wrap(err, "failed to write SBOM")
+		}
+		log.Debugf("Wrote SBOM to %s", b.opts.SBOMOutput)
+	}
+
+	return nil
+}
+
+// addSBOMComponent adds a third party component to the SBOM of the
+// bundle. Bundlers which were created without an SBOM (e.g. in tests)
+// ignore the component.
+func (opts *Opts) addSBOMComponent(c *archive.SBOMComponent) {
+	if opts.sbom != nil {
+		opts.sbom.AddComponent(c)
+	}
+}
+
 func (b *Bundler) createWorkDirInArchive(archiveWriter archive.ArchiveWriter) error {
 	// The fuzzing artifact archive spec requires this directory even if it is empty.
 	tempWorkDirPath := filepath.Join(b.opts.tempDir, archiveWorkDirPath)
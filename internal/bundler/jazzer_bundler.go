@@ -114,6 +114,25 @@ func (b *jazzerBundler) assembleArtifacts(fuzzTests []string, targetMethods []st
 		}
 	}
 
+	if b.opts.IncludeSources {
+		sourceFiles, sourceRoots := javaSourceFiles(rootDir, sourceMap)
+		n, err := writeSources(b.archiveWriter, rootDir, sourceFiles)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Added %d source files to the bundle", n)
+		b.opts.sources = &archive.Sources{
+			Dir:             archiveSourcesDir,
+			BuildDir:        rootDir,
+			JavaSourceRoots: sourceRoots,
+		}
+	}
+
+	err = b.addSBOMComponents(runtimeDeps)
+	if err != nil {
+		return nil, err
+	}
+
 	// Iterate over build results to fill archive and create fuzzers
 	for i := range fuzzTests {
 		fuzzTestName := fuzzTests[i]
@@ -221,6 +240,29 @@ func (b *jazzerBundler) assembleArtifacts(fuzzTests []string, targetMethods []st
 	return fuzzers, nil
 }
 
+// addSBOMComponents adds the jar files of the runtime dependencies to
+// the SBOM. Directories contain the classes of the project itself.
+func (b *jazzerBundler) addSBOMComponents(runtimeDeps []string) error {
+	for _, runtimeDep := range runtimeDeps {
+		info, err := os.Stat(runtimeDep)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.IsDir() || !strings.HasSuffix(runtimeDep, ".jar") {
+			continue
+		}
+		c, err := jarComponent(runtimeDep)
+		if err != nil {
+			return err
+		}
+		b.opts.addSBOMComponent(c)
+	}
+	return nil
+}
+
 func (b *jazzerBundler) copySeeds() (string, error) {
 	// Add seeds from user-specified seed corpus dirs (if any)
 	// to the seeds directory in the archive
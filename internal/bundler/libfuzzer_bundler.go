@@ -90,6 +90,19 @@ func (b *libfuzzerBundler) bundle() ([]*archive.Fuzzer, error) {
 		for _, systemDep := range systemDeps {
 			deduplicatedSystemDeps[systemDep] = struct{}{}
 		}
+
+		if b.opts.IncludeSources {
+			err = b.addSources(buildResult)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if b.opts.IncludeSources {
+		b.opts.sources = &archive.Sources{
+			Dir:      archiveSourcesDir,
+			BuildDir: b.opts.ProjectDir,
+		}
 	}
 
 	systemDeps := maps.Keys(deduplicatedSystemDeps)
@@ -509,6 +522,12 @@ depsLoop:
 		if err != nil {
 			return
 		}
+		var component *archive.SBOMComponent
+		component, err = sharedLibraryComponent(dep)
+		if err != nil {
+			return
+		}
+		b.opts.addSBOMComponent(component)
 	}
 
 	if b.opts.Dictionary == "" {
@@ -607,6 +626,35 @@ depsLoop:
 	return
 }
 
+// addSources adds the source files referenced by the debug info of the
+// fuzz test executable and of the runtime dependencies which were built
+// as part of the project to the archive.
+func (b *libfuzzerBundler) addSources(buildResult *build.CBuildResult) error {
+	binaries := []string{buildResult.Executable}
+	for _, dep := range buildResult.RuntimeDeps {
+		isBelowBuildDir, err := fileutil.IsBelow(dep, buildResult.BuildDir)
+		if err != nil {
+			return err
+		}
+		if isBelowBuildDir {
+			binaries = append(binaries, dep)
+		}
+	}
+
+	for _, binary := range binaries {
+		sourceFiles, err := debugInfoSourceFiles(binary)
+		if err != nil {
+			return err
+		}
+		n, err := writeSources(b.archiveWriter, b.opts.ProjectDir, sourceFiles)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Added %d source files referenced by the debug info of %s", n, binary)
+	}
+	return nil
+}
+
 // fuzzTestPrefix returns the path in the resulting artifact archive under which fuzz test specific files should be
 // added.
 func fuzzTestPrefix(buildResult *build.CBuildResult) string {
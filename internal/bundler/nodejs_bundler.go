@@ -0,0 +1,407 @@
+package bundler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+// The directory inside the fuzzing artifact which contains the Node.js
+// project. Jest is run in this directory.
+const archiveNodeProjectDir = "project"
+
+// Directories of the project which are not added to the bundle
+var nodeIgnoredDirs = []string{".git", ".cifuzz-build"}
+
+type nodeJSBundler struct {
+	opts          *Opts
+	archiveWriter archive.ArchiveWriter
+}
+
+func newNodeJSBundler(opts *Opts, archiveWriter archive.ArchiveWriter) *nodeJSBundler {
+	return &nodeJSBundler{opts, archiveWriter}
+}
+
+func (b *nodeJSBundler) bundle() ([]*archive.Fuzzer, error) {
+	err := dependencies.Check([]dependencies.Key{dependencies.Node}, b.opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fuzzTests, err := b.fuzzTests()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.opts.BuildSystemArgs) > 0 {
+		log.Warnf("Passing additional arguments is not supported for Node.js.\n"+
+			"These arguments are ignored: %s", strings.Join(b.opts.BuildSystemArgs, " "))
+	}
+
+	log.Info("Creating bundle...")
+
+	err = b.copyProject()
+	if err != nil {
+		return nil, err
+	}
+	if b.opts.IncludeSources {
+		// The source files are already part of the project copy
+		b.opts.sources = &archive.Sources{
+			Dir:      archiveNodeProjectDir,
+			BuildDir: b.opts.ProjectDir,
+		}
+	}
+
+	var archiveDict string
+	if b.opts.Dictionary != "" {
+		archiveDict = "dict"
+		err := b.archiveWriter.WriteFile(archiveDict, b.opts.Dictionary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var archiveSeedsDir string
+	if len(b.opts.SeedCorpusDirs) > 0 {
+		archiveSeedsDir = "seeds"
+		err := prepareSeeds(b.opts.SeedCorpusDirs, archiveSeedsDir, b.archiveWriter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fuzzers []*archive.Fuzzer
+	for _, fuzzTest := range fuzzTests {
+		relPath, err := filepath.Rel(b.opts.ProjectDir, fuzzTest.File)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		fuzzer := &archive.Fuzzer{
+			Name:         fuzzTest.Identifier,
+			Path:         filepath.ToSlash(filepath.Join(archiveNodeProjectDir, relPath)),
+			Engine:       "JAZZER_JS",
+			ProjectDir:   b.opts.ProjectDir,
+			Dictionary:   archiveDict,
+			Seeds:        archiveSeedsDir,
+			RuntimePaths: []string{archiveNodeProjectDir},
+			EngineOptions: archive.EngineOptions{
+				Env:   b.opts.Env,
+				Flags: b.opts.EngineArgs,
+			},
+			MaxRunTime: uint(b.opts.Timeout.Seconds()),
+		}
+		fuzzers = append(fuzzers, fuzzer)
+	}
+
+	return fuzzers, nil
+}
+
+// fuzzTests returns the fuzz tests which should be bundled. The fuzz
+// tests given to the bundler can either be the base name of a fuzz test
+// file, which selects all fuzz tests in that file, or the base name
+// followed by ":" and the (optionally quoted) name of a single fuzz test.
+func (b *nodeJSBundler) fuzzTests() ([]*cmdutils.NodeFuzzTest, error) {
+	allFuzzTests, err := cmdutils.ListNodeFuzzTests(b.opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(allFuzzTests) == 0 {
+		return nil, cmdutils.WrapIncorrectUsageError(
+			errors.Errorf("No fuzz test could be found in the project directory '%s'", b.opts.ProjectDir),
+		)
+	}
+
+	if len(b.opts.FuzzTests) == 0 {
+		return allFuzzTests, nil
+	}
+
+	var fuzzTests []*cmdutils.NodeFuzzTest
+	for _, arg := range b.opts.FuzzTests {
+		testPath, testName, hasTestName := strings.Cut(arg, ":")
+		testName = strings.Trim(testName, "\"'")
+
+		var found bool
+		for _, fuzzTest := range allFuzzTests {
+			fuzzTestPath, _, _ := strings.Cut(fuzzTest.Identifier, ":")
+			if testPath == fuzzTestPath && (!hasTestName || testName == fuzzTest.Name) {
+				fuzzTests = append(fuzzTests, fuzzTest)
+				found = true
+			}
+		}
+		if !found {
+			return nil, cmdutils.WrapIncorrectUsageError(
+				errors.Errorf("Fuzz test '%s' could not be found in the project directory '%s'", arg, b.opts.ProjectDir),
+			)
+		}
+	}
+
+	return fuzzTests, nil
+}
+
+// copyProject adds the project directory to the archive. Of the
+// packages in node_modules, only those which are needed to run the fuzz
+// tests are added.
+func (b *nodeJSBundler) copyProject() error {
+	packages, err := requiredNodePackages(b.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		if pkg.Name != "" {
+			b.opts.addSBOMComponent(nodePackageComponent(pkg))
+		}
+	}
+
+	var outputPath string
+	if b.opts.OutputPath != "" && b.opts.OutputPath != "-" {
+		outputPath, err = filepath.Abs(b.opts.OutputPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return filepath.WalkDir(b.opts.ProjectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(b.opts.ProjectDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		archivePath := filepath.Join(archiveNodeProjectDir, relPath)
+
+		if d.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if filepath.Dir(relPath) == "." && sliceutil.Contains(nodeIgnoredDirs, d.Name()) {
+				return fs.SkipDir
+			}
+			if isNodePackageDir(path) && packages[path] == nil {
+				log.Debugf("Skipping unused package %s", relPath)
+				return fs.SkipDir
+			}
+			// Skip caches and other tool directories in node_modules,
+			// apart from the directory containing the executables
+			if filepath.Base(filepath.Dir(path)) == "node_modules" && strings.HasPrefix(d.Name(), ".") && d.Name() != ".bin" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if path == outputPath {
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink == 0 {
+			return b.archiveWriter.WriteFile(archivePath, path)
+		}
+
+		// Symlinks are not supported by all the tools which handle
+		// bundles, so we resolve them
+		if isNodePackageDir(path) && packages[path] == nil {
+			log.Debugf("Skipping unused package %s", relPath)
+			return nil
+		}
+		if filepath.Base(filepath.Dir(path)) == ".bin" {
+			return b.writeBinShim(archivePath, path, packages)
+		}
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			log.Debugf("Skipping broken symlink %s", relPath)
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.IsDir() {
+			return b.archiveWriter.WriteDir(archivePath, path)
+		}
+		return b.archiveWriter.WriteFile(archivePath, path)
+	})
+}
+
+// writeBinShim adds a shell script to the archive which executes the
+// target of the symlink in node_modules/.bin, so that the executables
+// of the packages can still be found by npx.
+func (b *nodeJSBundler) writeBinShim(archivePath string, link string, packages map[string]*packageJSON) error {
+	target, err := filepath.EvalSymlinks(link)
+	if os.IsNotExist(err) {
+		log.Debugf("Skipping broken symlink %s", link)
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Don't add executables of packages which are not bundled
+	if pkg := nodePackageDir(target); pkg != "" && packages[pkg] == nil {
+		return nil
+	}
+
+	relTarget, err := os.Readlink(link)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if filepath.IsAbs(relTarget) {
+		relTarget, err = filepath.Rel(filepath.Dir(link), relTarget)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	shim := fmt.Sprintf("#!/bin/sh\nexec \"$(dirname \"$0\")/%s\" \"$@\"\n", filepath.ToSlash(relTarget))
+	shimPath := filepath.Join(b.opts.tempDir, archivePath)
+	err = os.MkdirAll(filepath.Dir(shimPath), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(shimPath, []byte(shim), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return b.archiveWriter.WriteFile(archivePath, shimPath)
+}
+
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// requiredNodePackages returns the directories of the installed
+// packages which are needed to run the fuzz tests of the project: The
+// dependencies and dev dependencies of the project (which include Jest
+// and Jazzer.js) and their transitive dependencies. The dependencies are
+// resolved like Node.js does, by looking them up in the node_modules
+// directories of the package and its parent directories.
+func requiredNodePackages(projectDir string) (map[string]*packageJSON, error) {
+	exists, err := fileutil.Exists(filepath.Join(projectDir, "node_modules"))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, cmdutils.WrapIncorrectUsageError(errors.Errorf(
+			"No node_modules directory found in %s, please install the dependencies (e.g. with 'npm install')", projectDir))
+	}
+
+	rootPkg, err := readPackageJSON(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := map[string]*packageJSON{}
+	var addDependencies func(pkgDir string, pkg *packageJSON, isRoot bool) error
+	addDependencies = func(pkgDir string, pkg *packageJSON, isRoot bool) error {
+		deps := []map[string]string{pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies}
+		// Dev dependencies of dependencies are not installed
+		if isRoot {
+			deps = append(deps, pkg.DevDependencies)
+		}
+
+		var names []string
+		for _, d := range deps {
+			for name := range d {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			depDir := resolveNodePackage(projectDir, pkgDir, name)
+			if depDir == "" {
+				// Optional and peer dependencies might not be installed
+				log.Debugf("Package %s required by %s is not installed", name, pkgDir)
+				continue
+			}
+			if packages[depDir] != nil {
+				continue
+			}
+			dep, err := readPackageJSON(depDir)
+			if err != nil {
+				return err
+			}
+			packages[depDir] = dep
+
+			err = addDependencies(depDir, dep, false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = addDependencies(projectDir, rootPkg, true)
+	if err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// resolveNodePackage returns the directory of the package with the given
+// name as seen from pkgDir, or an empty string if it's not installed.
+func resolveNodePackage(projectDir string, pkgDir string, name string) string {
+	dir := pkgDir
+	for {
+		if filepath.Base(dir) != "node_modules" {
+			candidate := filepath.Join(dir, "node_modules", filepath.FromSlash(name))
+			exists, err := fileutil.Exists(filepath.Join(candidate, "package.json"))
+			if err == nil && exists {
+				return candidate
+			}
+		}
+		if dir == projectDir || !strings.HasPrefix(dir, projectDir) {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func readPackageJSON(dir string) (*packageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pkg := &packageJSON{}
+	err = json.Unmarshal(data, pkg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse %s", filepath.Join(dir, "package.json"))
+	}
+	return pkg, nil
+}
+
+// Matches the directory of a package in a node_modules directory. The
+// greedy prefix makes it match the innermost package if packages are
+// nested. Directories starting with "@" are scopes, not packages.
+var nodePackageDirRegex = regexp.MustCompile(`^(.*[/\\]node_modules[/\\](?:@[^/\\]+[/\\])?[^/\\@.][^/\\]*)`)
+
+// isNodePackageDir returns true if dir is the directory of a package in
+// a node_modules directory.
+func isNodePackageDir(dir string) bool {
+	return nodePackageDir(dir) == dir
+}
+
+// nodePackageDir returns the directory of the package in a
+// node_modules directory which contains path, or an empty string if path
+// is not in a node_modules directory.
+func nodePackageDir(path string) string {
+	match := nodePackageDirRegex.FindStringSubmatch(path)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
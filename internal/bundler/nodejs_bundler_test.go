@@ -0,0 +1,138 @@
+package bundler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/util/archiveutil"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(path, []byte(content), 0o644)
+	require.NoError(t, err)
+}
+
+func createNodeProject(t *testing.T) string {
+	projectDir := testutil.MkdirTemp(t, "", "nodejs-bundler-")
+	writeTestFile(t, filepath.Join(projectDir, "package.json"),
+		`{"dependencies": {"a": "1.0.0"}, "devDependencies": {"jest": "29.0.0"}}`)
+	writeTestFile(t, filepath.Join(projectDir, "FuzzTestCase.fuzz.js"),
+		`test.fuzz("My fuzz test", data => {});`+"\n"+`test.fuzz("My other fuzz test", data => {});`)
+	writeTestFile(t, filepath.Join(projectDir, "Other.fuzz.ts"), `test.fuzz("fuzz", data => {});`)
+	writeTestFile(t, filepath.Join(projectDir, ".git", "HEAD"), "ref: refs/heads/main")
+
+	nodeModules := filepath.Join(projectDir, "node_modules")
+	// a depends on b and on a version of d which is installed in its own
+	// node_modules directory
+	writeTestFile(t, filepath.Join(nodeModules, "a", "package.json"), `{"dependencies": {"b": "1.0.0", "@scope/d": "2.0.0"}}`)
+	writeTestFile(t, filepath.Join(nodeModules, "a", "node_modules", "@scope", "d", "package.json"), `{}`)
+	writeTestFile(t, filepath.Join(nodeModules, "b", "package.json"), `{"optionalDependencies": {"not-installed": "1.0.0"}}`)
+	writeTestFile(t, filepath.Join(nodeModules, "jest", "package.json"), `{"name": "jest", "version": "29.7.0"}`)
+	writeTestFile(t, filepath.Join(nodeModules, "jest", "bin", "jest.js"), "#!/usr/bin/env node")
+	// The dev dependencies of dependencies are not needed
+	writeTestFile(t, filepath.Join(nodeModules, "c", "package.json"), `{"devDependencies": {"@scope/d": "1.0.0"}}`)
+	writeTestFile(t, filepath.Join(nodeModules, "c", "index.js"), "#!/usr/bin/env node")
+	writeTestFile(t, filepath.Join(nodeModules, "@scope", "d", "package.json"), `{}`)
+	writeTestFile(t, filepath.Join(nodeModules, ".cache", "file"), "cache")
+	require.NoError(t, os.MkdirAll(filepath.Join(nodeModules, ".bin"), 0o755))
+	require.NoError(t, os.Symlink("../jest/bin/jest.js", filepath.Join(nodeModules, ".bin", "jest")))
+	require.NoError(t, os.Symlink("../c/index.js", filepath.Join(nodeModules, ".bin", "c")))
+
+	return projectDir
+}
+
+func TestNodeJSBundler_CopyProject(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symlinks requires special privileges on Windows")
+	}
+	projectDir := createNodeProject(t)
+
+	buf := &bytes.Buffer{}
+	archiveWriter := archive.NewTarArchiveWriter(buf, false)
+	sbom, err := archive.NewSBOM(true)
+	require.NoError(t, err)
+	b := newNodeJSBundler(&Opts{
+		ProjectDir: projectDir,
+		tempDir:    testutil.MkdirTemp(t, "", "nodejs-bundler-temp-"),
+		sbom:       sbom,
+	}, archiveWriter)
+	err = b.copyProject()
+	require.NoError(t, err)
+
+	// Only packages with a name are added to the SBOM
+	require.Len(t, sbom.Components, 1)
+	assert.Equal(t, "pkg:npm/jest@29.7.0", sbom.Components[0].PURL)
+
+	var names []string
+	for _, header := range archiveWriter.Headers() {
+		names = append(names, header.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"project/package.json",
+		"project/FuzzTestCase.fuzz.js",
+		"project/Other.fuzz.ts",
+		"project/node_modules/.bin/jest",
+		"project/node_modules/a/package.json",
+		"project/node_modules/a/node_modules/@scope/d/package.json",
+		"project/node_modules/b/package.json",
+		"project/node_modules/jest/package.json",
+		"project/node_modules/jest/bin/jest.js",
+	}, names)
+	require.NoError(t, archiveWriter.Close())
+
+	// The symlink in node_modules/.bin is replaced by a script
+	extractDir := testutil.MkdirTemp(t, "", "nodejs-bundler-extract-")
+	require.NoError(t, archiveutil.Untar(buf, extractDir))
+	shim, err := os.ReadFile(filepath.Join(extractDir, "project", "node_modules", ".bin", "jest"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexec \"$(dirname \"$0\")/../jest/bin/jest.js\" \"$@\"\n", string(shim))
+}
+
+func TestNodeJSBundler_MissingNodeModules(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "nodejs-bundler-")
+	writeTestFile(t, filepath.Join(projectDir, "package.json"), `{}`)
+
+	b := newNodeJSBundler(&Opts{ProjectDir: projectDir}, &archive.NullArchiveWriter{})
+	err := b.copyProject()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "npm install")
+}
+
+func TestNodeJSBundler_FuzzTests(t *testing.T) {
+	projectDir := createNodeProject(t)
+
+	testCases := map[string][]string{
+		"":                                {`FuzzTestCase:"My fuzz test"`, `FuzzTestCase:"My other fuzz test"`, "Other"},
+		"FuzzTestCase":                    {`FuzzTestCase:"My fuzz test"`, `FuzzTestCase:"My other fuzz test"`},
+		`FuzzTestCase:"My fuzz test"`:     {`FuzzTestCase:"My fuzz test"`},
+		"FuzzTestCase:My other fuzz test": {`FuzzTestCase:"My other fuzz test"`},
+		"Other":                           {"Other"},
+	}
+	for arg, expected := range testCases {
+		opts := &Opts{ProjectDir: projectDir}
+		if arg != "" {
+			opts.FuzzTests = []string{arg}
+		}
+		fuzzTests, err := newNodeJSBundler(opts, &archive.NullArchiveWriter{}).fuzzTests()
+		require.NoError(t, err)
+		var identifiers []string
+		for _, fuzzTest := range fuzzTests {
+			identifiers = append(identifiers, fuzzTest.Identifier)
+		}
+		assert.ElementsMatch(t, expected, identifiers, arg)
+	}
+
+	opts := &Opts{ProjectDir: projectDir, FuzzTests: []string{"FuzzTestCase:unknown"}}
+	_, err := newNodeJSBundler(opts, &archive.NullArchiveWriter{}).fuzzTests()
+	require.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/util/sliceutil"
@@ -30,6 +31,15 @@ type Opts struct {
 	ProjectDir      string        `mapstructure:"project-dir"`
 	ConfigDir       string        `mapstructure:"config-dir"`
 	AdditionalFiles []string      `mapstructure:"add"`
+	Reproducible    bool          `mapstructure:"reproducible"`
+	SigningKey      string        `mapstructure:"signing-key"`
+	Compression     string        `mapstructure:"compression"`
+	SBOMOutput      string        `mapstructure:"sbom-output"`
+	IncludeSources  bool          `mapstructure:"include-sources"`
+
+	// Path of the detached signature file. If empty, the signature is
+	// stored in the bundle.
+	DetachedSignature string `mapstructure:"detached-signature"`
 
 	// Fields which are not configurable via viper (i.e. via cifuzz.yaml
 	// and CIFUZZ_* environment variables), by setting
@@ -43,7 +53,9 @@ type Opts struct {
 	BuildStdout     io.Writer `mapstructure:"-"`
 	BuildStderr     io.Writer `mapstructure:"-"`
 
-	tempDir string `mapstructure:"-"`
+	tempDir string           `mapstructure:"-"`
+	sbom    *archive.SBOM    `mapstructure:"-"`
+	sources *archive.Sources `mapstructure:"-"`
 
 	ResolveSourceFilePath bool
 	BundleBuildLogFile    string
@@ -68,6 +80,16 @@ func (opts *Opts) Validate() error {
 		}
 	}
 
+	_, err = archive.ParseCompression(opts.Compression)
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+
+	if opts.DetachedSignature != "" && opts.SigningKey == "" {
+		msg := "Flag \"detached-signature\" requires flag \"signing-key\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	if opts.BuildSystem == config.BuildSystemBazel {
 		// We don't support building a bundle with bazel without any
 		// specified fuzz tests
@@ -0,0 +1,111 @@
+package bundler
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+)
+
+var (
+	// Matches the hash directories of the Gradle dependency cache
+	gradleHashDirRegex = regexp.MustCompile(`^[0-9a-f]{30,}$`)
+	// Matches jar names which end with a version, e.g. guava-32.1.2-jre.jar
+	versionedJarRegex = regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)\.jar$`)
+	// Matches the versions of shared libraries, e.g. libz.so.1.2.13,
+	// libfoo-1.2.so or libfoo.1.2.dylib
+	versionedLibraryRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`^(.+?)\.so\.(\d[\d.]*)$`),
+		regexp.MustCompile(`^(.+?)-(\d[\d.]*)\.so$`),
+		regexp.MustCompile(`^(.+?)\.(\d[\d.]*)\.dylib$`),
+	}
+)
+
+// jarComponent returns the SBOM component of a jar file. The group,
+// name and version are determined from the location of the jar in the
+// local Maven repository or the Gradle cache, or, if the jar is located
+// somewhere else, from the file name.
+func jarComponent(jarPath string) (*archive.SBOMComponent, error) {
+	hash, err := sha256sum(jarPath)
+	if err != nil {
+		return nil, err
+	}
+	c := &archive.SBOMComponent{
+		Type:   "library",
+		Hashes: []archive.SBOMHash{{Algorithm: "SHA-256", Content: hash}},
+	}
+
+	parts := strings.Split(filepath.ToSlash(jarPath), "/")
+	n := len(parts)
+	fileName := parts[n-1]
+	switch {
+	case n >= 6 && parts[n-6] == "files-2.1" && gradleHashDirRegex.MatchString(parts[n-2]):
+		// Gradle: files-2.1/<group>/<name>/<version>/<hash>/<name>-<version>.jar
+		c.Group, c.Name, c.Version = parts[n-5], parts[n-4], parts[n-3]
+	case n >= 5 && strings.HasPrefix(fileName, parts[n-3]+"-"+parts[n-2]):
+		// Maven: repository/<group path>/<name>/<version>/<name>-<version>.jar
+		c.Name, c.Version = parts[n-3], parts[n-2]
+		for i := n - 4; i >= 0; i-- {
+			if parts[i] == "repository" {
+				c.Group = strings.Join(parts[i+1:n-3], ".")
+				break
+			}
+		}
+	default:
+		c.Name = strings.TrimSuffix(fileName, ".jar")
+		if match := versionedJarRegex.FindStringSubmatch(fileName); match != nil {
+			c.Name, c.Version = match[1], match[2]
+		}
+	}
+
+	if c.Group != "" && c.Version != "" {
+		c.PURL = fmt.Sprintf("pkg:maven/%s/%s@%s", c.Group, c.Name, c.Version)
+	}
+	return c, nil
+}
+
+// sharedLibraryComponent returns the SBOM component of a shared library.
+// The version is determined from the file name, if it contains one.
+func sharedLibraryComponent(libPath string) (*archive.SBOMComponent, error) {
+	hash, err := sha256sum(libPath)
+	if err != nil {
+		return nil, err
+	}
+	c := &archive.SBOMComponent{
+		Type:   "library",
+		Name:   filepath.Base(libPath),
+		Hashes: []archive.SBOMHash{{Algorithm: "SHA-256", Content: hash}},
+	}
+	for _, regex := range versionedLibraryRegexes {
+		if match := regex.FindStringSubmatch(c.Name); match != nil {
+			c.Name, c.Version = match[1], match[2]
+			break
+		}
+	}
+	// Libraries with the same name and version can still differ, e.g.
+	// when they were built with different options
+	c.BOMRef = fmt.Sprintf("%s@%s#sha256:%s", c.Name, c.Version, hash)
+	return c, nil
+}
+
+// nodePackageComponent returns the SBOM component of an npm package.
+func nodePackageComponent(pkg *packageJSON) *archive.SBOMComponent {
+	c := &archive.SBOMComponent{
+		Type:    "library",
+		Name:    pkg.Name,
+		Version: pkg.Version,
+	}
+	if scope, name, found := strings.Cut(pkg.Name, "/"); found {
+		c.Group, c.Name = scope, name
+	}
+	if pkg.Version != "" {
+		purlName := pkg.Name
+		if c.Group != "" {
+			purlName = "%40" + strings.TrimPrefix(c.Group, "@") + "/" + c.Name
+		}
+		c.PURL = fmt.Sprintf("pkg:npm/%s@%s", purlName, pkg.Version)
+	}
+	return c
+}
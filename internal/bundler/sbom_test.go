@@ -0,0 +1,90 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestJarComponent(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "sbom-")
+
+	testCases := []struct {
+		path    string
+		group   string
+		name    string
+		version string
+		purl    string
+	}{
+		{
+			path:    ".m2/repository/com/google/guava/guava/32.1.2-jre/guava-32.1.2-jre.jar",
+			group:   "com.google.guava",
+			name:    "guava",
+			version: "32.1.2-jre",
+			purl:    "pkg:maven/com.google.guava/guava@32.1.2-jre",
+		},
+		{
+			path:    ".gradle/caches/modules-2/files-2.1/org.junit.jupiter/junit-jupiter-api/5.9.2/fed843581520eac594bc36bb4b0f55e7b947bda9/junit-jupiter-api-5.9.2.jar",
+			group:   "org.junit.jupiter",
+			name:    "junit-jupiter-api",
+			version: "5.9.2",
+			purl:    "pkg:maven/org.junit.jupiter/junit-jupiter-api@5.9.2",
+		},
+		{
+			path:    "lib/jazzer-junit-0.20.1.jar",
+			name:    "jazzer-junit",
+			version: "0.20.1",
+		},
+		{
+			path: "lib/custom.jar",
+			name: "custom",
+		},
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(dir, filepath.FromSlash(tc.path))
+		writeTestFile(t, path, "jar")
+
+		c, err := jarComponent(path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.group, c.Group, tc.path)
+		assert.Equal(t, tc.name, c.Name, tc.path)
+		assert.Equal(t, tc.version, c.Version, tc.path)
+		assert.Equal(t, tc.purl, c.PURL, tc.path)
+		require.Len(t, c.Hashes, 1)
+		assert.Equal(t, "SHA-256", c.Hashes[0].Algorithm)
+	}
+}
+
+func TestSharedLibraryComponent(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "sbom-")
+	for name, expected := range map[string][2]string{
+		"libz.so.1.2.13":    {"libz", "1.2.13"},
+		"libfoo-2.1.so":     {"libfoo", "2.1"},
+		"libbar.3.dylib":    {"libbar", "3"},
+		"libunversioned.so": {"libunversioned.so", ""},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+
+		c, err := sharedLibraryComponent(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected[0], c.Name, name)
+		assert.Equal(t, expected[1], c.Version, name)
+	}
+}
+
+func TestNodePackageComponent(t *testing.T) {
+	c := nodePackageComponent(&packageJSON{Name: "@jazzer.js/jest-runner", Version: "2.1.0"})
+	assert.Equal(t, "@jazzer.js", c.Group)
+	assert.Equal(t, "jest-runner", c.Name)
+	assert.Equal(t, "pkg:npm/%40jazzer.js/jest-runner@2.1.0", c.PURL)
+
+	c = nodePackageComponent(&packageJSON{Name: "jest", Version: "29.7.0"})
+	assert.Equal(t, "", c.Group)
+	assert.Equal(t, "pkg:npm/jest@29.7.0", c.PURL)
+}
@@ -0,0 +1,192 @@
+package bundler
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"debug/macho"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The directory inside the archive which contains the source files when
+// the bundle is created with --include-sources.
+const archiveSourcesDir = "src"
+
+// debugInfoSourceFiles returns the absolute paths of the source files
+// referenced by the DWARF line tables of the binary. On macOS, the debug
+// info is read from the .dSYM bundle next to the binary, if it exists.
+func debugInfoSourceFiles(binary string) ([]string, error) {
+	data, err := dwarfData(binary)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	files := map[string]struct{}{}
+	r := data.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read debug info of %s", binary)
+		}
+		if entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := data.LineReader(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read line table of %s", binary)
+		}
+		if lr == nil {
+			continue
+		}
+		// Relative file names are relative to the compilation directory
+		compDir, _ := entry.Val(dwarf.AttrCompDir).(string)
+		for _, f := range lr.Files() {
+			// The first entry is nil in DWARF versions before 5
+			if f == nil || f.Name == "" {
+				continue
+			}
+			name := f.Name
+			if !filepath.IsAbs(name) {
+				if compDir == "" {
+					continue
+				}
+				name = filepath.Join(compDir, name)
+			}
+			files[filepath.Clean(name)] = struct{}{}
+		}
+		r.SkipChildren()
+	}
+
+	var result []string
+	for f := range files {
+		result = append(result, f)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// dwarfData returns the DWARF debug info of the binary, or nil if the
+// binary has an unsupported format.
+func dwarfData(binary string) (*dwarf.Data, error) {
+	f, err := elf.Open(binary)
+	if err == nil {
+		defer f.Close()
+		data, err := f.DWARF()
+		if err != nil {
+			log.Debugf("No debug info found in %s: %v", binary, err)
+			return nil, nil
+		}
+		return data, nil
+	}
+	var formatErr *elf.FormatError
+	if !errors.As(err, &formatErr) {
+		return nil, errors.WithStack(err)
+	}
+
+	// On macOS, the debug info is stored in a separate .dSYM bundle
+	dsymBinary := filepath.Join(binary+".dSYM", "Contents", "Resources", "DWARF", filepath.Base(binary))
+	exists, err := fileutil.Exists(dsymBinary)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		dsymBinary = binary
+	}
+	m, err := macho.Open(dsymBinary)
+	if err != nil {
+		log.Debugf("Unsupported binary format of %s: %v", binary, err)
+		return nil, nil
+	}
+	defer m.Close()
+	data, err := m.DWARF()
+	if err != nil {
+		log.Debugf("No debug info found in %s: %v", dsymBinary, err)
+		return nil, nil
+	}
+	return data, nil
+}
+
+// writeSources adds the source files which are located below the
+// build dir to the sources directory of the archive and returns the
+// number of files which were added. Files which don't exist (anymore)
+// are ignored.
+func writeSources(archiveWriter archive.ArchiveWriter, buildDir string, files []string) (int, error) {
+	var n int
+	for _, file := range files {
+		isBelow, err := fileutil.IsBelow(file, buildDir)
+		if err != nil {
+			return n, err
+		}
+		if !isBelow {
+			// The source file is not part of the project, e.g. a
+			// system header
+			continue
+		}
+		relPath, err := filepath.Rel(buildDir, file)
+		if err != nil {
+			return n, errors.WithStack(err)
+		}
+		archivePath := filepath.Join(archiveSourcesDir, relPath)
+		if archiveWriter.HasFileEntry(archivePath) {
+			continue
+		}
+		exists, err := fileutil.Exists(file)
+		if err != nil {
+			return n, err
+		}
+		if !exists {
+			log.Debugf("Source file %s referenced by debug info doesn't exist", file)
+			continue
+		}
+		err = archiveWriter.WriteFile(archivePath, file)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// javaSourceFiles returns the absolute paths of the source files in the
+// source map and the roots of their package hierarchies, relative to the
+// root dir.
+func javaSourceFiles(rootDir string, sourceMap *sourcemap.SourceMap) ([]string, []string) {
+	var files []string
+	roots := map[string]struct{}{}
+	for pkg, relPaths := range sourceMap.JavaPackages {
+		pkgDir := strings.ReplaceAll(pkg, ".", "/")
+		for _, relPath := range relPaths {
+			files = append(files, filepath.Join(rootDir, filepath.FromSlash(relPath)))
+			dir := filepath.ToSlash(filepath.Dir(relPath))
+			if root, found := strings.CutSuffix(dir, pkgDir); found && (root == "" || strings.HasSuffix(root, "/")) {
+				roots[strings.TrimSuffix(root, "/")] = struct{}{}
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var sortedRoots []string
+	for root := range roots {
+		if root == "" {
+			root = "."
+		}
+		sortedRoots = append(sortedRoots, root)
+	}
+	sort.Strings(sortedRoots)
+	return files, sortedRoots
+}
@@ -0,0 +1,95 @@
+package bundler
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
+)
+
+func TestDebugInfoSourceFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("The debug info is only contained in the binary on Linux")
+	}
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("cc not found")
+	}
+
+	projectDir := testutil.MkdirTemp(t, "", "debug-info-")
+	writeTestFile(t, filepath.Join(projectDir, "src", "api.h"), "int api(void) { return 0; }\n")
+	writeTestFile(t, filepath.Join(projectDir, "src", "main.c"), "#include \"api.h\"\nint main(void) { return api(); }\n")
+	binary := filepath.Join(projectDir, "main")
+	cmd := exec.Command(cc, "-g", "-o", binary, filepath.Join("src", "main.c"))
+	cmd.Dir = projectDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	files, err := debugInfoSourceFiles(binary)
+	require.NoError(t, err)
+	// Relative paths are resolved against the compilation directory
+	assert.Contains(t, files, filepath.Join(projectDir, "src", "main.c"))
+	assert.Contains(t, files, filepath.Join(projectDir, "src", "api.h"))
+}
+
+func TestWriteSources(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "sources-")
+	writeTestFile(t, filepath.Join(projectDir, "src", "api.cpp"), "")
+	writeTestFile(t, filepath.Join(projectDir, "src", "api.h"), "")
+
+	archiveWriter := archive.NewTarArchiveWriter(io.Discard, false)
+	n, err := writeSources(archiveWriter, projectDir, []string{
+		filepath.Join(projectDir, "src", "api.cpp"),
+		filepath.Join(projectDir, "src", "api.h"),
+		// Doesn't exist
+		filepath.Join(projectDir, "src", "generated.cpp"),
+		// Not below the project dir
+		filepath.Join(filepath.Dir(projectDir), "usr", "include", "stdio.h"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var names []string
+	for _, h := range archiveWriter.Headers() {
+		names = append(names, h.Name)
+	}
+	assert.ElementsMatch(t, []string{"src/src/api.cpp", "src/src/api.h"}, names)
+
+	// Files which are already contained in the archive are skipped
+	n, err = writeSources(archiveWriter, projectDir, []string{filepath.Join(projectDir, "src", "api.cpp")})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestJavaSourceFiles(t *testing.T) {
+	rootDir := filepath.Join(os.TempDir(), "project")
+	sourceMap := &sourcemap.SourceMap{
+		JavaPackages: map[string][]string{
+			"com.example": {
+				"src/main/java/com/example/ExploreMe.java",
+				"src/test/java/com/example/FuzzTestCase.java",
+			},
+			"com.example.util": {"module/src/main/java/com/example/util/Util.java"},
+			// A file which is not located in the directory of its package
+			"org.other": {"src/main/java/Other.java"},
+		},
+	}
+
+	files, roots := javaSourceFiles(rootDir, sourceMap)
+	assert.Equal(t, []string{
+		filepath.Join(rootDir, "module", "src", "main", "java", "com", "example", "util", "Util.java"),
+		filepath.Join(rootDir, "src", "main", "java", "Other.java"),
+		filepath.Join(rootDir, "src", "main", "java", "com", "example", "ExploreMe.java"),
+		filepath.Join(rootDir, "src", "test", "java", "com", "example", "FuzzTestCase.java"),
+	}, files)
+	assert.Equal(t, []string{"module/src/main/java", "src/main/java", "src/test/java"}, roots)
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"code-intelligence.com/cifuzz/internal/bundler"
+	"code-intelligence.com/cifuzz/internal/cmd/bundle/diff"
+	"code-intelligence.com/cifuzz/internal/cmd/bundle/inspect"
+	"code-intelligence.com/cifuzz/internal/cmd/bundle/verify"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
 	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
@@ -31,10 +34,6 @@ func (opts *options) Validate() error {
 		return err
 	}
 
-	if opts.BuildSystem == config.BuildSystemNodeJS && !config.AllowUnsupportedPlatforms() {
-		return errors.Errorf(config.NotSupportedErrorMessage("bundle", opts.BuildSystem))
-	}
-
 	return opts.Opts.Validate()
 }
 
@@ -67,6 +66,25 @@ configured for the project.
 This command will select an appropriate Docker image for execution based
 on the build system. This can be overridden with a docker-image flag.
 
+The bundle contains a manifest.sha256 file with the SHA-256 checksums of
+all other files in the bundle. If a signing key is specified via the
+--signing-key flag, the manifest is signed, which allows 'cifuzz execute'
+and 'cifuzz bundle verify' to check that the bundle was not modified.
+
+The bundle also contains a CycloneDX SBOM (sbom.cdx.json) which lists the
+third party components of the bundle, i.e. the dependency jars of Java
+projects, the external shared libraries of C/C++ projects and the npm
+packages of Node.js projects. Use the --sbom-output flag to write a copy
+of it next to the bundle.
+
+With --include-sources, the source files of the project are added to the
+src/ directory of the bundle: For C/C++ projects, the source files which
+are referenced by the debug info of the fuzz tests, for Java projects the
+source files of all source and test directories. The mapping from the
+project directory on the build machine to the src/ directory is recorded
+in bundle.yaml, which allows 'cifuzz execute' to produce line coverage
+reports.
+
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("CMake") + `
   <fuzz test> is the name of the fuzz test defined in the add_fuzz_test
   command in your CMakeLists.txt.
@@ -116,6 +134,17 @@ on the build system. This can be overridden with a docker-image flag.
 
   If no fuzz tests are specified, all fuzz tests are added to the bundle.
 
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Node.js") + `
+  <fuzz test> is the name of the fuzz test file without the ".fuzz.js"
+  or ".fuzz.ts" extension, optionally followed by ":" and the name of a
+  single fuzz test in that file.
+
+  The project directory is added to the bundle, including the packages
+  in node_modules which are needed to run the fuzz tests. The
+  dependencies must be installed before creating the bundle.
+
+  If no fuzz tests are specified, all fuzz tests are added to the bundle.
+
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Other build systems") + `
   <fuzz test> is either the path or basename of the fuzz test executable
   created by the build command. If it's the basename, it will be searched
@@ -200,7 +229,13 @@ on the build system. This can be overridden with a docker-image flag.
 			return opts.Validate()
 		},
 		RunE: func(c *cobra.Command, args []string) error {
-			buildPrinter := logging.NewBuildPrinter(os.Stdout, log.BundleInProgressMsg)
+			// Keep stdout clean if the bundle is written to it
+			streaming := opts.OutputPath == "-"
+			var printerOutput io.Writer = os.Stdout
+			if streaming {
+				printerOutput = c.ErrOrStderr()
+			}
+			buildPrinter := logging.NewBuildPrinter(printerOutput, log.BundleInProgressMsg)
 
 			_, err := bundler.New(&opts.Opts).Bundle()
 			if err != nil {
@@ -209,7 +244,11 @@ on the build system. This can be overridden with a docker-image flag.
 			}
 
 			buildPrinter.StopOnSuccess(log.BundleInProgressSuccessMsg, true)
-			log.Successf("Successfully created bundle: %s", opts.OutputPath)
+			if streaming {
+				log.Success("Successfully wrote bundle to stdout")
+			} else {
+				log.Successf("Successfully created bundle: %s", opts.OutputPath)
+			}
 
 			return nil
 		},
@@ -222,16 +261,27 @@ on the build system. This can be overridden with a docker-image flag.
 		cmdutils.AddCleanCommandFlag,
 		cmdutils.AddBuildJobsFlag,
 		cmdutils.AddCommitFlag,
+		cmdutils.AddCompressionFlag,
+		cmdutils.AddDetachedSignatureFlag,
 		cmdutils.AddDictFlag,
 		cmdutils.AddDockerImageFlagForBundleCommand,
 		cmdutils.AddEngineArgFlag,
 		cmdutils.AddEnvFlag,
+		cmdutils.AddIncludeSourcesFlag,
 		cmdutils.AddProjectDirFlag,
+		cmdutils.AddReproducibleFlag,
+		cmdutils.AddSBOMOutputFlag,
 		cmdutils.AddSeedCorpusFlag,
+		cmdutils.AddSigningKeyFlag,
 		cmdutils.AddTimeoutFlag,
 		cmdutils.AddResolveSourceFileFlag,
 	)
-	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output path of the bundle (.tar.gz)")
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output path of the bundle (.tar.gz, .tar.zst or .tar).\n"+
+		"Use \"-\" to write the bundle to stdout, e.g. to pipe it into an upload.")
+
+	cmd.AddCommand(diff.New())
+	cmd.AddCommand(inspect.New())
+	cmd.AddCommand(verify.New())
 
 	return cmd
 }
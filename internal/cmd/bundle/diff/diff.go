@@ -0,0 +1,120 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+)
+
+func New() *cobra.Command {
+	var printJSON bool
+	cmd := &cobra.Command{
+		Use:   "diff <old bundle> <new bundle>",
+		Short: "Show the differences between two bundles",
+		Long: `This command compares two bundles created by 'cifuzz bundle' and prints
+what changed between them:
+
+  * the docker image and the code revision
+  * the fuzzers which were added or removed
+  * changed properties of fuzzers, e.g. engine options and sanitizers
+  * the files which were added, removed or modified, according to their
+    SHA-256 checksums
+  * the seeds which were added, removed or modified, per seed corpus`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			d, err := archive.DiffBundles(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if printJSON {
+				out, err := json.MarshalIndent(d, "", "  ")
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				_, err = fmt.Fprintln(c.OutOrStdout(), string(out))
+				return errors.WithStack(err)
+			}
+			printDiff(c.OutOrStdout(), d)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&printJSON, "json", false, "Print output as JSON")
+	return cmd
+}
+
+func printDiff(out io.Writer, d *archive.Diff) {
+	if d.Empty() {
+		fmt.Fprintln(out, "The bundles don't differ")
+		return
+	}
+
+	printValueChange(out, "Docker image", d.DockerImage)
+	printValueChange(out, "Commit", d.Commit)
+	printValueChange(out, "Branch", d.Branch)
+
+	if len(d.FuzzersAdded) > 0 || len(d.FuzzersRemoved) > 0 || len(d.FuzzersChanged) > 0 {
+		fmt.Fprintln(out, "\nFuzzers:")
+		for _, name := range d.FuzzersAdded {
+			fmt.Fprintf(out, "  + %s\n", name)
+		}
+		for _, name := range d.FuzzersRemoved {
+			fmt.Fprintf(out, "  - %s\n", name)
+		}
+		for _, fuzzer := range d.FuzzersChanged {
+			fmt.Fprintf(out, "  ~ %s\n", fuzzer.Name)
+			var fields []string
+			for field := range fuzzer.Changes {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				change := fuzzer.Changes[field]
+				fmt.Fprintf(out, "      %s: %q -> %q\n", field, change.Old, change.New)
+			}
+		}
+	}
+
+	if len(d.SeedCorpora) > 0 {
+		fmt.Fprintln(out, "\nSeed corpora:")
+		for _, seeds := range d.SeedCorpora {
+			fmt.Fprintf(out, "  %s: %d added, %d removed, %d modified\n",
+				seeds.Dir, len(seeds.Added), len(seeds.Removed), len(seeds.Modified))
+		}
+	}
+
+	if d.Files != nil {
+		fmt.Fprintln(out, "\nFiles:")
+		printFiles(out, d.Files)
+	}
+}
+
+func printValueChange(out io.Writer, name string, change *archive.ValueChange) {
+	if change != nil {
+		fmt.Fprintf(out, "%s: %s -> %s\n", name, valueOrNone(change.Old), valueOrNone(change.New))
+	}
+}
+
+func printFiles(out io.Writer, files *archive.FilesDiff) {
+	for _, name := range files.Added {
+		fmt.Fprintf(out, "  + %s\n", name)
+	}
+	for _, name := range files.Removed {
+		fmt.Fprintf(out, "  - %s\n", name)
+	}
+	for _, name := range files.Modified {
+		fmt.Fprintf(out, "  ~ %s\n", name)
+	}
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
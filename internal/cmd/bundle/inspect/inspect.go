@@ -0,0 +1,71 @@
+package inspect
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect <bundle>",
+		Short: "Print the contents of a bundle",
+		Long: `This command prints the metadata of a bundle created by 'cifuzz bundle',
+i.e. the run environment, the code revision and the fuzzers with their
+engines and sanitizers, as well as the size of the files of each component
+of the bundle.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			contents, err := archive.ReadContents(args[0])
+			if err != nil {
+				return err
+			}
+			return printContents(c.OutOrStdout(), contents)
+		},
+	}
+	return cmd
+}
+
+func printContents(out io.Writer, contents *archive.Contents) error {
+	metadata := contents.Metadata
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if metadata.RunEnvironment != nil {
+		fmt.Fprintf(w, "Docker image:\t%s\n", metadata.Docker)
+	}
+	if metadata.CodeRevision != nil && metadata.CodeRevision.Git != nil {
+		fmt.Fprintf(w, "Commit:\t%s\n", metadata.CodeRevision.Git.Commit)
+		fmt.Fprintf(w, "Branch:\t%s\n", metadata.CodeRevision.Git.Branch)
+	}
+	fmt.Fprintf(w, "Total size:\t%s\n", archive.FormatSize(contents.TotalSize))
+
+	fmt.Fprintf(w, "\nFuzzers:\n")
+	fmt.Fprintf(w, "  NAME\tENGINE\tSANITIZER\tPATH\n")
+	for _, fuzzer := range metadata.Fuzzers {
+		name := fuzzer.Name
+		if name == "" {
+			name = fuzzer.Target
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", name, fuzzer.Engine, valueOrDash(fuzzer.Sanitizer), valueOrDash(fuzzer.Path))
+	}
+
+	fmt.Fprintf(w, "\nComponents:\n")
+	fmt.Fprintf(w, "  PATH\tFILES\tSIZE\n")
+	for _, c := range contents.Components {
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", c.Path, c.Files, archive.FormatSize(c.Size))
+	}
+
+	return w.Flush()
+}
+
+func valueOrDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package verify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	publicKey     string
+	signaturePath string
+}
+
+func New() *cobra.Command {
+	opts := &options{}
+	cmd := &cobra.Command{
+		Use:   "verify <bundle>",
+		Short: "Check that a bundle is complete before uploading it",
+		Long: `This command extracts a bundle created by 'cifuzz bundle' and checks
+that it can be executed:
+
+  * all fuzzer executables, seed corpus directories, dictionaries and
+    library paths referenced in bundle.yaml exist in the bundle
+  * fuzzer executables are executable
+  * shared libraries required by the fuzzer executables are contained in
+    the bundle (libraries which are not are reported as warnings, because
+    they have to be provided by the docker image)
+  * the runtime paths of Java fuzz tests contain the fuzz test class
+  * all files match the checksums in the manifest of the bundle and no
+    files were added
+  * if --public-key is specified, the manifest is signed by the
+    corresponding private key`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return run(args[0], opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.publicKey, "public-key", "", "Check that the bundle manifest is signed by the private key corresponding to the ed25519 public key in this `file` (PEM encoded).")
+	cmd.Flags().StringVar(&opts.signaturePath, "signature", "", "Path to a detached signature of the bundle manifest. By default, the signature contained in the bundle is used.")
+	return cmd
+}
+
+func run(bundle string, opts *options) error {
+	integrityOpts := &archive.IntegrityOpts{SignaturePath: opts.signaturePath}
+	if opts.publicKey != "" {
+		publicKey, err := archive.ReadPublicKey(opts.publicKey)
+		if err != nil {
+			return err
+		}
+		integrityOpts.PublicKey = publicKey
+	}
+
+	dir, err := os.MkdirTemp("", "cifuzz-bundle-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(dir)
+
+	err = archive.Extract(bundle, dir)
+	if err != nil {
+		return errors.WithMessagef(err, "Failed to extract bundle %s", bundle)
+	}
+	metadata, err := archive.MetadataFromPath(filepath.Join(dir, archive.MetadataFileName))
+	if err != nil {
+		return err
+	}
+
+	result, err := archive.Verify(dir, metadata)
+	if err != nil {
+		return err
+	}
+	err = verifyIntegrity(dir, integrityOpts, result)
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		log.Warn(warning)
+	}
+	if !result.OK() {
+		for _, e := range result.Errors {
+			log.ErrorMsg(e)
+		}
+		return errors.Errorf("Bundle %s is invalid", bundle)
+	}
+
+	log.Successf("Bundle %s is valid (%d fuzzers)", bundle, len(metadata.Fuzzers))
+	return nil
+}
+
+// verifyIntegrity adds problems with the checksum manifest and the
+// signature of the bundle to the result.
+func verifyIntegrity(dir string, opts *archive.IntegrityOpts, result *archive.VerificationResult) error {
+	err := archive.VerifyIntegrity(dir, opts)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return nil
+	}
+
+	exists, err := fileutil.Exists(filepath.Join(dir, archive.ManifestFileName))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("The bundle doesn't contain a %s file, its integrity can't be checked", archive.ManifestFileName))
+		return nil
+	}
+
+	if opts.PublicKey == nil {
+		if _, err := os.Stat(filepath.Join(dir, archive.SignatureFileName)); err == nil {
+			log.Info("The bundle is signed, use --public-key to verify the signature")
+		}
+	}
+	return nil
+}
@@ -22,7 +22,9 @@ import (
 	"code-intelligence.com/cifuzz/internal/container"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/suppression"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 )
 
 type containerRemoteRunOpts struct {
@@ -33,6 +35,9 @@ type containerRemoteRunOpts struct {
 	MonitorDuration    time.Duration `mapstructure:"monitor-duration"`
 	MonitorInterval    time.Duration `mapstructure:"monitor-interval"`
 	MinFindingSeverity string        `mapstructure:"min-finding-severity"`
+	// Findings which match one of these rules don't result in a
+	// non-zero exit code
+	Suppressions []*suppression.Rule `mapstructure:"suppressions"`
 
 	// CI Sense specific options
 	Server  string `mapstructure:"server"`
@@ -281,6 +286,11 @@ func (c *containerRemoteRunCmd) monitorCampaignRun(apiClient *api.APIClient, run
 	}
 	log.Info("Monitoring will automatically stop when the run finishes, times out, or a finding is reported.")
 
+	suppressions, err := suppression.Load(c.opts.ProjectDir, c.opts.Suppressions)
+	if err != nil {
+		return err
+	}
+
 	// if the monitor duration is set, we want to stop monitoring after the
 	// duration has passed. If the duration is less than the pull interval, we
 	// need to pull every second to make sure we don't miss the end of the run.
@@ -354,6 +364,9 @@ func (c *containerRemoteRunCmd) monitorCampaignRun(apiClient *api.APIClient, run
 				findings.Findings = filteredFindings
 			}
 
+			// Suppressed findings don't fail the run
+			findings.Findings = unsuppressedFindings(suppressions, findings.Findings)
+
 			if len(findings.Findings) > 0 {
 				for idx := range findings.Findings {
 					finding := findings.Findings[idx]
@@ -401,3 +414,32 @@ func (c *containerRemoteRunCmd) monitorCampaignRun(apiClient *api.APIClient, run
 		}
 	}
 }
+
+// unsuppressedFindings returns the remote findings which don't match any
+// of the suppression rules.
+func unsuppressedFindings(rules []*suppression.Rule, findings []api.Finding) []api.Finding {
+	var unsuppressed []api.Finding
+	for idx := range findings {
+		f := findings[idx]
+		localFinding := &finding.Finding{
+			Name:        f.DisplayName,
+			FuzzTest:    f.FuzzTargetDisplayName,
+			MoreDetails: &finding.ErrorDetails{ID: f.ErrorID},
+		}
+		for _, frame := range f.Stacktrace {
+			localFinding.StackTrace = append(localFinding.StackTrace, &stacktrace.StackFrame{
+				Function:   frame.Function,
+				SourceFile: frame.File,
+				Line:       uint32(frame.Line),
+				Column:     uint32(frame.Column),
+			})
+		}
+
+		if rule := suppression.Match(rules, localFinding, time.Now()); rule != nil {
+			log.Debugf("Suppressed finding %s, NID: %s (%s)", f.DisplayName, f.Nid, rule.Reason)
+			continue
+		}
+		unsuppressed = append(unsuppressed, f)
+	}
+	return unsuppressed
+}
@@ -14,6 +14,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/bazel"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
+	htmlCoverage "code-intelligence.com/cifuzz/internal/coverage/html"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/parser/coverage"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
@@ -35,6 +36,7 @@ type CoverageGenerator struct {
 	BuildStdout     io.Writer
 	BuildStderr     io.Writer
 	Verbose         bool
+	BuiltinHTML     bool
 }
 
 // symlinkUserInputsToGeneratedCorpus handles user defined inputs set via
@@ -261,10 +263,16 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 		cov.OutputPath = filepath.Join(outputDir, path)
 	}
 
+	if cov.BuiltinHTML {
+		return cov.generateBuiltinHTMLReport(reportPath)
+	}
+
 	// Create an HTML report via genhtml
 	genHTML, err := runfiles.Finder.GenHTMLPath()
 	if err != nil {
-		return "", err
+		log.Debug(err)
+		log.Info("genhtml not found, using the built-in HTML report generator")
+		return cov.generateBuiltinHTMLReport(reportPath)
 	}
 	args := []string{"--output", cov.OutputPath, reportPath}
 
@@ -280,6 +288,14 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	return cov.OutputPath, nil
 }
 
+func (cov *CoverageGenerator) generateBuiltinHTMLReport(reportPath string) (string, error) {
+	err := htmlCoverage.GenerateFromLCOVFile(reportPath, cov.ProjectDir, cov.OutputPath)
+	if err != nil {
+		return "", err
+	}
+	return cov.OutputPath, nil
+}
+
 // getBazelCommandFlags returns flags to be used when executing a bazel command
 // to avoid part of the loading and/or analysis phase to rerun.
 func (cov *CoverageGenerator) getBazelCommandFlags() ([]string, error) {
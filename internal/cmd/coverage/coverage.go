@@ -26,8 +26,10 @@ import (
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/coverage"
+	"code-intelligence.com/cifuzz/internal/coverage/blockers"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/log"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -47,6 +49,8 @@ type coverageOptions struct {
 	CorpusDirs   []string `mapstructure:"corpus-dirs"`
 	UseSandbox   bool     `mapstructure:"use-sandbox"`
 	EngineArgs   []string `mapstructure:"engine-args"`
+	BuiltinHTML  bool     `mapstructure:"builtin-html"`
+	Blockers     bool     `mapstructure:"blockers"`
 
 	ResolveSourceFilePath bool
 	Preset                string
@@ -80,6 +84,16 @@ func (opts *coverageOptions) validate() error {
 		return err
 	}
 
+	// The blocker analysis needs the function and branch data of an
+	// lcov report
+	if opts.Blockers {
+		if viper.IsSet("format") && opts.OutputFormat != coverage.FormatLCOV {
+			msg := `Flag 'blockers' can only be used with the output format 'lcov'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		opts.OutputFormat = coverage.FormatLCOV
+	}
+
 	validFormats := coverage.ValidOutputFormats[opts.BuildSystem]
 	if !stringutil.Contains(validFormats, opts.OutputFormat) {
 		msg := fmt.Sprintf("Flag \"format\" must be %s", strings.Join(validFormats, " or "))
@@ -129,6 +143,15 @@ The flag 'build-jobs' is only applicable for CMake, Bazel and 'other'.
 The output can be displayed in the browser or written as a HTML
 or a lcov trace file.
 
+HTML reports are created via genhtml for C/C++ and via the JaCoCo CLI
+for Java. If genhtml is not available, or if the flag 'builtin-html'
+is set, a built-in HTML report generator is used instead.
+
+With the flag 'blockers', an lcov report is created and analyzed to
+find out why coverage stalls: It lists functions which are never
+entered although they sit in well covered files, and partially taken
+branches, ranked by the amount of uncovered code behind them.
+
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Browser") + `
     cifuzz coverage <fuzz test>
 
@@ -140,6 +163,9 @@ or a lcov trace file.
 
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("XML (Jacoco Report)") + `
     cifuzz coverage --format=jacocoxml <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Fuzz blockers") + `
+    cifuzz coverage --blockers <fuzz test>
 `,
 		ValidArgsFunction: completion.ValidFuzzTests,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -149,6 +175,8 @@ or a lcov trace file.
 			bindFlags()
 			cmdutils.ViperMustBindPFlag("format", cmd.Flags().Lookup("format"))
 			cmdutils.ViperMustBindPFlag("output", cmd.Flags().Lookup("output"))
+			cmdutils.ViperMustBindPFlag("builtin-html", cmd.Flags().Lookup("builtin-html"))
+			cmdutils.ViperMustBindPFlag("blockers", cmd.Flags().Lookup("blockers"))
 
 			var lenFuzzTestArgs int
 			var argsToPass []string
@@ -232,6 +260,11 @@ or a lcov trace file.
 	}
 	cmd.Flags().StringP("format", "f", "html", "Output format of the coverage report (html/lcov).")
 	cmd.Flags().StringP("output", "o", "", "Output path of the coverage report.")
+	cmd.Flags().Bool("builtin-html", false,
+		"Create HTML reports with the built-in generator instead of genhtml or the JaCoCo CLI.")
+	cmd.Flags().Bool("blockers", false,
+		"List uncovered functions in well covered files and partially taken branches.\n"+
+			"Implies the output format 'lcov'.")
 	err = cmd.RegisterFlagCompletionFunc("format", completion.ValidCoverageOutputFormat)
 	if err != nil {
 		panic(err)
@@ -282,6 +315,7 @@ func (c *coverageCmd) run() error {
 			BuildStdout:     c.opts.buildStdout,
 			BuildStderr:     c.opts.buildStderr,
 			Verbose:         viper.GetBool("verbose"),
+			BuiltinHTML:     c.opts.BuiltinHTML,
 		}
 	case config.BuildSystemCMake, config.BuildSystemOther:
 		if c.opts.BuildSystem == config.BuildSystemOther {
@@ -301,6 +335,7 @@ func (c *coverageCmd) run() error {
 			NumBuildJobs:    c.opts.NumBuildJobs,
 			CorpusDirs:      c.opts.CorpusDirs,
 			UseSandbox:      c.opts.UseSandbox,
+			BuiltinHTML:     c.opts.BuiltinHTML,
 			FuzzTest:        c.opts.fuzzTest,
 			ProjectDir:      c.opts.ProjectDir,
 			Stderr:          c.OutOrStderr(),
@@ -338,6 +373,7 @@ func (c *coverageCmd) run() error {
 			FuzzTest:     c.opts.fuzzTest,
 			TargetMethod: c.opts.targetMethod,
 			ProjectDir:   c.opts.ProjectDir,
+			BuiltinHTML:  c.opts.BuiltinHTML,
 			Deps:         deps,
 			CorpusDirs:   c.opts.CorpusDirs,
 			EngineArgs:   c.opts.EngineArgs,
@@ -362,6 +398,7 @@ func (c *coverageCmd) run() error {
 			TestPathPattern: c.opts.fuzzTest,
 			TestNamePattern: c.opts.testNamePattern,
 			ProjectDir:      c.opts.ProjectDir,
+			BuiltinHTML:     c.opts.BuiltinHTML,
 			Stderr:          c.OutOrStderr(),
 			BuildStdout:     c.opts.buildStdout,
 			BuildStderr:     c.opts.buildStderr,
@@ -393,6 +430,9 @@ func (c *coverageCmd) run() error {
 		return c.handleHTMLReport(reportPath)
 	case coverage.FormatLCOV:
 		log.Successf("Created coverage lcov report: %s", reportPath)
+		if c.opts.Blockers {
+			return c.printBlockers(reportPath)
+		}
 		return nil
 	case coverage.FormatJacocoXML:
 		log.Successf("Created jacoco.xml coverage report: %s", reportPath)
@@ -427,6 +467,24 @@ func (c *coverageCmd) handleHTMLReport(reportPath string) error {
 	return nil
 }
 
+func (c *coverageCmd) printBlockers(reportPath string) error {
+	reportFile, err := os.Open(reportPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer reportFile.Close()
+
+	report, err := parser.ParseLCOVFileIntoLCOVReport(reportFile)
+	if err != nil {
+		return err
+	}
+
+	log.Print("\n")
+	log.Successf("Fuzz blockers:\n")
+	blockers.Analyze(report, c.opts.ProjectDir, nil).Print(c.OutOrStdout())
+	return nil
+}
+
 func (c *coverageCmd) openReport(reportPath string) error {
 	// ignore output of browser package
 	browser.Stdout = io.Discard
@@ -445,27 +503,26 @@ func (c *coverageCmd) printReportURI(reportPath string) error {
 	return nil
 }
 
+// checkDependencies checks the dependencies needed to build and run the
+// fuzz test. genhtml is not required, because HTML reports fall back to
+// the built-in generator if it's missing.
 func (c *coverageCmd) checkDependencies() error {
 	var deps []dependencies.Key
 	switch c.opts.BuildSystem {
 	case config.BuildSystemBazel:
-		deps = []dependencies.Key{
-			dependencies.Bazel,
-			dependencies.GenHTML,
-		}
+		deps = []dependencies.Key{dependencies.Bazel}
 	case config.BuildSystemCMake:
 		deps = []dependencies.Key{
 			dependencies.CMake,
 			dependencies.LLVMSymbolizer,
 			dependencies.LLVMCov,
 			dependencies.LLVMProfData,
-			dependencies.GenHTML,
 		}
 		switch runtime.GOOS {
 		case "linux", "darwin":
 			deps = append(deps, dependencies.Clang)
 		case "windows":
-			deps = append(deps, dependencies.VisualStudio, dependencies.Perl)
+			deps = append(deps, dependencies.VisualStudio)
 		}
 	case config.BuildSystemMaven:
 		deps = []dependencies.Key{dependencies.Maven}
@@ -479,7 +536,6 @@ func (c *coverageCmd) checkDependencies() error {
 			dependencies.LLVMSymbolizer,
 			dependencies.LLVMCov,
 			dependencies.LLVMProfData,
-			dependencies.GenHTML,
 		}
 	default:
 		return errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
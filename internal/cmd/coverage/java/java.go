@@ -15,6 +15,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/build/java"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/coverage"
+	htmlCoverage "code-intelligence.com/cifuzz/internal/coverage/html"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/options"
 	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
@@ -32,11 +33,16 @@ type CoverageGenerator struct {
 	FuzzTest     string
 	TargetMethod string
 	ProjectDir   string
+	BuiltinHTML  bool
 
 	Deps       []string
 	CorpusDirs []string
 	EngineArgs []string
 
+	// The source roots in the fuzz container, if the bundle includes
+	// the source files
+	SourceFilesDirs []string
+
 	BuildStdout io.Writer
 	BuildStderr io.Writer
 	Stderr      io.Writer
@@ -91,7 +97,14 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	sourceFilesDir := sourceFilesDirs[0]
 
 	htmlPath := filepath.Join(cov.OutputPath, "html")
-	jacocoXMLPath, err := cov.runJacocoCommand(cliJar, cov.jacocoExecFilePath(), htmlPath, classFilesDir, sourceFilesDir)
+	// The JaCoCo CLI is still needed to turn the jacoco.exec file into
+	// a jacoco.xml report, but the HTML report can be rendered from it
+	// by the built-in generator instead.
+	jacocoHTMLPath := htmlPath
+	if cov.BuiltinHTML {
+		jacocoHTMLPath = ""
+	}
+	jacocoXMLPath, err := cov.runJacocoCommand(cliJar, cov.jacocoExecFilePath(), jacocoHTMLPath, classFilesDir, []string{sourceFilesDir})
 	if err != nil {
 		return "", err
 	}
@@ -110,27 +123,31 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	case coverage.FormatJacocoXML:
 		return jacocoXMLPath, nil
 	case coverage.FormatHTML:
+		if cov.BuiltinHTML {
+			reportFile, err := os.Open(jacocoXMLPath)
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			defer reportFile.Close()
+
+			lcovReport, err := parser.ParseJacocoXMLIntoLCOVReport(reportFile, sourceFilesDir)
+			if err != nil {
+				return "", err
+			}
+			err = htmlCoverage.Generate(lcovReport, sourceFilesDir, htmlPath)
+			if err != nil {
+				return "", err
+			}
+		}
 		return htmlPath, nil
 	case coverage.FormatLCOV:
-		// Open report here again otherwise it will be seen as empty
-		// after parsing it into the summary
-		reportFile, err := os.Open(jacocoXMLPath)
-		if err != nil {
-			return "", errors.WithStack(err)
-		}
-
-		lcovReport, err := parser.ParseJacocoXMLIntoLCOVReport(reportFile, sourceFilesDir)
-		if err != nil {
-			return "", err
-		}
-
 		lcovFilePath := filepath.Join(cov.OutputPath, "report.lcov")
-		err = lcovReport.WriteLCOVReportToFile(lcovFilePath)
+		err = convertJacocoXMLToLCOV(jacocoXMLPath, []string{sourceFilesDir}, lcovFilePath)
 		if err != nil {
 			return "", err
 		}
 
-		return lcovFilePath, err
+		return lcovFilePath, nil
 	}
 
 	return "", fmt.Errorf("undefined output format: %s", cov.OutputFormat)
@@ -175,36 +192,26 @@ func (cov *CoverageGenerator) GenerateCoverageReportInFuzzContainer(jacocoExecFi
 	}
 
 	classFilesDir := "/cifuzz/runtime_deps/target/classes"
-	// Here and in the call to parser.ParseJacocoXMLIntoLCOVReport below, we do not pass in a
-	// non-empty sourceFilesDir as source files aren't available in fuzz containers anyway. We are
-	// only interested in coverage statistics, not actual source file contents.
-	jacocoXMLFile, err := cov.runJacocoCommand(cliJar, jacocoExecFilePath, "", classFilesDir, "")
-	if err != nil {
-		return "", err
-	}
-
-	// Convert jacoco.xml report to LCOV report
-	fileReader, err := os.Open(jacocoXMLFile)
-	if err != nil {
-		return "", errors.WithStack(err)
-	}
-	defer fileReader.Close()
-	lcovReport, err := parser.ParseJacocoXMLIntoLCOVReport(fileReader, "")
+	// Here and in the call to convertJacocoXMLToLCOV below, the source
+	// files dirs are only non-empty if the bundle includes the source
+	// files. Else, we are only interested in coverage statistics, not
+	// actual source file contents.
+	jacocoXMLFile, err := cov.runJacocoCommand(cliJar, jacocoExecFilePath, "", classFilesDir, cov.SourceFilesDirs)
 	if err != nil {
 		return "", err
 	}
 
 	// Remove jacoco.xml file because we don't need it anymore
 	defer func() {
-		err = os.Remove(jacocoXMLFile)
+		err := os.Remove(jacocoXMLFile)
 		if err != nil {
 			log.Debugf("Failed to remove intermediate jacoco.xml report: %v", err)
 		}
 	}()
 
-	// Write the LCOV report to the specified path.
+	// Convert jacoco.xml report to an LCOV report at the specified path.
 	lcovPath := filepath.Join(cov.OutputPath, "report.lcov")
-	err = lcovReport.WriteLCOVReportToFile(lcovPath)
+	err = convertJacocoXMLToLCOV(jacocoXMLFile, cov.SourceFilesDirs, lcovPath)
 	if err != nil {
 		return "", err
 	}
@@ -246,7 +253,7 @@ func (cov *CoverageGenerator) jacocoExecFilePath() string {
 	return filepath.Join(cov.OutputPath, fmt.Sprintf("jacoco_%s_%s.exec", cov.FuzzTest, cov.TargetMethod))
 }
 
-func (cov *CoverageGenerator) runJacocoCommand(cliJar, jacocoExecPath, htmlPath, classFilesDir, sourceFilesDir string) (string, error) {
+func (cov *CoverageGenerator) runJacocoCommand(cliJar, jacocoExecPath, htmlPath, classFilesDir string, sourceFilesDirs []string) (string, error) {
 	jacocoXMLPath := filepath.Join(cov.OutputPath, "jacoco.xml")
 
 	args := []string{
@@ -255,11 +262,11 @@ func (cov *CoverageGenerator) runJacocoCommand(cliJar, jacocoExecPath, htmlPath,
 		"--xml", jacocoXMLPath,
 		"--classfiles", classFilesDir,
 	}
-	if sourceFilesDir != "" {
-		args = append(args, "--sourcefiles", sourceFilesDir)
+	for _, dir := range sourceFilesDirs {
+		args = append(args, "--sourcefiles", dir)
 	}
 	// Set html output path if needed
-	if cov.OutputFormat == coverage.FormatHTML {
+	if cov.OutputFormat == coverage.FormatHTML && htmlPath != "" {
 		args = append(args, "--html", htmlPath)
 	}
 
@@ -369,3 +376,26 @@ func (cov *CoverageGenerator) produceJacocoExec(agentJarPath, jacocoExecFilePath
 
 	return nil
 }
+
+// convertJacocoXMLToLCOV streams the jacoco.xml report into an LCOV
+// report, so that large reports don't have to be held in memory.
+func convertJacocoXMLToLCOV(jacocoXMLPath string, sourceFilesDirs []string, lcovPath string) error {
+	reportFile, err := os.Open(jacocoXMLPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer reportFile.Close()
+
+	// Note: file needs read/write access to be used with genhtml later on
+	lcovFile, err := os.OpenFile(lcovPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer lcovFile.Close()
+
+	err = parser.ConvertJacocoXMLToLCOV(reportFile, sourceFilesDirs, lcovFile)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(lcovFile.Close())
+}
@@ -22,6 +22,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	htmlCoverage "code-intelligence.com/cifuzz/internal/coverage/html"
 	"code-intelligence.com/cifuzz/pkg/binary"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/minijail"
@@ -44,12 +45,20 @@ type CoverageGenerator struct {
 	NumBuildJobs    uint
 	CorpusDirs      []string
 	UseSandbox      bool
+	BuiltinHTML     bool
 	FuzzTest        string
 	ProjectDir      string
 	Stderr          io.Writer
 	BuildStdout     io.Writer
 	BuildStderr     io.Writer
 
+	// If LocalSourceDir is set, the source paths below BuildSourceDir,
+	// which is the project directory on the machine where the coverage
+	// binary was built, are mapped to LocalSourceDir. The report which
+	// is produced in the fuzz container then includes line coverage.
+	BuildSourceDir string
+	LocalSourceDir string
+
 	coverageBinary string
 	libraryDirs    []string
 	runtimeDeps    []string
@@ -164,7 +173,14 @@ func (cov *CoverageGenerator) GenerateCoverageReportInFuzzContainer(ctx context.
 		return err
 	}
 
-	lcovReportSummary, err := cov.lcovReportSummary(ctx)
+	var report string
+	if cov.LocalSourceDir != "" {
+		// The source files are available, so the report can include
+		// line coverage
+		report, err = cov.lcovReport(ctx)
+	} else {
+		report, err = cov.lcovReportSummary(ctx)
+	}
 	if err != nil {
 		return err
 	}
@@ -173,7 +189,7 @@ func (cov *CoverageGenerator) GenerateCoverageReportInFuzzContainer(ctx context.
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	err = os.WriteFile(outputPath, []byte(lcovReportSummary), 0o644)
+	err = os.WriteFile(outputPath, []byte(report), 0o644)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -503,10 +519,16 @@ func (cov *CoverageGenerator) generateHTMLReport(ctx context.Context) (string, e
 		cov.OutputPath = filepath.Join(outputDir, cov.executableName())
 	}
 
+	if cov.BuiltinHTML {
+		return cov.generateBuiltinHTMLReport(lcovReport)
+	}
+
 	// Create an HTML report via genhtml
 	genHTML, err := runfiles.Finder.GenHTMLPath()
 	if err != nil {
-		return "", err
+		log.Debug(err)
+		log.Info("genhtml not found, using the built-in HTML report generator")
+		return cov.generateBuiltinHTMLReport(lcovReport)
 	}
 	args = []string{"--output", cov.OutputPath, lcovReport}
 
@@ -517,7 +539,9 @@ func (cov *CoverageGenerator) generateHTMLReport(ctx context.Context) (string, e
 		args = append([]string{genHTML}, args...)
 		perl, err := runfiles.Finder.PerlPath()
 		if err != nil {
-			return "", err
+			log.Debug(err)
+			log.Info("perl not found, using the built-in HTML report generator")
+			return cov.generateBuiltinHTMLReport(lcovReport)
 		}
 		cmd = exec.Command(perl, args...)
 	} else {
@@ -535,6 +559,14 @@ func (cov *CoverageGenerator) generateHTMLReport(ctx context.Context) (string, e
 	return cov.OutputPath, nil
 }
 
+func (cov *CoverageGenerator) generateBuiltinHTMLReport(lcovReport string) (string, error) {
+	err := htmlCoverage.GenerateFromLCOVFile(lcovReport, cov.ProjectDir, cov.OutputPath)
+	if err != nil {
+		return "", err
+	}
+	return cov.OutputPath, nil
+}
+
 func (cov *CoverageGenerator) runLlvmCov(ctx context.Context, args []string) (string, error) {
 	llvmCov, err := cov.runfilesFinder.LLVMCovPath()
 	if err != nil {
@@ -544,6 +576,9 @@ func (cov *CoverageGenerator) runLlvmCov(ctx context.Context, args []string) (st
 	// Add all runtime dependencies of the fuzz test to the binaries
 	// processed by llvm-cov to include them in the coverage report
 	args = append(args, "-instr-profile="+cov.indexedProfilePath())
+	if cov.BuildSourceDir != "" && cov.LocalSourceDir != "" {
+		args = append(args, fmt.Sprintf("-path-equivalence=%s,%s", cov.BuildSourceDir, cov.LocalSourceDir))
+	}
 	args = append(args, cov.coverageBinary)
 	if archArg, err := cov.archFlagIfNeeded(cov.coverageBinary); err != nil {
 		return "", err
@@ -570,13 +605,7 @@ func (cov *CoverageGenerator) runLlvmCov(ctx context.Context, args []string) (st
 }
 
 func (cov *CoverageGenerator) generateLcovReport(ctx context.Context) (string, error) {
-	args := []string{"export", "-format=lcov"}
-	ignoreCIFuzzIncludesArgs, err := cov.getIgnoreCIFuzzIncludesArgs()
-	if err != nil {
-		return "", err
-	}
-	args = append(args, ignoreCIFuzzIncludesArgs...)
-	report, err := cov.runLlvmCov(ctx, args)
+	report, err := cov.lcovReport(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -600,6 +629,16 @@ func (cov *CoverageGenerator) generateLcovReport(ctx context.Context) (string, e
 	return outputPath, nil
 }
 
+func (cov *CoverageGenerator) lcovReport(ctx context.Context) (string, error) {
+	args := []string{"export", "-format=lcov"}
+	ignoreCIFuzzIncludesArgs, err := cov.getIgnoreCIFuzzIncludesArgs()
+	if err != nil {
+		return "", err
+	}
+	args = append(args, ignoreCIFuzzIncludesArgs...)
+	return cov.runLlvmCov(ctx, args)
+}
+
 func (cov *CoverageGenerator) lcovReportSummary(ctx context.Context) (string, error) {
 	args := []string{"export", "-format=lcov", "-summary-only"}
 	ignoreCIFuzzIncludesArgs, err := cov.getIgnoreCIFuzzIncludesArgs()
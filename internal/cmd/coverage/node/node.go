@@ -13,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/internal/coverage"
+	htmlCoverage "code-intelligence.com/cifuzz/internal/coverage/html"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/options"
 	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
@@ -26,6 +27,7 @@ type CoverageGenerator struct {
 	TestPathPattern string
 	TestNamePattern string
 	ProjectDir      string
+	BuiltinHTML     bool
 
 	Stderr      io.Writer
 	BuildStdout io.Writer
@@ -57,16 +59,29 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	args = append(args, options.JazzerJSTestPathPatternFlag(cov.TestPathPattern))
 	args = append(args, options.JazzerJSTestNamePatternFlag(cov.TestNamePattern))
 	args = append(args, options.JazzerJSCoverageDirectoryFlag(cov.OutputPath))
-	// the lcov coverage reporter generates both the lcov.info and an html report
-	args = append(args, options.JazzerJSCoverageReportersFlag(coverage.FormatLCOV))
+	// The json coverage reporter generates the coverage-final.json
+	// report, which we convert into an lcov report ourselves so that
+	// source maps are applied.
+	args = append(args, options.JazzerJSCoverageReportersFlag("json"))
+	if cov.OutputFormat == coverage.FormatHTML && !cov.BuiltinHTML {
+		// the lcov coverage reporter generates both the lcov.info and an html report
+		args = append(args, options.JazzerJSCoverageReportersFlag(coverage.FormatLCOV))
+	}
 
 	err = cov.runNPXCommand(args, cov.BuildStdout, cov.BuildStderr)
 	if err != nil {
 		return "", err
 	}
 
-	// generate the summary table
+	// Replaces the lcov.info written by jest's lcov reporter (if any),
+	// which contains the transpiled files instead of the original ones
 	reportPath := filepath.Join(cov.OutputPath, "lcov.info")
+	err = convertIstanbulJSONToLCOV(filepath.Join(cov.OutputPath, "coverage-final.json"), reportPath)
+	if err != nil {
+		return "", err
+	}
+
+	// generate the summary table
 	reportFile, err := os.Open(reportPath)
 	if err != nil {
 		return "", errors.WithStack(err)
@@ -78,8 +93,16 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	}
 	summary.PrintTable(cov.Stderr)
 
-	// the index.html file is located in the subfolder lcov-report
-	if cov.OutputFormat == "html" {
+	if cov.OutputFormat == coverage.FormatHTML {
+		if cov.BuiltinHTML {
+			htmlPath := filepath.Join(cov.OutputPath, "html")
+			err = htmlCoverage.GenerateFromLCOVFile(reportPath, cov.ProjectDir, htmlPath)
+			if err != nil {
+				return "", err
+			}
+			return htmlPath, nil
+		}
+		// the index.html file is located in the subfolder lcov-report
 		reportPath = filepath.Join(cov.OutputPath, "lcov-report")
 	}
 
@@ -144,3 +167,23 @@ func (cov *CoverageGenerator) runNPXCommand(args []string, stdout, stderr io.Wri
 
 	return nil
 }
+
+func convertIstanbulJSONToLCOV(jsonPath, lcovPath string) error {
+	jsonFile, err := os.Open(jsonPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer jsonFile.Close()
+
+	lcovFile, err := os.OpenFile(lcovPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer lcovFile.Close()
+
+	err = parser.ConvertIstanbulJSONToLCOV(jsonFile, lcovFile)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(lcovFile.Close())
+}
@@ -0,0 +1,277 @@
+//go:build !windows
+
+package execute
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// minFuzzerTimeout is the minimum time a fuzzer is run with --all.
+// Fuzzers which would get less time from the remaining budget are
+// skipped.
+const minFuzzerTimeout = time.Second
+
+var unsafeDirNameCharsRegex = regexp.MustCompile(`[^\w.\-]+`)
+
+// fuzzerResult is the outcome of running one of the fuzzers of the
+// bundle with --all.
+type fuzzerResult struct {
+	Name       string   `json:"name"`
+	Engine     string   `json:"engine"`
+	Sanitizer  string   `json:"sanitizer,omitempty"`
+	OutputDir  string   `json:"output_dir"`
+	CorpusDir  string   `json:"generated_corpus_dir"`
+	Timeout    float64  `json:"timeout_seconds"`
+	Duration   float64  `json:"duration_seconds"`
+	Executions uint64   `json:"executions"`
+	Findings   []string `json:"findings"`
+	Skipped    bool     `json:"skipped,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type executeSummary struct {
+	Fuzzers  []*fuzzerResult `json:"fuzzers"`
+	Findings int             `json:"findings"`
+	Failed   int             `json:"failed"`
+	Skipped  int             `json:"skipped"`
+	Duration float64         `json:"duration_seconds"`
+}
+
+// runAll runs all fuzzers of the bundle in the order in which they are
+// listed in the metadata and prints a summary of the runs.
+func (c *executeCmd) runAll(metadata *archive.Metadata) error {
+	if c.opts.CoverageOutputPath != "" {
+		msg := "The --coverage-output-path flag cannot be used with the --all flag."
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if c.opts.JSONOutputFilePath != "" {
+		msg := "The --json-output-file flag cannot be used with the --all flag, the JSON output of each fuzz test is stored in --output-dir."
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	fuzzers := runnableFuzzers(metadata)
+	if len(fuzzers) == 0 {
+		return errors.New("No fuzzers found in the bundle metadata file")
+	}
+	if c.opts.TotalTime == 0 {
+		// Without a time budget, the fuzzers must have a max run time,
+		// else the first fuzzer would run forever
+		for _, fuzzer := range fuzzers {
+			if fuzzer.MaxRunTime == 0 {
+				msg := fmt.Sprintf("Fuzz test %s has no max run time, please specify a time budget via --total-time.", getFuzzerName(fuzzer))
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+		}
+	}
+
+	if c.opts.PrintBundleMetadata {
+		err := printMetadata(metadata, os.Stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	summary := &executeSummary{}
+	start := time.Now()
+	var runErr error
+	for i, fuzzer := range fuzzers {
+		dirName := fuzzerDirName(fuzzer)
+		result := &fuzzerResult{
+			Name:      getFuzzerName(fuzzer),
+			Engine:    fuzzer.Engine,
+			Sanitizer: fuzzer.Sanitizer,
+			OutputDir: filepath.Join(c.opts.OutputDir, dirName),
+			CorpusDir: filepath.Join(c.opts.GeneratedCorpusDir, dirName),
+			Findings:  []string{},
+		}
+		summary.Fuzzers = append(summary.Fuzzers, result)
+
+		var budget time.Duration
+		if c.opts.TotalTime > 0 {
+			budget = c.opts.TotalTime - time.Since(start)
+		}
+		timeout := fuzzerTimeout(fuzzer.MaxRunTime, budget, len(fuzzers)-i)
+		if c.opts.TotalTime > 0 && timeout < minFuzzerTimeout {
+			log.Warnf("Skipping fuzz test %s, the time budget is exhausted", result.Name)
+			result.Skipped = true
+			summary.Skipped++
+			continue
+		}
+		result.Timeout = timeout.Seconds()
+
+		log.Infof("Running fuzz test %s (%d/%d) for %s", fuzzerDisplayName(fuzzer), i+1, len(fuzzers), timeout)
+		fuzzerStart := time.Now()
+		reportHandler, err := c.runFuzzer(metadata, fuzzer, &fuzzerRun{
+			jsonOutputFilePath: filepath.Join(result.OutputDir, "output.json"),
+			generatedCorpusDir: result.CorpusDir,
+			timeout:            timeout,
+		})
+		result.Duration = time.Since(fuzzerStart).Seconds()
+		if reportHandler != nil {
+			if reportHandler.LastMetrics != nil {
+				result.Executions = reportHandler.LastMetrics.TotalExecutions
+			}
+			for _, f := range reportHandler.Findings {
+				result.Findings = append(result.Findings, f.ShortDescriptionWithName())
+			}
+			summary.Findings += len(reportHandler.Findings)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			summary.Failed++
+			var signalErr *cmdutils.SignalError
+			if errors.As(err, &signalErr) {
+				// Don't run the remaining fuzzers if the user
+				// interrupted the execution
+				runErr = err
+				break
+			}
+			log.Errorf(err, "Fuzz test %s failed: %v", result.Name, err)
+		}
+	}
+	summary.Duration = time.Since(start).Seconds()
+
+	err := writeSummary(summary, filepath.Join(c.opts.OutputDir, "summary.json"))
+	if err != nil {
+		return err
+	}
+	if c.opts.PrintJSON {
+		err = printSummaryJSON(summary, os.Stdout)
+	} else {
+		err = printSummary(summary)
+	}
+	if err != nil {
+		return err
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+	if summary.Failed > 0 {
+		return cmdutils.WrapSilentError(errors.Errorf("%d of %d fuzz tests failed", summary.Failed, len(fuzzers)))
+	}
+	return nil
+}
+
+// runnableFuzzers returns all fuzzers of the bundle except for the
+// coverage binaries.
+func runnableFuzzers(metadata *archive.Metadata) []*archive.Fuzzer {
+	var fuzzers []*archive.Fuzzer
+	for _, fuzzer := range metadata.Fuzzers {
+		if fuzzer.Engine != "LLVM_COV" {
+			fuzzers = append(fuzzers, fuzzer)
+		}
+	}
+	return fuzzers
+}
+
+// fuzzerTimeout returns the time the fuzzer is run for. If a time
+// budget is given, it's split equally across the remaining fuzzers,
+// so that the time not used by previous fuzzers is available to the
+// following ones. The max run time of the fuzzer is respected in any
+// case.
+func fuzzerTimeout(maxRunTime uint, budget time.Duration, remainingFuzzers int) time.Duration {
+	timeout := time.Duration(maxRunTime) * time.Second
+	if budget != 0 {
+		share := budget / time.Duration(remainingFuzzers)
+		if timeout == 0 || share < timeout {
+			timeout = share
+		}
+	}
+	return timeout
+}
+
+// fuzzerDirName returns the name of the directory in which the output
+// of the fuzzer is stored. It includes the sanitizer, so that the
+// sanitizer variants of the same fuzz test don't share a directory.
+func fuzzerDirName(fuzzer *archive.Fuzzer) string {
+	name := getFuzzerName(fuzzer)
+	if fuzzer.Sanitizer != "" {
+		name += "-" + strings.ToLower(fuzzer.Sanitizer)
+	}
+	return unsafeDirNameCharsRegex.ReplaceAllString(name, "_")
+}
+
+func fuzzerDisplayName(fuzzer *archive.Fuzzer) string {
+	name := getFuzzerName(fuzzer)
+	if fuzzer.Sanitizer != "" {
+		name += fmt.Sprintf(" (%s)", strings.ToLower(fuzzer.Sanitizer))
+	}
+	return name
+}
+
+func writeSummary(summary *executeSummary, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return printSummaryJSON(summary, f)
+}
+
+func printSummaryJSON(summary *executeSummary, output io.Writer) error {
+	summaryJSON, err := stringutil.ToJSONString(summary)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, summaryJSON)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func printSummary(summary *executeSummary) error {
+	data := [][]string{{"Fuzz Test", "Engine", "Sanitizer", "Duration", "Executions", "Findings", "Status"}}
+	for _, result := range summary.Fuzzers {
+		status := "OK"
+		switch {
+		case result.Skipped:
+			status = "skipped"
+		case result.Error != "":
+			status = "failed"
+		}
+		data = append(data, []string{
+			result.Name,
+			result.Engine,
+			strings.ToLower(result.Sanitizer),
+			time.Duration(result.Duration * float64(time.Second)).Round(time.Second).String(),
+			fmt.Sprint(result.Executions),
+			fmt.Sprint(len(result.Findings)),
+			status,
+		})
+	}
+	log.Print("\n")
+	err := pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, result := range summary.Fuzzers {
+		for _, f := range result.Findings {
+			log.Printf("  %s: %s", fuzzerDisplayName(&archive.Fuzzer{Name: result.Name, Sanitizer: result.Sanitizer}), f)
+		}
+	}
+	log.Printf("\nRan %d fuzz tests in %s: %d findings, %d failed, %d skipped",
+		len(summary.Fuzzers), time.Duration(summary.Duration*float64(time.Second)).Round(time.Second),
+		summary.Findings, summary.Failed, summary.Skipped)
+	return nil
+}
@@ -6,7 +6,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -28,6 +31,7 @@ import (
 	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/runner/jazzer"
+	"code-intelligence.com/cifuzz/pkg/runner/jazzerjs"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
@@ -36,10 +40,17 @@ import (
 type executeOpts struct {
 	PrintJSON           bool   `mapstructure:"print-json"`
 	SingleFuzzTest      bool   `mapstructure:"single-fuzz-test"`
+	All                 bool   `mapstructure:"all"`
 	PrintBundleMetadata bool   `mapstructure:"print-bundle-metadata"`
 	JSONOutputFilePath  string `mapstructure:"json-output-file"`
 	GeneratedCorpusDir  string `mapstructure:"generated-corpus-dir"`
 	CoverageOutputPath  string `mapstructure:"coverage-output-path"`
+	PublicKey           string `mapstructure:"public-key"`
+	SignaturePath       string `mapstructure:"signature"`
+	OutputDir           string `mapstructure:"output-dir"`
+
+	CoverageSnapshotInterval time.Duration `mapstructure:"coverage-snapshot-interval"`
+	TotalTime                time.Duration `mapstructure:"total-time"`
 
 	name string
 }
@@ -60,26 +71,48 @@ func New() *cobra.Command {
 It can be used as an experimental alternative to cifuzz_runner.
 It is currently only intended for use with the 'cifuzz container' subcommand.
 
+With --all, all fuzzers of the bundle are run one after the other,
+including the sanitizer variants of the same fuzz test. Each fuzzer
+runs for the max run time specified in the bundle or, if --total-time
+is set, for an equal share of the remaining time budget, whichever is
+shorter. The generated corpus of each fuzzer is stored in a subdirectory
+of --generated-corpus-dir and its JSON output in a subdirectory of
+--output-dir. A summary of all runs is printed at the end and written
+to summary.json in --output-dir.
+
 `,
-		Example: "cifuzz execute [fuzz test]",
-		Args:    cobra.MaximumNArgs(1),
+		Example: `cifuzz execute [fuzz test]
+cifuzz execute --all --total-time 1h`,
+		Args: cobra.MaximumNArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			// Bind viper keys to flags. We can't do this in the New
 			// function, because that would re-bind viper keys which
 			// were bound to the flags of other commands before.
 			bindFlags()
 			cmdutils.ViperMustBindPFlag("single-fuzz-test", cmd.Flags().Lookup("single-fuzz-test"))
+			cmdutils.ViperMustBindPFlag("all", cmd.Flags().Lookup("all"))
+			cmdutils.ViperMustBindPFlag("total-time", cmd.Flags().Lookup("total-time"))
+			cmdutils.ViperMustBindPFlag("output-dir", cmd.Flags().Lookup("output-dir"))
 			cmdutils.ViperMustBindPFlag("print-bundle-metadata", cmd.Flags().Lookup("print-bundle-metadata"))
 			cmdutils.ViperMustBindPFlag("coverage-output-path", cmd.Flags().Lookup("coverage-output-path"))
+			cmdutils.ViperMustBindPFlag("coverage-snapshot-interval", cmd.Flags().Lookup("coverage-snapshot-interval"))
 			cmdutils.ViperMustBindPFlag("stop-signal-file", cmd.Flags().Lookup("stop-signal-file"))
 			cmdutils.ViperMustBindPFlag("json-output-file", cmd.Flags().Lookup("json-output-file"))
 			cmdutils.ViperMustBindPFlag("generated-corpus-dir", cmd.Flags().Lookup("generated-corpus-dir"))
+			cmdutils.ViperMustBindPFlag("public-key", cmd.Flags().Lookup("public-key"))
+			cmdutils.ViperMustBindPFlag("signature", cmd.Flags().Lookup("signature"))
 			opts.SingleFuzzTest = viper.GetBool("single-fuzz-test")
+			opts.All = viper.GetBool("all")
+			opts.TotalTime = viper.GetDuration("total-time")
+			opts.OutputDir = viper.GetString("output-dir")
 			opts.PrintBundleMetadata = viper.GetBool("print-bundle-metadata")
 			opts.CoverageOutputPath = viper.GetString("coverage-output-path")
+			opts.CoverageSnapshotInterval = viper.GetDuration("coverage-snapshot-interval")
 			opts.PrintJSON = viper.GetBool("print-json")
 			opts.JSONOutputFilePath = viper.GetString("json-output-file")
 			opts.GeneratedCorpusDir = viper.GetString("generated-corpus-dir")
+			opts.PublicKey = viper.GetString("public-key")
+			opts.SignaturePath = viper.GetString("signature")
 		},
 		RunE: func(c *cobra.Command, args []string) error {
 			if signalFile := viper.GetString("stop-signal-file"); signalFile != "" {
@@ -91,11 +124,27 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 				}()
 			}
 
+			// Check the integrity of the bundle before using any of
+			// its files
+			err := verifyBundle(opts)
+			if err != nil {
+				return err
+			}
+
 			metadata, err := getMetadata()
 			if err != nil {
 				return err
 			}
 
+			if opts.All {
+				if len(args) > 0 || opts.SingleFuzzTest {
+					msg := "The --all flag cannot be used with the <fuzz test> argument or the --single-fuzz-test flag."
+					return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+				}
+				cmd := executeCmd{Command: c, opts: opts}
+				return cmd.runAll(metadata)
+			}
+
 			// If there are no arguments provided, provide a helpful message and list all available fuzzers.
 			if len(args) == 0 && !opts.SingleFuzzTest {
 				return printNotice(metadata)
@@ -118,10 +167,19 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 	cmdutils.DisableConfigCheck(cmd)
 
 	cmd.Flags().Bool("single-fuzz-test", false, "Run the only fuzz test in the bundle (without specifying the fuzz test name).")
+	cmd.Flags().Bool("all", false, "Run all fuzz tests in the bundle one after the other.")
+	cmd.Flags().Duration("total-time", 0, "With --all, the time budget which is split across the fuzz tests.")
+	cmd.Flags().String("output-dir", "/tmp/cifuzz-output", "With --all, the directory where the JSON output of each fuzz test and the summary are stored.")
 	cmd.Flags().Bool("print-bundle-metadata", false, "Print the bundle metadata as JSON.")
 	cmd.Flags().String("coverage-output-path", "", "Produce an LCOV coverage report at the specified path after running the fuzz test.")
+	cmd.Flags().Duration("coverage-snapshot-interval", 0,
+		"Update the coverage report at --coverage-output-path in this interval while the fuzz test is running (libFuzzer only).\n"+
+			"Each snapshot only replays the inputs added since the last snapshot. A history of the line coverage\n"+
+			"is written next to the report.")
 	cmd.Flags().String("stop-signal-file", "", "CI Fuzz will create a file 'cifuzz-execution-finished' upon exit")
 	cmd.Flags().String("json-output-file", "", "Print output as JSON to the specified file (implies --json)")
+	cmd.Flags().String("public-key", "", "Only execute the bundle if its manifest is signed by the private key corresponding to the ed25519 public key in this `file` (PEM encoded).")
+	cmd.Flags().String("signature", "", "Path to a detached signature of the bundle manifest. By default, the signature contained in the bundle is used.")
 	cmd.Flags().String("generated-corpus-dir", "/tmp/generated-corpus", "The directory where inputs which increased the coverage are stored. The user running the container must have write access to this directory.")
 
 	// Note: If a flag should be configurable via viper as well (i.e.
@@ -140,27 +198,52 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		return err
 	}
 
-	err = checkDependencies(fuzzer)
+	_, err = c.runFuzzer(metadata, fuzzer, &fuzzerRun{
+		jsonOutputFilePath:  c.opts.JSONOutputFilePath,
+		generatedCorpusDir:  c.opts.GeneratedCorpusDir,
+		timeout:             time.Duration(fuzzer.MaxRunTime) * time.Second,
+		printBundleMetadata: c.opts.PrintBundleMetadata,
+	})
+	return err
+}
+
+// fuzzerRun holds the options which differ between the fuzzers when
+// running all fuzzers of the bundle.
+type fuzzerRun struct {
+	jsonOutputFilePath  string
+	generatedCorpusDir  string
+	timeout             time.Duration
+	printBundleMetadata bool
+}
+
+// runFuzzer runs the fuzzer and returns the report handler, which
+// contains the findings and metrics of the run.
+func (c *executeCmd) runFuzzer(metadata *archive.Metadata, fuzzer *archive.Fuzzer, run *fuzzerRun) (*reporthandler.ReportHandler, error) {
+	err := checkDependencies(fuzzer)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// --json-output-file implies --json
+	printJSON := c.opts.PrintJSON || run.jsonOutputFilePath != ""
+
 	var jsonOutput, printerOutput io.Writer
 	// Set the output streams depending on the flags.
-	if c.opts.JSONOutputFilePath != "" {
-		// --json-output-file implies --json
-		c.opts.PrintJSON = true
-
+	if run.jsonOutputFilePath != "" {
+		err = os.MkdirAll(filepath.Dir(run.jsonOutputFilePath), 0o755)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 		// Create the output file in write-only mode.
-		f, err := os.OpenFile(c.opts.JSONOutputFilePath, os.O_WRONLY|os.O_CREATE, 0644)
+		f, err := os.OpenFile(run.jsonOutputFilePath, os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		}
 		defer f.Close()
 		// Write JSON output to the file and printer output to stdout.
 		jsonOutput = f
 		printerOutput = os.Stdout
-	} else if c.opts.PrintJSON {
+	} else if printJSON {
 		// Write JSON output to stdout and printer output to stderr.
 		jsonOutput = os.Stdout
 		printerOutput = os.Stderr
@@ -170,26 +253,26 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		printerOutput = os.Stdout
 	}
 
-	if c.opts.PrintBundleMetadata {
+	if run.printBundleMetadata {
 		var metadataOutput io.Writer
-		if c.opts.PrintJSON {
+		if printJSON {
 			metadataOutput = jsonOutput
 		} else {
 			metadataOutput = os.Stdout
 		}
 		err := printMetadata(metadata, metadataOutput)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	err = os.MkdirAll(container.ManagedSeedCorpusDir, 0o755)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
-	err = os.MkdirAll(c.opts.GeneratedCorpusDir, 0o755)
+	err = os.MkdirAll(run.generatedCorpusDir, 0o755)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
 	reportHandler, err := reporthandler.NewReportHandler(
@@ -206,21 +289,37 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 			JSONOutput:        jsonOutput,
 		})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	runnerOpts := &libfuzzer.RunnerOptions{
 		FuzzTarget:         fuzzer.Path,
 		EngineArgs:         fuzzer.EngineOptions.Flags,
-		Timeout:            time.Duration(fuzzer.MaxRunTime) * time.Second,
+		Timeout:            run.timeout,
 		ProjectDir:         fuzzer.ProjectDir,
 		UseMinijail:        false,
 		LibraryDirs:        fuzzer.LibraryPaths,
 		Verbose:            viper.GetBool("verbose"),
 		ReportHandler:      reportHandler,
-		GeneratedCorpusDir: c.opts.GeneratedCorpusDir,
+		GeneratedCorpusDir: run.generatedCorpusDir,
 		EnvVars:            []string{"NO_CIFUZZ=1"},
-		KeepColor:          !c.opts.PrintJSON && !log.PlainStyle(),
+		KeepColor:          !printJSON && !log.PlainStyle(),
+	}
+
+	// If the bundle includes the source files, resolve the paths in
+	// stack traces and coverage reports against them. The paths in the
+	// debug info are paths on the build machine, which are mapped to
+	// the sources in the bundle.
+	var sourceDir string
+	if metadata.Sources != nil {
+		sourceDir, err = filepath.Abs(metadata.Sources.Dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sources := *metadata.Sources
+		sources.Dir = sourceDir
+		runnerOpts.SourceDir = sourceDir
+		runnerOpts.LocalSourcePath = sources.LocalPath
 	}
 
 	var runner adapter.FuzzerRunner
@@ -233,7 +332,7 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		dictFileName := "dict"
 		exists, err := fileutil.Exists(dictFileName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if exists {
 			runnerOpts.Dictionary = dictFileName
@@ -244,11 +343,11 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		entries, err := os.ReadDir(userSeedCorpusDir)
 		// Don't return an error if the directory doesn't exist.
 		if err != nil && !os.IsNotExist(err) {
-			return errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		}
 		for _, entry := range entries {
 			if !entry.IsDir() {
-				return errors.Errorf("unexpected file in user seed corpus dir %q: %s", userSeedCorpusDir, entry.Name())
+				return nil, errors.Errorf("unexpected file in user seed corpus dir %q: %s", userSeedCorpusDir, entry.Name())
 			}
 			seedCorpusDir := fmt.Sprintf("%s/%s", userSeedCorpusDir, entry.Name())
 			runnerOpts.SeedCorpusDirs = append(runnerOpts.SeedCorpusDirs, seedCorpusDir)
@@ -257,12 +356,12 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		sourceMapFileName := "source_map.json"
 		exists, err = fileutil.Exists(sourceMapFileName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if exists {
 			sourceMap, err := sourcemap.ReadSourceMapFromFile("source_map.json")
 			if err != nil {
-				return err
+				return nil, err
 			}
 			runnerOpts.SourceMap = sourceMap
 		}
@@ -281,29 +380,51 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 			LibfuzzerOptions: runnerOpts,
 		}
 		runner = jazzer.NewRunner(runnerOpts)
-	default:
-		// Use dictionary file if the bundle includes one.
-		dictFileName := fuzzer.Dictionary
-		exists, err := fileutil.Exists(dictFileName)
-		if err != nil {
-			return err
+	case "JAZZER_JS":
+		if c.opts.CoverageOutputPath != "" {
+			return nil, errors.New("Producing coverage reports is not supported for Node.js fuzz tests")
 		}
-		if exists {
-			runnerOpts.Dictionary = dictFileName
+		if len(fuzzer.RuntimePaths) == 0 {
+			return nil, errors.Errorf("No project directory specified for fuzz test %s", fuzzer.Name)
 		}
 
-		// Use seed corpus dirs if the bundle includes any.
-		entries, err := os.ReadDir(fuzzer.Seeds)
-		// Don't return an error if the directory doesn't exist.
-		if err != nil && !os.IsNotExist(err) {
-			return errors.WithStack(err)
+		err = addDictionaryAndSeeds(runnerOpts, fuzzer)
+		if err != nil {
+			return nil, err
 		}
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				return errors.Errorf("unexpected file in user seed corpus dir %q: %s", fuzzer.Seeds, entry.Name())
+
+		// Jest is run in the project directory contained in the bundle
+		runnerOpts.ProjectDir, err = filepath.Abs(fuzzer.RuntimePaths[0])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		testPath, err := filepath.Rel(fuzzer.RuntimePaths[0], fuzzer.Path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// The name is the base name of the fuzz test file, followed by
+		// the quoted name of the fuzz test if the file contains multiple
+		// fuzz tests
+		_, testName, _ := strings.Cut(fuzzer.Name, ":")
+		testName = strings.Trim(testName, "\"")
+
+		runner = jazzerjs.NewRunner(&jazzerjs.RunnerOptions{
+			PackageManager:   "npm",
+			TestPathPattern:  regexp.QuoteMeta(testPath),
+			TestNamePattern:  regexp.QuoteMeta(testName),
+			LibfuzzerOptions: runnerOpts,
+		})
+	default:
+		err = addDictionaryAndSeeds(runnerOpts, fuzzer)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.opts.CoverageOutputPath != "" && c.opts.CoverageSnapshotInterval > 0 {
+			err = c.setCoverageSnapshotOptions(runnerOpts, metadata)
+			if err != nil {
+				return nil, err
 			}
-			seedCorpusDir := fmt.Sprintf("%s/%s", fuzzer.Seeds, entry.Name())
-			runnerOpts.SeedCorpusDirs = append(runnerOpts.SeedCorpusDirs, seedCorpusDir)
 		}
 
 		runner = libfuzzer.NewRunner(runnerOpts)
@@ -311,15 +432,19 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 
 	err = adapter.ExecuteFuzzerRunner(runner)
 	if err != nil {
-		return err
+		// Return the report handler as well, so that the findings and
+		// metrics of the run are available to the caller
+		return reportHandler, err
 	}
 
-	if c.opts.CoverageOutputPath == "" {
-		// If no coverage output path is specified, we're done.
-		return nil
+	if c.opts.CoverageOutputPath == "" || runnerOpts.CoverageBinary != "" {
+		// If no coverage output path is specified, or the coverage
+		// report was already produced by the runner, we're done.
+		return reportHandler, nil
 	}
 
-	// Create the coverage report
+	// Create the coverage report. If that fails, the report handler is
+	// still returned, so that the findings of the run are not lost.
 	switch fuzzer.Engine {
 	case "JAVA_LIBFUZZER":
 		corpusDirs := append(runnerOpts.SeedCorpusDirs, runnerOpts.GeneratedCorpusDir)
@@ -332,6 +457,11 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 			CorpusDirs:   corpusDirs,
 			Stderr:       os.Stderr,
 		}
+		if sourceDir != "" {
+			for _, root := range metadata.Sources.JavaSourceRoots {
+				gen.SourceFilesDirs = append(gen.SourceFilesDirs, filepath.Join(sourceDir, root))
+			}
+		}
 
 		if viper.GetBool("verbose") {
 			gen.BuildStdout = printerOutput
@@ -341,22 +471,22 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		jacocoExec := "/tmp/jacoco.exec"
 		err = gen.BuildFuzzTestForContainerCoverage(jacocoExec)
 		if err != nil {
-			return err
+			return reportHandler, err
 		}
 
 		_, err = gen.GenerateCoverageReportInFuzzContainer(jacocoExec)
 		if err != nil {
-			return err
+			return reportHandler, err
 		}
 
-		return nil
+		return reportHandler, nil
 	default:
 		// libFuzzer fuzz tests have a separate coverage binary which
 		// is used to produce coverage data. The coverage binary is
 		// specified in the bundle metadata.
 		coverageBinary, err := findCoverageBinary(c.opts.name, metadata)
 		if err != nil {
-			return err
+			return reportHandler, err
 		}
 		seedCorpusDirs := append(runnerOpts.SeedCorpusDirs, runnerOpts.GeneratedCorpusDir, container.ManagedSeedCorpusDir)
 		gen := &llvmCoverage.CoverageGenerator{
@@ -364,9 +494,113 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 			CorpusDirs:   seedCorpusDirs,
 			Stderr:       os.Stderr,
 		}
-		return gen.GenerateCoverageReportInFuzzContainer(context.Background(), coverageBinary.Path,
+		if sourceDir != "" {
+			gen.BuildSourceDir = metadata.Sources.BuildDir
+			gen.LocalSourceDir = sourceDir
+		}
+		err = gen.GenerateCoverageReportInFuzzContainer(context.Background(), coverageBinary.Path,
 			c.opts.CoverageOutputPath, coverageBinary.LibraryPaths)
+		if err != nil {
+			return reportHandler, err
+		}
+		return reportHandler, nil
+	}
+}
+
+// addDictionaryAndSeeds configures the runner to use the dictionary and
+// the seed corpus dirs of the fuzzer, if the bundle includes any.
+func addDictionaryAndSeeds(runnerOpts *libfuzzer.RunnerOptions, fuzzer *archive.Fuzzer) error {
+	// Use dictionary file if the bundle includes one.
+	dictFileName := fuzzer.Dictionary
+	exists, err := fileutil.Exists(dictFileName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		runnerOpts.Dictionary = dictFileName
+	}
+
+	// Use seed corpus dirs if the bundle includes any.
+	entries, err := os.ReadDir(fuzzer.Seeds)
+	// Don't return an error if the directory doesn't exist.
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return errors.Errorf("unexpected file in user seed corpus dir %q: %s", fuzzer.Seeds, entry.Name())
+		}
+		seedCorpusDir := fmt.Sprintf("%s/%s", fuzzer.Seeds, entry.Name())
+		runnerOpts.SeedCorpusDirs = append(runnerOpts.SeedCorpusDirs, seedCorpusDir)
+	}
+	return nil
+}
+
+// setCoverageSnapshotOptions configures the libFuzzer runner to take
+// coverage snapshots while the fuzz test is running.
+func (c *executeCmd) setCoverageSnapshotOptions(runnerOpts *libfuzzer.RunnerOptions, metadata *archive.Metadata) error {
+	coverageBinary, err := findCoverageBinary(c.opts.name, metadata)
+	if err != nil {
+		return err
+	}
+
+	// Like the llvm coverage generator, add all files in the "cas"
+	// directory to the objects included in the coverage report
+	var objects []string
+	exists, err := fileutil.Exists("cas")
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = filepath.WalkDir("cas", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !d.IsDir() {
+				objects = append(objects, path)
+			}
+			return nil
+		})
+		// nolint: wrapcheck
+		if err != nil {
+			return err
+		}
 	}
+
+	outputPath := c.opts.CoverageOutputPath
+	runnerOpts.CoverageBinary = coverageBinary.Path
+	runnerOpts.CoverageLibraryDirs = coverageBinary.LibraryPaths
+	runnerOpts.CoverageObjects = objects
+	runnerOpts.CoverageCorpusDirs = []string{container.ManagedSeedCorpusDir}
+	runnerOpts.CoverageOutputPath = outputPath
+	runnerOpts.CoverageSnapshotInterval = c.opts.CoverageSnapshotInterval
+	runnerOpts.CoverageHistoryPath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".history.json"
+	return nil
+}
+
+// verifyBundle checks the checksum manifest of the bundle in the
+// current working directory and, if a public key was provided, its
+// signature. Only the files which are listed in the manifest are
+// checked, because the container build context adds files to the bundle
+// directory, see container.prepareBuildContext. 'cifuzz bundle verify'
+// rejects unlisted files.
+func verifyBundle(opts *executeOpts) error {
+	integrityOpts := &archive.IntegrityOpts{
+		SignaturePath: opts.SignaturePath,
+		AllowUnlisted: true,
+	}
+	if opts.PublicKey != "" {
+		publicKey, err := archive.ReadPublicKey(opts.PublicKey)
+		if err != nil {
+			return err
+		}
+		integrityOpts.PublicKey = publicKey
+	}
+	err := archive.VerifyIntegrity(".", integrityOpts)
+	if err != nil {
+		return errors.WithMessage(err, "Bundle verification failed")
+	}
+	return nil
 }
 
 // getMetadata returns the bundle metadata from the bundle.yaml file.
@@ -494,6 +728,10 @@ func checkDependencies(fuzzer *archive.Fuzzer) error {
 		deps = []dependencies.Key{
 			dependencies.Java,
 		}
+	case "JAZZER_JS":
+		deps = []dependencies.Key{
+			dependencies.Node,
+		}
 	case "LIBFUZZER":
 		deps = []dependencies.Key{
 			dependencies.LLVMSymbolizer,
@@ -3,11 +3,13 @@
 package execute
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,6 +19,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/util/archiveutil"
 )
 
 func Test_getFuzzer(t *testing.T) {
@@ -260,3 +263,72 @@ func createBundleYamlFile(t *testing.T, outputPath string, fuzzers []*archive.Fu
 	err = os.WriteFile(metadataYamlPath, metadataYamlContent, 0644)
 	require.NoError(t, err)
 }
+
+func Test_fuzzerTimeout(t *testing.T) {
+	// Without a budget, the max run time is used
+	assert.Equal(t, 30*time.Second, fuzzerTimeout(30, 0, 3))
+	// The budget is split across the remaining fuzzers
+	assert.Equal(t, 20*time.Second, fuzzerTimeout(0, time.Minute, 3))
+	// The max run time is respected if it's shorter than the share
+	assert.Equal(t, 10*time.Second, fuzzerTimeout(10, time.Minute, 3))
+	assert.Equal(t, 20*time.Second, fuzzerTimeout(60, time.Minute, 3))
+	// An exhausted budget results in a negative timeout
+	assert.Negative(t, int64(fuzzerTimeout(10, -time.Second, 1)))
+}
+
+func Test_fuzzerDirName(t *testing.T) {
+	assert.Equal(t, "my_fuzz_test-address", fuzzerDirName(&archive.Fuzzer{Target: "my_fuzz_test", Sanitizer: "ADDRESS"}))
+	assert.Equal(t, "my_fuzz_test-undefined", fuzzerDirName(&archive.Fuzzer{Target: "my_fuzz_test", Sanitizer: "UNDEFINED"}))
+	assert.Equal(t, "com.example.FuzzTest_myFuzzTest", fuzzerDirName(&archive.Fuzzer{Name: "com.example.FuzzTest::myFuzzTest"}))
+	assert.Equal(t, "parser.fuzz.js_parses_input_", fuzzerDirName(&archive.Fuzzer{Name: `parser.fuzz.js:"parses input"`}))
+}
+
+func Test_runnableFuzzers(t *testing.T) {
+	metadata := &archive.Metadata{
+		Fuzzers: []*archive.Fuzzer{
+			{Target: "my_fuzz_test", Engine: "LIBFUZZER", Sanitizer: "ADDRESS"},
+			{Target: "my_fuzz_test", Engine: "LIBFUZZER", Sanitizer: "UNDEFINED"},
+			{Target: "my_fuzz_test", Engine: "LLVM_COV", Sanitizer: "COVERAGE"},
+		},
+	}
+	fuzzers := runnableFuzzers(metadata)
+	require.Len(t, fuzzers, 2)
+	assert.Equal(t, "ADDRESS", fuzzers[0].Sanitizer)
+	assert.Equal(t, "UNDEFINED", fuzzers[1].Sanitizer)
+}
+
+func TestVerifyBundle_ContainerBuildContext(t *testing.T) {
+	srcDir := testutil.MkdirTemp(t, "", "verify-bundle-src-")
+	writeFile := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeFile(filepath.Join(srcDir, archive.MetadataFileName), "fuzzers: []\n")
+	writeFile(filepath.Join(srcDir, "libfuzzer", "address", "my_fuzz_test", "bin", "my_fuzz_test"), "#!/bin/sh")
+
+	buf := &bytes.Buffer{}
+	w := archive.NewTarArchiveWriter(buf, false)
+	require.NoError(t, w.WriteFile(archive.MetadataFileName, filepath.Join(srcDir, archive.MetadataFileName)))
+	require.NoError(t, w.WriteDir("libfuzzer", filepath.Join(srcDir, "libfuzzer")))
+	_, err := w.WriteManifest(nil, false)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	bundleDir := testutil.ChdirToTempDir(t, "verify-bundle-")
+	require.NoError(t, archiveutil.Untar(buf, bundleDir))
+
+	// The container build context adds these files to the directory of
+	// the extracted bundle, see container.prepareBuildContext
+	writeFile(filepath.Join(bundleDir, "Dockerfile"), "FROM ubuntu")
+	writeFile(filepath.Join(bundleDir, "cifuzz_linux"), "cifuzz")
+	writeFile(filepath.Join(bundleDir, "internal", "cifuzz_binaries", "bin", "minijail0"), "minijail")
+
+	err = verifyBundle(&executeOpts{})
+	require.NoError(t, err)
+
+	// Files which are listed in the manifest are still checked
+	writeFile(filepath.Join(bundleDir, "libfuzzer", "address", "my_fuzz_test", "bin", "my_fuzz_test"), "modified")
+	err = verifyBundle(&executeOpts{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "libfuzzer/address/my_fuzz_test/bin/my_fuzz_test has been modified")
+}
@@ -0,0 +1,269 @@
+package bisect
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+)
+
+type options struct {
+	findingutil.Options `mapstructure:",squash"`
+
+	Good string
+	Bad  string
+}
+
+func (opts *options) validate() error {
+	err := opts.Options.Validate("Bisecting findings")
+	if err != nil {
+		return err
+	}
+
+	if opts.Good == "" {
+		msg := "Flag \"good\" must be set to a commit in which the finding doesn't occur"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+type bisectCmd struct {
+	*cobra.Command
+	opts        *options
+	buildRunner findingutil.BuildRunnerFunc
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "bisect <name> --good <ref> [--bad <ref>]",
+		Short: "Find the commit which introduced a finding",
+		Long: `This command uses git bisect to find the first commit in which the
+crashing input of a local finding triggers the crash.
+
+The commits are checked out in a temporary Git worktree, so the working
+directory is not modified. For each commit, the fuzz test is built and
+run with the crashing input. A commit is classified as
+  * bad, if the fuzz test crashes with the same error and the same top
+    stack frames as on the bad commit,
+  * good, if the fuzz test doesn't crash,
+  * skipped, if the fuzz test can't be built or crashes differently.
+
+The first bad commit is stored in the finding and shown by
+'cifuzz finding <name>'.
+
+<name> is the name of a finding.
+Run 'cifuzz findings' to get a list of all available findings.
+
+Only CMake, Maven, Gradle, Node.js and other build systems are supported.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.FindingName = args[0]
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			err := opts.CheckDependencies()
+			if err != nil {
+				return err
+			}
+			cmd := bisectCmd{Command: c, opts: opts, buildRunner: findingutil.BuildRunner}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+	)
+	cmd.Flags().StringVar(&opts.Good, "good", "",
+		"A commit in which the finding doesn't occur.")
+	cmd.Flags().StringVar(&opts.Bad, "bad", "HEAD",
+		"A commit in which the finding occurs.")
+
+	return cmd
+}
+
+func (c *bisectCmd) run() error {
+	f, inputPath, err := findingutil.LoadFinding(c.opts.ProjectDir, c.opts.FindingName)
+	if err != nil {
+		return err
+	}
+	// The crashing input is passed to the fuzz test in the worktree
+	inputPath, err = filepath.Abs(inputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	good, err := vcs.GitRevParse(c.opts.ProjectDir, c.opts.Good)
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+	bad, err := vcs.GitRevParse(c.opts.ProjectDir, c.opts.Bad)
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+	repoDir, err := vcs.GitTopLevel(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	worktree, err := vcs.AddWorktree(repoDir, bad)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := worktree.Remove()
+		if err != nil {
+			log.Warnf("Failed to remove the Git worktree %s: %v", worktree.Dir, err)
+		}
+	}()
+	projectDir, err := worktreeProjectDir(repoDir, c.opts.ProjectDir, worktree.Dir)
+	if err != nil {
+		return err
+	}
+
+	// Determine the signature of the crash on the bad commit, because
+	// the stored signature might stem from another version of the code
+	log.Infof("Verifying that finding %s occurs on the bad commit", f.Name)
+	reference, err := c.reproduce(projectDir, f.FuzzTest, inputPath)
+	if err != nil {
+		return err
+	}
+	if reference == nil {
+		return errors.Errorf("Finding %s doesn't reproduce on the bad commit %s", f.Name, c.opts.Bad)
+	}
+	referenceSignature := signature.ForFinding(reference, c.opts.SignatureOpts)
+	if f.Signature != "" && f.Signature != referenceSignature {
+		log.Warnf("The crash on the bad commit differs from finding %s, searching for the commit which introduced it:\n  %s",
+			f.Name, referenceSignature)
+	}
+
+	log.Infof("Verifying that finding %s doesn't occur on the good commit", f.Name)
+	err = worktree.Checkout(good)
+	if err != nil {
+		return err
+	}
+	goodFinding, err := c.reproduce(projectDir, f.FuzzTest, inputPath)
+	if err != nil {
+		return err
+	}
+	if goodFinding != nil {
+		return errors.Errorf("The fuzz test also crashes on the good commit %s", c.opts.Good)
+	}
+
+	firstBad, err := worktree.Bisect(good, bad, func(commit string) (vcs.BisectVerdict, error) {
+		summary, err := vcs.GitCommitSummary(worktree.Dir, commit)
+		if err != nil {
+			return "", err
+		}
+		log.Infof("Testing commit %s", summary)
+		crash, err := c.reproduce(projectDir, f.FuzzTest, inputPath)
+		if err != nil {
+			log.Warnf("Skipping commit %s: %v", summary, err)
+			return vcs.BisectSkip, nil
+		}
+		verdict := classify(crash, referenceSignature, c.opts.SignatureOpts)
+		if verdict == vcs.BisectSkip {
+			log.Warnf("Skipping commit %s, the fuzz test crashes differently:\n  %s",
+				summary, signature.ForFinding(crash, c.opts.SignatureOpts))
+		}
+		return verdict, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = f.SaveFirstBadCommit(c.opts.ProjectDir, firstBad)
+	if err != nil {
+		return err
+	}
+	summary, err := vcs.GitCommitSummary(repoDir, firstBad)
+	if err != nil {
+		return err
+	}
+	log.Successf("The first bad commit of finding %s is %s", f.Name, summary)
+	return nil
+}
+
+// reproduce builds the fuzz test in the project directory and runs it
+// with the crashing input. It returns the resulting finding, or nil if
+// the fuzz test doesn't crash.
+func (c *bisectCmd) reproduce(projectDir, fuzzTest, inputPath string) (*finding.Finding, error) {
+	runner, err := c.buildRunner(c.opts.BuildOptions(projectDir), fuzzTest)
+	if err != nil {
+		return nil, err
+	}
+	f, output, err := runner.Run(context.Background(), inputPath)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug(output)
+	return f, nil
+}
+
+// classify returns the verdict for a commit on which the fuzz test
+// produced the finding f, which is nil if it didn't crash. A different
+// crash than the one which is searched for is skipped, because it might
+// hide it.
+func classify(f *finding.Finding, referenceSignature string, opts *signature.Options) vcs.BisectVerdict {
+	if f == nil {
+		return vcs.BisectGood
+	}
+	if signature.ForFinding(f, opts) == referenceSignature {
+		return vcs.BisectBad
+	}
+	return vcs.BisectSkip
+}
+
+// worktreeProjectDir returns the directory in the worktree which
+// corresponds to the project directory in the repository.
+func worktreeProjectDir(repoDir, projectDir, worktreeDir string) (string, error) {
+	// Git returns the top-level directory with symlinks resolved
+	projectDir, err := filepath.EvalSymlinks(projectDir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	repoDir, err = filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	relPath, err := filepath.Rel(repoDir, projectDir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return filepath.Join(worktreeDir, relPath), nil
+}
@@ -0,0 +1,172 @@
+package bisect
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/builder"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+)
+
+func TestMain(m *testing.M) {
+	// Set finder install dir to project root. This way the
+	// finder finds the required error-details.json in the
+	// project dir instead of the cifuzz install dir.
+	sourceDir, err := builder.FindProjectDir()
+	if err != nil {
+		log.Fatalf("Failed to find cifuzz project dir")
+	}
+
+	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
+
+	m.Run()
+}
+
+func TestClassify(t *testing.T) {
+	crash := &finding.Finding{
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "src/parser.cpp", Line: 12},
+		},
+	}
+	opts, err := signature.NewOptions(0, nil)
+	require.NoError(t, err)
+	reference := signature.ForFinding(crash, opts)
+
+	assert.Equal(t, vcs.BisectGood, classify(nil, reference, opts))
+	assert.Equal(t, vcs.BisectBad, classify(crash, reference, opts))
+
+	other := &finding.Finding{
+		MoreDetails: &finding.ErrorDetails{ID: "use_after_free"},
+		StackTrace:  crash.StackTrace,
+	}
+	assert.Equal(t, vcs.BisectSkip, classify(other, reference, opts))
+}
+
+func TestWorktreeProjectDir(t *testing.T) {
+	repoDir := t.TempDir()
+	projectDir := filepath.Join(repoDir, "sub", "project")
+	err := os.MkdirAll(projectDir, 0o755)
+	require.NoError(t, err)
+	worktreeDir := t.TempDir()
+
+	dir, err := worktreeProjectDir(repoDir, projectDir, worktreeDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(worktreeDir, "sub", "project"), dir)
+
+	dir, err = worktreeProjectDir(repoDir, repoDir, worktreeDir)
+	require.NoError(t, err)
+	assert.Equal(t, worktreeDir, dir)
+}
+
+// stubRunner crashes if the version of the checked out commit is at
+// least firstBadVersion.
+type stubRunner struct {
+	version         int
+	firstBadVersion int
+	crash           *finding.Finding
+}
+
+func (r *stubRunner) Run(context.Context, ...string) (*finding.Finding, string, error) {
+	if r.version >= r.firstBadVersion {
+		return r.crash, "", nil
+	}
+	return nil, "", nil
+}
+
+func TestBisect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Bisecting findings is not supported on Windows")
+	}
+
+	// Each commit writes its index to the file "version", the bug was
+	// introduced in version 3
+	projectDir := testutil.MkdirTemp(t, "", "test-bisect-")
+	runGit(t, projectDir, "init")
+	runGit(t, projectDir, "config", "user.email", "you@example.com")
+	runGit(t, projectDir, "config", "user.name", "Your Name")
+	var commits []string
+	for i := 0; i < 6; i++ {
+		err := os.WriteFile(filepath.Join(projectDir, "version"), []byte(strconv.Itoa(i)), 0o644)
+		require.NoError(t, err)
+		runGit(t, projectDir, "add", "version")
+		runGit(t, projectDir, "commit", "-m", fmt.Sprintf("Version %d", i))
+		commit, err := vcs.GitRevParse(projectDir, "HEAD")
+		require.NoError(t, err)
+		commits = append(commits, commit)
+	}
+
+	f := &finding.Finding{
+		Name:        "test_finding",
+		FuzzTest:    "my_fuzz_test",
+		InputData:   []byte("crashing input"),
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "src/parser.cpp", Line: 12},
+		},
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		Options: findingutil.Options{
+			ProjectDir:   projectDir,
+			BuildSystem:  config.BuildSystemOther,
+			BuildCommand: "make",
+			FindingName:  f.Name,
+		},
+		Good: commits[0],
+		Bad:  "HEAD",
+	}
+	err = opts.validate()
+	require.NoError(t, err)
+
+	cmd := &bisectCmd{
+		opts: opts,
+		buildRunner: func(buildOpts *findingutil.BuildOptions, _ string) (findingutil.FuzzTestRunner, error) {
+			// The fuzz test is built in the worktree
+			assert.NotEqual(t, projectDir, buildOpts.ProjectDir)
+			content, err := os.ReadFile(filepath.Join(buildOpts.ProjectDir, "version"))
+			if err != nil {
+				return nil, err
+			}
+			version, err := strconv.Atoi(string(content))
+			if err != nil {
+				return nil, err
+			}
+			return &stubRunner{version: version, firstBadVersion: 3, crash: f}, nil
+		},
+	}
+	err = cmd.run()
+	require.NoError(t, err)
+
+	saved, err := finding.LoadFinding(projectDir, f.Name)
+	require.NoError(t, err)
+	assert.Equal(t, commits[3], saved.FirstBadCommit)
+}
+
+func runGit(t *testing.T, repo string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	require.NoError(t, err)
+}
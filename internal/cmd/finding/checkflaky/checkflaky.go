@@ -0,0 +1,219 @@
+package checkflaky
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type options struct {
+	findingutil.Options `mapstructure:",squash"`
+
+	Runs     int
+	Parallel int
+}
+
+func (opts *options) validate() error {
+	err := opts.Options.Validate("Checking findings for flakiness")
+	if err != nil {
+		return err
+	}
+
+	if opts.Runs <= 0 {
+		msg := "Flag \"runs\" must be a positive number"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.Parallel <= 0 {
+		msg := "Flag \"parallel\" must be a positive number"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+type checkFlakyCmd struct {
+	*cobra.Command
+	opts        *options
+	buildRunner findingutil.BuildRunnerFunc
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "check-flaky <name>",
+		Short: "Check how reliably a finding reproduces",
+		Long: `This command builds the fuzz test of a local finding and runs it
+multiple times with the crashing input. A run reproduces the finding if
+the fuzz test crashes with the same error and the same top stack frames
+as the finding.
+
+The ratio of reproducing runs is stored in the finding and shown by
+'cifuzz finding', so that deterministic findings can be triaged first.
+Findings like timeouts, out-of-memory errors and data races often don't
+reproduce reliably.
+
+<name> is the name of a finding.
+Run 'cifuzz findings' to get a list of all available findings.
+
+Only CMake, Maven, Gradle, Node.js and other build systems are supported.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.FindingName = args[0]
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			err := opts.CheckDependencies()
+			if err != nil {
+				return err
+			}
+			cmd := checkFlakyCmd{Command: c, opts: opts, buildRunner: findingutil.BuildRunner}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+	)
+	cmd.Flags().IntVar(&opts.Runs, "runs", 10,
+		"Number of times to run the fuzz test with the crashing input.")
+	cmd.Flags().IntVar(&opts.Parallel, "parallel", 1,
+		"Number of runs to execute in parallel.")
+
+	return cmd
+}
+
+func (c *checkFlakyCmd) run() error {
+	f, inputPath, err := findingutil.LoadFinding(c.opts.ProjectDir, c.opts.FindingName)
+	if err != nil {
+		return err
+	}
+
+	runner, err := c.buildRunner(c.opts.BuildOptions(c.opts.ProjectDir), f.FuzzTest)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Running %s %d times with the crashing input", f.FuzzTest, c.opts.Runs)
+	results, err := replay(runner, inputPath, c.opts.Runs, c.opts.Parallel)
+	if err != nil {
+		return err
+	}
+
+	reproduced, others := count(f, results, c.opts.SignatureOpts)
+	for _, s := range sortedKeys(others) {
+		log.Warnf("%d runs crashed differently than finding %s:\n  %s", others[s], f.Name, s)
+	}
+
+	r := finding.NewReproducibility(c.opts.Runs, reproduced)
+	err = f.SaveReproducibility(c.opts.ProjectDir, r)
+	if err != nil {
+		return err
+	}
+
+	if r.IsFlaky() {
+		log.Notef("Finding %s is flaky, it was reproduced in %s", f.Name, r)
+	} else {
+		log.Successf("Finding %s was reproduced in all %d runs", f.Name, r.Runs)
+	}
+	return nil
+}
+
+// replay runs the fuzz test with the crashing input the given number of
+// times, at most parallel runs at the same time. It returns the finding
+// of each run, which is nil if the run didn't crash.
+func replay(runner findingutil.FuzzTestRunner, inputPath string, runs, parallel int) ([]*finding.Finding, error) {
+	results := make([]*finding.Finding, runs)
+	var mutex sync.Mutex
+	done := 0
+
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(parallel)
+	for i := 0; i < runs; i++ {
+		i := i
+		g.Go(func() error {
+			f, output, err := runner.Run(ctx, inputPath)
+			if err != nil {
+				return err
+			}
+			results[i] = f
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			done++
+			log.Debugf("Run %d of %d finished (crashed: %t)", done, runs, f != nil)
+			log.Debug(output)
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// count returns the number of results which have the same signature as
+// the finding and the number of results for each other signature.
+// Results without a crash are not counted.
+func count(f *finding.Finding, results []*finding.Finding, opts *signature.Options) (int, map[string]int) {
+	reference := signature.ForFinding(f, opts)
+	reproduced := 0
+	others := map[string]int{}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		s := signature.ForFinding(result, opts)
+		if s == reference {
+			reproduced++
+		} else {
+			others[s]++
+		}
+	}
+	return reproduced, others
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package checkflaky
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/builder"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+)
+
+func TestMain(m *testing.M) {
+	// Set finder install dir to project root. This way the
+	// finder finds the required error-details.json in the
+	// project dir instead of the cifuzz install dir.
+	sourceDir, err := builder.FindProjectDir()
+	if err != nil {
+		log.Fatalf("Failed to find cifuzz project dir")
+	}
+
+	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
+
+	m.Run()
+}
+
+func TestCount(t *testing.T) {
+	stackTrace := []*stacktrace.StackFrame{
+		{Function: "parse", SourceFile: "src/parser.cpp", Line: 12},
+	}
+	f := &finding.Finding{
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace:  stackTrace,
+	}
+	other := &finding.Finding{
+		MoreDetails: &finding.ErrorDetails{ID: "use_after_free"},
+		StackTrace:  stackTrace,
+	}
+	opts, err := signature.NewOptions(0, nil)
+	require.NoError(t, err)
+
+	reproduced, others := count(f, []*finding.Finding{f, nil, other, f, other}, opts)
+	assert.Equal(t, 2, reproduced)
+	assert.Equal(t, map[string]int{signature.ForFinding(other, opts): 2}, others)
+}
+
+// stubRunner crashes with the finding in every second run.
+type stubRunner struct {
+	crash *finding.Finding
+	runs  atomic.Int32
+}
+
+func (r *stubRunner) Run(context.Context, ...string) (*finding.Finding, string, error) {
+	if r.runs.Add(1)%2 == 0 {
+		return nil, "", nil
+	}
+	return r.crash, "", nil
+}
+
+func TestCheckFlaky(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Checking findings for flakiness is not supported on Windows")
+	}
+
+	projectDir := t.TempDir()
+	f := &finding.Finding{
+		Name:        "test_finding",
+		FuzzTest:    "my_fuzz_test",
+		InputData:   []byte("crashing input"),
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "src/parser.cpp", Line: 12},
+		},
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		Options: findingutil.Options{
+			ProjectDir:   projectDir,
+			BuildSystem:  config.BuildSystemOther,
+			BuildCommand: "make",
+			FindingName:  f.Name,
+		},
+		Runs:     4,
+		Parallel: 2,
+	}
+	err = opts.validate()
+	require.NoError(t, err)
+
+	runner := &stubRunner{crash: f}
+	cmd := &checkFlakyCmd{
+		opts: opts,
+		buildRunner: func(*findingutil.BuildOptions, string) (findingutil.FuzzTestRunner, error) {
+			return runner, nil
+		},
+	}
+	err = cmd.run()
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, runner.runs.Load())
+
+	saved, err := finding.LoadFinding(projectDir, f.Name)
+	require.NoError(t, err)
+	require.NotNil(t, saved.Reproducibility)
+	assert.Equal(t, 4, saved.Reproducibility.Runs)
+	assert.Equal(t, 2, saved.Reproducibility.Reproduced)
+	assert.Equal(t, 0.5, saved.Reproducibility.Rate)
+}
@@ -0,0 +1,321 @@
+package exportreproducer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/cmd/reproduce"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/ldd"
+	findingPkg "code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	optionsPkg "code-intelligence.com/cifuzz/pkg/options"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+const (
+	// The name of the crashing input inside the reproducer package
+	inputFileName = "crashing-input"
+	// The name of the script which reproduces the finding
+	scriptFileName = "reproduce.sh"
+)
+
+type options struct {
+	findingutil.Options `mapstructure:",squash"`
+
+	OutputPath string
+}
+
+type exportReproducerCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+// reproducer contains everything which is needed to reproduce a finding
+// without cifuzz.
+type reproducer struct {
+	finding *findingPkg.Finding
+	// The path of the crashing input
+	inputPath string
+	// The fuzz test executable and the non-system shared libraries it
+	// depends on (C/C++ only)
+	executable string
+	libraries  []string
+	// The class path and the fuzz test (Java only)
+	classPath    []string
+	targetClass  string
+	targetMethod string
+	// The sanitizer options
+	env []string
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "export-reproducer <name>",
+		Short: "Export a self-contained package which reproduces a finding",
+		Long: `This command builds the fuzz test of a local finding and writes a
+tarball which contains everything needed to reproduce the finding without
+cifuzz:
+
+  * the fuzz test executable and the non-system shared libraries it
+    depends on (C/C++) or the jars and class directories of the class
+    path (Java)
+  * the crashing input
+  * a reproduce.sh script which runs the fuzz test with the crashing
+    input and the same sanitizer options as 'cifuzz reproduce'
+
+Anyone on a similar Linux machine can extract the tarball and run
+reproduce.sh to trigger the crash. Java reproducers require a Java
+runtime.
+
+<name> is the name of a finding.
+Run 'cifuzz findings' to get a list of all available findings.
+
+Only CMake, Maven, Gradle and other build systems are supported.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.FindingName = args[0]
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+
+			err = opts.Validate("Exporting reproducers")
+			if err != nil {
+				return err
+			}
+			// Node.js fuzz tests are not built into an executable or a
+			// class path which could be exported
+			if opts.BuildSystem == config.BuildSystemNodeJS {
+				msg := fmt.Sprintf("Exporting reproducers is not supported for build system %q.", opts.BuildSystem)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			err := opts.CheckDependencies()
+			if err != nil {
+				return err
+			}
+			cmd := exportReproducerCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+	)
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output path of the reproducer tarball (default: <name>.tar.gz)")
+
+	return cmd
+}
+
+func (c *exportReproducerCmd) run() error {
+	f, inputPath, err := findingutil.LoadFinding(c.opts.ProjectDir, c.opts.FindingName)
+	if err != nil {
+		return err
+	}
+
+	r := &reproducer{
+		finding:   f,
+		inputPath: inputPath,
+	}
+
+	buildResult, err := findingutil.Build(c.opts.BuildOptions(c.opts.ProjectDir), f.FuzzTest)
+	if err != nil {
+		return err
+	}
+	if findingutil.IsJavaBuildSystem(c.opts.BuildSystem) {
+		r.classPath = buildResult.RuntimeDeps
+		r.targetClass, r.targetMethod, _ = strings.Cut(f.FuzzTest, "::")
+	} else {
+		r.executable = buildResult.Executable
+		r.libraries, err = ldd.NonSystemSharedLibraries(r.executable)
+		if err != nil {
+			return err
+		}
+		r.env, err = reproduce.SetSanitizerOptions(nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	outputPath := c.opts.OutputPath
+	if outputPath == "" {
+		outputPath = f.Name + ".tar.gz"
+	}
+	err = writeReproducer(outputPath, r)
+	if err != nil {
+		return err
+	}
+
+	log.Successf("Exported reproducer for finding %s to %s", f.Name, outputPath)
+	log.Printf("Extract it and run %s/%s to reproduce the finding.", f.Name, scriptFileName)
+	return nil
+}
+
+// writeReproducer writes a gzip-compressed tarball to outputPath which
+// contains the files of the reproducer below a directory named after
+// the finding.
+func writeReproducer(outputPath string, r *reproducer) (err error) {
+	tempDir, err := os.MkdirTemp("", "cifuzz-reproducer-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(outputPath)
+		}
+	}()
+	archiveWriter := archive.NewTarArchiveWriter(out, true)
+
+	prefix := r.finding.Name
+	err = archiveWriter.WriteFile(filepath.Join(prefix, inputFileName), r.inputPath)
+	if err != nil {
+		return err
+	}
+
+	var command []string
+	var libDir string
+	if r.executable != "" {
+		executablePath := filepath.Join("bin", filepath.Base(r.executable))
+		err = archiveWriter.WriteFile(filepath.Join(prefix, executablePath), r.executable)
+		if err != nil {
+			return err
+		}
+		if len(r.libraries) > 0 {
+			libDir = "lib"
+			// The dynamic linker looks up the libraries by their file
+			// name, so libraries with the same name can't both be put
+			// into the library directory
+			libraries := map[string]string{}
+			for _, lib := range r.libraries {
+				name := filepath.Base(lib)
+				if other, exists := libraries[name]; exists {
+					return errors.Errorf("The fuzz test depends on multiple shared libraries named %s: %s and %s", name, other, lib)
+				}
+				libraries[name] = lib
+				err = archiveWriter.WriteFile(filepath.Join(prefix, libDir, name), lib)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		command = []string{"./" + executablePath, inputFileName}
+	} else {
+		var classPath []string
+		for i, path := range r.classPath {
+			// Prefix the entries with their index to keep the order of
+			// the class path and to avoid clashes between entries with
+			// the same name, e.g. multiple "classes" directories
+			archivePath := filepath.Join("classpath", fmt.Sprintf("%d-%s", i, filepath.Base(path)))
+			if fileutil.IsDir(path) {
+				err = archiveWriter.WriteDir(filepath.Join(prefix, archivePath), path)
+			} else {
+				err = archiveWriter.WriteFile(filepath.Join(prefix, archivePath), path)
+			}
+			if err != nil {
+				return err
+			}
+			classPath = append(classPath, archivePath)
+		}
+		command = []string{"java", "-cp", strings.Join(classPath, ":"), optionsPkg.JazzerMainClass,
+			optionsPkg.JazzerTargetClassFlag(r.targetClass)}
+		if r.targetMethod != "" {
+			command = append(command, optionsPkg.JazzerTargetMethodFlag(r.targetMethod))
+		}
+		command = append(command, inputFileName)
+	}
+
+	scriptPath := filepath.Join(tempDir, scriptFileName)
+	err = os.WriteFile(scriptPath, []byte(reproduceScript(r, command, libDir)), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = archiveWriter.WriteFile(filepath.Join(prefix, scriptFileName), scriptPath)
+	if err != nil {
+		return err
+	}
+
+	err = archiveWriter.Close()
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(out.Close())
+}
+
+// reproduceScript returns the content of the shell script which runs
+// the command from the directory of the script.
+func reproduceScript(r *reproducer, command []string, libDir string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Reproduces the finding %s of the fuzz test %s.\n", r.finding.Name, r.finding.FuzzTest)
+	b.WriteString("# Additional arguments are passed to the fuzz test.\n")
+	b.WriteString("set -e\n")
+	b.WriteString("cd \"$(dirname \"$0\")\"\n")
+
+	var env []string
+	env = append(env, r.env...)
+	sort.Strings(env)
+	for _, e := range env {
+		key, value, _ := strings.Cut(e, "=")
+		fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(value))
+	}
+	if libDir != "" {
+		fmt.Fprintf(&b, "export LD_LIBRARY_PATH=\"$PWD/%s${LD_LIBRARY_PATH:+:$LD_LIBRARY_PATH}\"\n", libDir)
+	}
+
+	var quotedCommand []string
+	for _, arg := range command {
+		quotedCommand = append(quotedCommand, shellQuote(arg))
+	}
+	fmt.Fprintf(&b, "exec %s \"$@\"\n", strings.Join(quotedCommand, " "))
+	return b.String()
+}
+
+// shellQuote quotes the string for use in a POSIX shell script.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@", r))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
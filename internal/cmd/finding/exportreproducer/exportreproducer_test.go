@@ -0,0 +1,159 @@
+package exportreproducer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestWriteReproducer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Exporting reproducers is not supported on Windows")
+	}
+
+	dir := testutil.MkdirTemp(t, "", "export-reproducer-")
+
+	// The fake fuzz test prints its arguments and the environment which
+	// the script is expected to set up
+	executable := filepath.Join(dir, "my_fuzz_test")
+	err := os.WriteFile(executable, []byte(`#!/bin/sh
+echo "args: $*"
+echo "input: $(cat "$1")"
+echo "ASAN_OPTIONS=$ASAN_OPTIONS"
+echo "LD_LIBRARY_PATH=$LD_LIBRARY_PATH"
+test -f "$(echo "$LD_LIBRARY_PATH" | cut -d: -f1)/libfoo.so" && echo "found libfoo.so"
+`), 0o755)
+	require.NoError(t, err)
+	lib := filepath.Join(dir, "libfoo.so")
+	err = os.WriteFile(lib, []byte("lib"), 0o644)
+	require.NoError(t, err)
+	input := filepath.Join(dir, "input")
+	err = os.WriteFile(input, []byte("crash"), 0o644)
+	require.NoError(t, err)
+
+	r := &reproducer{
+		finding:    &finding.Finding{Name: "funky_angelfish", FuzzTest: "my_fuzz_test"},
+		inputPath:  input,
+		executable: executable,
+		libraries:  []string{lib},
+		env:        []string{"ASAN_OPTIONS=detect_leaks=1:symbolize=1 'quoted'"},
+	}
+	outputPath := filepath.Join(dir, "reproducer.tar.gz")
+	err = writeReproducer(outputPath, r)
+	require.NoError(t, err)
+
+	extractDir := testutil.MkdirTemp(t, "", "export-reproducer-extract-")
+	err = archive.Extract(outputPath, extractDir)
+	require.NoError(t, err)
+
+	reproducerDir := filepath.Join(extractDir, "funky_angelfish")
+	assert.FileExists(t, filepath.Join(reproducerDir, "bin", "my_fuzz_test"))
+	assert.FileExists(t, filepath.Join(reproducerDir, "lib", "libfoo.so"))
+	assert.FileExists(t, filepath.Join(reproducerDir, inputFileName))
+
+	// Run the script from a different directory to check that it
+	// doesn't depend on the working directory
+	cmd := exec.Command(filepath.Join(reproducerDir, scriptFileName), "-runs=1")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "args: crashing-input -runs=1")
+	assert.Contains(t, string(out), "input: crash")
+	assert.Contains(t, string(out), "ASAN_OPTIONS=detect_leaks=1:symbolize=1 'quoted'")
+	assert.Contains(t, string(out), "found libfoo.so")
+}
+
+func TestWriteReproducer_LibraryNameCollision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Exporting reproducers is not supported on Windows")
+	}
+
+	dir := testutil.MkdirTemp(t, "", "export-reproducer-")
+	executable := filepath.Join(dir, "my_fuzz_test")
+	err := os.WriteFile(executable, []byte("#!/bin/sh\n"), 0o755)
+	require.NoError(t, err)
+	var libs []string
+	for _, libDir := range []string{"a", "b"} {
+		lib := filepath.Join(dir, libDir, "libfoo.so")
+		err = os.MkdirAll(filepath.Dir(lib), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(lib, []byte(libDir), 0o644)
+		require.NoError(t, err)
+		libs = append(libs, lib)
+	}
+	input := filepath.Join(dir, "input")
+	err = os.WriteFile(input, []byte("crash"), 0o644)
+	require.NoError(t, err)
+
+	r := &reproducer{
+		finding:    &finding.Finding{Name: "funky_angelfish", FuzzTest: "my_fuzz_test"},
+		inputPath:  input,
+		executable: executable,
+		libraries:  libs,
+	}
+	outputPath := filepath.Join(dir, "reproducer.tar.gz")
+	err = writeReproducer(outputPath, r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "multiple shared libraries named libfoo.so")
+	assert.NoFileExists(t, outputPath)
+}
+
+func TestWriteReproducer_Java(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Exporting reproducers is not supported on Windows")
+	}
+
+	dir := testutil.MkdirTemp(t, "", "export-reproducer-")
+	jar := filepath.Join(dir, "jazzer.jar")
+	err := os.WriteFile(jar, []byte("jar"), 0o644)
+	require.NoError(t, err)
+	classesDir := filepath.Join(dir, "classes")
+	err = os.MkdirAll(filepath.Join(classesDir, "com", "example"), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(classesDir, "com", "example", "FuzzTest.class"), []byte("class"), 0o644)
+	require.NoError(t, err)
+	input := filepath.Join(dir, "input")
+	err = os.WriteFile(input, []byte("crash"), 0o644)
+	require.NoError(t, err)
+
+	r := &reproducer{
+		finding:      &finding.Finding{Name: "funky_angelfish", FuzzTest: "com.example.FuzzTest::fuzz"},
+		inputPath:    input,
+		classPath:    []string{classesDir, jar},
+		targetClass:  "com.example.FuzzTest",
+		targetMethod: "fuzz",
+	}
+	outputPath := filepath.Join(dir, "reproducer.tar.gz")
+	err = writeReproducer(outputPath, r)
+	require.NoError(t, err)
+
+	extractDir := testutil.MkdirTemp(t, "", "export-reproducer-extract-")
+	err = archive.Extract(outputPath, extractDir)
+	require.NoError(t, err)
+
+	reproducerDir := filepath.Join(extractDir, "funky_angelfish")
+	assert.FileExists(t, filepath.Join(reproducerDir, "classpath", "0-classes", "com", "example", "FuzzTest.class"))
+	assert.FileExists(t, filepath.Join(reproducerDir, "classpath", "1-jazzer.jar"))
+
+	script, err := os.ReadFile(filepath.Join(reproducerDir, scriptFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(script),
+		"exec java -cp classpath/0-classes:classpath/1-jazzer.jar com.code_intelligence.jazzer.Jazzer "+
+			"--target_class=com.example.FuzzTest --target_method=fuzz crashing-input \"$@\"")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "foo/bar.txt", shellQuote("foo/bar.txt"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "'foo bar'", shellQuote("foo bar"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
@@ -13,18 +14,26 @@ import (
 	"golang.org/x/term"
 
 	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/bisect"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/checkflaky"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/exportreproducer"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/minimize"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/totest"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/version"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/sarif"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
 type options struct {
 	PrintJSON   bool   `mapstructure:"print-json"`
+	PrintSARIF  bool   `mapstructure:"print-sarif"`
 	ProjectDir  string `mapstructure:"project-dir"`
 	ConfigDir   string `mapstructure:"config-dir"`
 	Interactive bool   `mapstructure:"interactive"`
@@ -85,11 +94,19 @@ func newWithOptions(opts *options) *cobra.Command {
 	//       it to viper in the PreRun function.
 	bindFlags = cmdutils.AddFlags(cmd,
 		cmdutils.AddPrintJSONFlag,
+		cmdutils.AddPrintSARIFFlag,
 		cmdutils.AddProjectDirFlag,
 		cmdutils.AddInteractiveFlag,
 		cmdutils.AddServerFlag,
 		cmdutils.AddProjectFlag,
 	)
+	cmd.MarkFlagsMutuallyExclusive("json", "sarif")
+
+	cmd.AddCommand(bisect.New())
+	cmd.AddCommand(checkflaky.New())
+	cmd.AddCommand(exportreproducer.New())
+	cmd.AddCommand(minimize.New())
+	cmd.AddCommand(totest.New())
 
 	return cmd
 }
@@ -175,6 +192,10 @@ Skipping remote findings because running in non-interactive mode.`)
 		// descriptions of all findings
 		allFindings := append(localFindings, remoteFindings...)
 
+		if cmd.opts.PrintSARIF {
+			return cmd.printSARIF(allFindings)
+		}
+
 		if cmd.opts.PrintJSON {
 			s, err := stringutil.ToJSONString(allFindings)
 			if err != nil {
@@ -192,7 +213,7 @@ Skipping remote findings because running in non-interactive mode.`)
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
 
 		data := [][]string{
-			{"Origin", "Severity", "Name", "Description", "Fuzz Test", "Location"},
+			{"Origin", "Severity", "Name", "Description", "Fuzz Test", "Location", "Occurrences", "Last Seen"},
 		}
 
 		for _, f := range allFindings {
@@ -206,16 +227,25 @@ Skipping remote findings because running in non-interactive mode.`)
 					score = colorFunc(fmt.Sprintf("%.1f", f.MoreDetails.Severity.Score))
 				}
 			}
+			// FIXME: replace f.ShortDescriptionColumns()[0] with
+			// f.MoreDetails.Name once we cover all bugs with our
+			// error-details.json
+			description := f.ShortDescriptionColumns()[0]
+			if f.Suppressed {
+				description += " (suppressed)"
+			}
+			if f.Reproducibility != nil && f.Reproducibility.IsFlaky() {
+				description += fmt.Sprintf(" (flaky: %.0f%%)", f.Reproducibility.Rate*100)
+			}
 			data = append(data, []string{
 				f.Origin,
 				score,
 				f.Name,
-				// FIXME: replace f.ShortDescriptionColumns()[0] with
-				// f.MoreDetails.Name once we cover all bugs with our
-				// error-details.json
-				f.ShortDescriptionColumns()[0],
+				description,
 				f.FuzzTest,
 				locationInfo,
+				fmt.Sprint(f.Count()),
+				formatTime(f.LastSeen()),
 			})
 		}
 		err = pterm.DefaultTable.WithHasHeader().WithData(data).Render()
@@ -254,6 +284,10 @@ Skipping remote findings because running in non-interactive mode.`)
 }
 
 func (cmd *findingCmd) printFinding(f *finding.Finding) error {
+	if cmd.opts.PrintSARIF {
+		return cmd.printSARIF([]*finding.Finding{f})
+	}
+
 	if cmd.opts.PrintJSON {
 		s, err := stringutil.ToJSONString(f)
 		if err != nil {
@@ -266,6 +300,27 @@ func (cmd *findingCmd) printFinding(f *finding.Finding) error {
 	} else {
 		s := pterm.Style{pterm.Reset, pterm.Bold}.Sprint(f.ShortDescriptionWithName())
 		s += fmt.Sprintf("\nDate: %s\n", f.CreatedAt)
+		s += fmt.Sprintf("First seen: %s\n", formatTime(f.FirstSeen()))
+		s += fmt.Sprintf("Last seen: %s\n", formatTime(f.LastSeen()))
+		s += fmt.Sprintf("Occurrences: %d\n", f.Count())
+		if f.MinimizedInputFile != "" {
+			s += fmt.Sprintf("Minimized input: %s\n", f.MinimizedInputFile)
+		}
+		if f.Suppressed {
+			s += fmt.Sprintf("Suppressed: %s\n", f.SuppressionReason)
+		}
+		if f.FirstBadCommit != "" {
+			s += fmt.Sprintf("First bad commit: %s\n", f.FirstBadCommit)
+		}
+		if f.Reproducibility != nil {
+			s += fmt.Sprintf("Reproducibility: %s\n", f.Reproducibility)
+		}
+		if f.Exploitability != nil {
+			s += fmt.Sprintf("Exploitability: %s\n", f.Exploitability)
+		}
+		if len(f.Occurrences) > 0 {
+			s += "\n" + formatOccurrences(f)
+		}
 		s += fmt.Sprintf("\n  %s\n", strings.Join(f.Logs, "\n  "))
 		_, err := fmt.Fprint(cmd.OutOrStdout(), s)
 		if err != nil {
@@ -276,6 +331,55 @@ func (cmd *findingCmd) printFinding(f *finding.Finding) error {
 	return nil
 }
 
+// printSARIF prints the findings as a SARIF log, which can be uploaded
+// to code scanning tools.
+func (cmd *findingCmd) printSARIF(findings []*finding.Finding) error {
+	s, err := stringutil.ToJSONString(sarif.FromFindings(findings, version.Version))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// formatOccurrences returns a table of the occurrences of the finding.
+// Occurrences with a different name are duplicates with the same crash
+// signature.
+func formatOccurrences(f *finding.Finding) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  Date\tFuzz Test\tGit Revision\tDuplicate\tInput")
+	for _, o := range f.Occurrences {
+		revision := "n/a"
+		if o.GitCommit != "" {
+			revision = o.GitCommit
+			if len(revision) > 12 {
+				revision = revision[:12]
+			}
+			if o.GitBranch != "" {
+				revision += " (" + o.GitBranch + ")"
+			}
+		}
+		duplicate := ""
+		if o.Name != f.Name {
+			duplicate = o.Name
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", formatTime(o.CreatedAt), o.FuzzTest, revision, duplicate, o.InputFile)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "n/a"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
 func PrintMoreDetails(f *finding.Finding) {
 	if f.MoreDetails == nil {
 		return
@@ -294,6 +398,9 @@ func PrintMoreDetails(f *finding.Finding) {
 	if f.MoreDetails.Severity != nil {
 		data = append(data, []string{"Severity Level", string(f.MoreDetails.Severity.Level)})
 		data = append(data, []string{"Severity Score", fmt.Sprintf("%.1f", f.MoreDetails.Severity.Score)})
+		if f.Exploitability != nil {
+			data = append(data, []string{"Exploitability", string(f.Exploitability.Rating)})
+		}
 
 	}
 	if f.MoreDetails.Links != nil {
@@ -1,6 +1,7 @@
 package finding
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/sarif"
 	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
 	"code-intelligence.com/cifuzz/util/stringutil"
@@ -167,6 +169,57 @@ func TestPrintFinding(t *testing.T) {
 	require.NotContains(t, stdErr, "cifuzz found more extensive information about this finding:")
 }
 
+func TestPrintFindings_SARIF(t *testing.T) {
+	// The exploitability is classified from the AddressSanitizer report
+	// when the finding is loaded
+	f := &finding.Finding{
+		Origin:      "Local",
+		Name:        "test_finding",
+		Type:        finding.ErrorTypeCrash,
+		Details:     "heap-buffer-overflow on address 0x0001054009b1",
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		Logs: []string{
+			"==6862==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x0001054009b1 at pc 0x000102ec2228 bp 0x00016d6162d0 sp 0x00016d615a90",
+			"WRITE of size 9 at 0x0001054009b1 thread T0",
+			"0x0001054009b1 is located 0 bytes to the right of 1-byte region [0x0001054009b0,0x0001054009b1)",
+		},
+	}
+
+	projectDir := testutil.BootstrapEmptyProject(t, "test-list-findings-")
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	// Check that the findings are listed as SARIF results
+	stdOut, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--sarif", "--interactive=false")
+	require.NoError(t, err)
+	var log sarif.Log
+	err = json.Unmarshal([]byte(stdOut), &log)
+	require.NoError(t, err)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "heap_buffer_overflow", result.RuleID)
+	assert.Equal(t, "test_finding", result.Properties.FindingName)
+	require.NotNil(t, result.Properties.Exploitability)
+	assert.Equal(t, finding.ExploitabilityLikely, result.Properties.Exploitability.Rating)
+	assert.NotEmpty(t, log.Runs[0].Tool.Driver.Rules[0].Properties.SecuritySeverity)
+
+	// Check that a single finding is printed as SARIF as well
+	stdOut, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name, "--sarif", "--interactive=false")
+	require.NoError(t, err)
+	err = json.Unmarshal([]byte(stdOut), &log)
+	require.NoError(t, err)
+	require.Len(t, log.Runs[0].Results, 1)
+
+	// Check that --json and --sarif can't be combined
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--sarif", "--json", "--interactive=false")
+	require.Error(t, err)
+}
+
 func TestPrintFinding_Authenticated(t *testing.T) {
 	t.Setenv("CIFUZZ_API_TOKEN", "token")
 	server := mockserver.New(t)
@@ -0,0 +1,156 @@
+// Package findingutil contains the functionality which is shared by the
+// commands which operate on a single local finding, e.g. building the
+// fuzz test of the finding and running it with the crashing input.
+package findingutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// BuildOptions are the options to build the fuzz test of a finding and
+// to run it with NewRunner.
+type BuildOptions struct {
+	ProjectDir   string
+	BuildSystem  string
+	BuildCommand string
+	CleanCommand string
+	NumBuildJobs uint
+
+	// The dictionary and the engine args which are passed to the fuzz
+	// test in the same way as by 'cifuzz run'
+	Dictionary string
+	EngineArgs []string
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Validate determines the build system if it's not set and checks that
+// it's supported by the finding commands.
+func (opts *BuildOptions) Validate(command string) error {
+	var err error
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = config.ValidateBuildSystem(opts.BuildSystem)
+	if err != nil {
+		return err
+	}
+
+	if !IsSupportedBuildSystem(opts.BuildSystem) {
+		msg := fmt.Sprintf("%s is not supported for build system %q.", command, opts.BuildSystem)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	// To build with other build systems, a build command must be provided
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := "Flag \"build-command\" must be set when using build system type \"other\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+// IsSupportedBuildSystem returns whether the fuzz tests of the build
+// system can be built and run by the finding commands.
+func IsSupportedBuildSystem(buildSystem string) bool {
+	switch buildSystem {
+	case config.BuildSystemCMake, config.BuildSystemOther, config.BuildSystemMaven, config.BuildSystemGradle, config.BuildSystemNodeJS:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsJavaBuildSystem returns whether the build system builds JVM fuzz
+// tests which are run via Jazzer.
+func IsJavaBuildSystem(buildSystem string) bool {
+	return buildSystem == config.BuildSystemMaven || buildSystem == config.BuildSystemGradle
+}
+
+// LoadFinding loads the local finding and returns it together with the
+// absolute path of its crashing input.
+func LoadFinding(projectDir, name string) (*finding.Finding, string, error) {
+	f, err := finding.LoadFinding(projectDir, name)
+	if finding.IsNotExistError(err) {
+		return nil, "", errors.WithMessagef(err, "Finding %s does not exist", name)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if f.InputFile == "" && len(f.InputData) > 0 {
+		// Saving the finding writes the input data to a file
+		err = f.Save(projectDir)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if f.InputFile == "" {
+		return nil, "", errors.Errorf("Finding %s has no crashing input", f.Name)
+	}
+
+	inputPath := f.InputFile
+	if !filepath.IsAbs(inputPath) {
+		inputPath = filepath.Join(projectDir, inputPath)
+	}
+	return f, inputPath, nil
+}
+
+// Build builds the fuzz test via the run adapters, so that it's built
+// with the same flags and sanitizers as by 'cifuzz run'. For JVM fuzz
+// tests, the runtime dependencies of the build result contain the class
+// path. Node.js fuzz tests don't need to be built, so an empty build
+// result is returned for them.
+func Build(opts *BuildOptions, fuzzTest string) (*build.BuildResult, error) {
+	if opts.BuildSystem == config.BuildSystemNodeJS {
+		return &build.BuildResult{}, nil
+	}
+
+	var err error
+	if logging.ShouldLogBuildToFile() {
+		opts.Stdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		opts.Stderr = opts.Stdout
+	}
+
+	// JVM fuzz tests are built by class, the method is only needed to
+	// run the fuzz test
+	targetClass, _, _ := strings.Cut(fuzzTest, "::")
+
+	log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(targetClass))
+	return adapter.Build(&adapter.RunOptions{
+		ProjectDir:   opts.ProjectDir,
+		BuildSystem:  opts.BuildSystem,
+		BuildCommand: opts.BuildCommand,
+		CleanCommand: opts.CleanCommand,
+		NumBuildJobs: opts.NumBuildJobs,
+		FuzzTest:     targetClass,
+		BuildStdout:  opts.Stdout,
+		BuildStderr:  opts.Stderr,
+		// The build printer must not print to the build log file
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+}
@@ -0,0 +1,36 @@
+package findingutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestBuildOptions_Validate(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "build-options-")
+
+	for _, buildSystem := range []string{
+		config.BuildSystemCMake,
+		config.BuildSystemMaven,
+		config.BuildSystemGradle,
+		config.BuildSystemNodeJS,
+	} {
+		opts := &BuildOptions{ProjectDir: projectDir, BuildSystem: buildSystem}
+		assert.NoError(t, opts.Validate("Minimizing findings"), buildSystem)
+	}
+
+	opts := &BuildOptions{ProjectDir: projectDir, BuildSystem: config.BuildSystemBazel}
+	err := opts.Validate("Minimizing findings")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Minimizing findings is not supported for build system "bazel".`)
+
+	// Other build systems need a build command
+	opts = &BuildOptions{ProjectDir: projectDir, BuildSystem: config.BuildSystemOther}
+	require.Error(t, opts.Validate("Minimizing findings"))
+	opts.BuildCommand = "make"
+	require.NoError(t, opts.Validate("Minimizing findings"))
+}
@@ -0,0 +1,88 @@
+package findingutil
+
+import (
+	"io"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+)
+
+// Options are the options which are shared by the commands which build
+// and run the fuzz test of a local finding. The commands embed them in
+// their own options with `mapstructure:",squash"`.
+type Options struct {
+	cmdutils.DedupOptions `mapstructure:",squash"`
+
+	ProjectDir   string   `mapstructure:"project-dir"`
+	ConfigDir    string   `mapstructure:"config-dir"`
+	BuildSystem  string   `mapstructure:"build-system"`
+	BuildCommand string   `mapstructure:"build-command"`
+	CleanCommand string   `mapstructure:"clean-command"`
+	NumBuildJobs uint     `mapstructure:"build-jobs"`
+	Dictionary   string   `mapstructure:"dict"`
+	EngineArgs   []string `mapstructure:"engine-args"`
+
+	FindingName string
+
+	// Set by Validate
+	SignatureOpts *signature.Options
+
+	BuildStdout io.Writer
+	BuildStderr io.Writer
+}
+
+// Validate checks that the command is run on a supported platform and
+// validates the options with ValidateBuildOptions. The command is used
+// in error messages, e.g. "Minimizing findings".
+func (opts *Options) Validate(command string) error {
+	if runtime.GOOS == "windows" {
+		return errors.Errorf("%s is not supported on Windows", command)
+	}
+	return opts.ValidateBuildOptions(command)
+}
+
+// ValidateBuildOptions determines the build system if it's not set,
+// checks that it's supported by the command and parses the crash
+// signature options. Unlike Validate, it doesn't reject Windows.
+func (opts *Options) ValidateBuildOptions(command string) error {
+	buildOpts := opts.BuildOptions(opts.ProjectDir)
+	err := buildOpts.Validate(command)
+	if err != nil {
+		return err
+	}
+	opts.BuildSystem = buildOpts.BuildSystem
+
+	opts.SignatureOpts, err = opts.SignatureOptions()
+	return err
+}
+
+// BuildOptions returns the options to build the fuzz test in the
+// project directory, which differs from opts.ProjectDir if the fuzz test
+// is built in another checkout of the project.
+func (opts *Options) BuildOptions(projectDir string) *BuildOptions {
+	return &BuildOptions{
+		ProjectDir:   projectDir,
+		BuildSystem:  opts.BuildSystem,
+		BuildCommand: opts.BuildCommand,
+		CleanCommand: opts.CleanCommand,
+		NumBuildJobs: opts.NumBuildJobs,
+		Dictionary:   opts.Dictionary,
+		EngineArgs:   opts.EngineArgs,
+		Stdout:       opts.BuildStdout,
+		Stderr:       opts.BuildStderr,
+	}
+}
+
+// CheckDependencies checks that the dependencies which are needed to
+// build and run the fuzz tests of the build system are installed.
+func (opts *Options) CheckDependencies() error {
+	a, err := adapter.NewAdapter(opts.BuildSystem)
+	if err != nil {
+		return err
+	}
+	return a.CheckDependencies(opts.ProjectDir)
+}
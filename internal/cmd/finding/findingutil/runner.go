@@ -0,0 +1,278 @@
+package findingutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/java"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/ldd"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
+	"code-intelligence.com/cifuzz/pkg/log"
+	libfuzzer_parser "code-intelligence.com/cifuzz/pkg/parser/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/jazzer"
+	"code-intelligence.com/cifuzz/pkg/runner/jazzerjs"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/util/envutil"
+)
+
+// FuzzTestRunner runs a fuzz test with single inputs, see Runner.Run.
+type FuzzTestRunner interface {
+	Run(ctx context.Context, args ...string) (*finding.Finding, string, error)
+}
+
+// BuildRunnerFunc builds the fuzz test and returns a runner for it. The
+// commands use BuildRunner, tests replace it with a stub.
+type BuildRunnerFunc func(opts *BuildOptions, fuzzTest string) (FuzzTestRunner, error)
+
+// BuildRunner builds the fuzz test with Build and returns a runner for
+// the build result.
+func BuildRunner(opts *BuildOptions, fuzzTest string) (FuzzTestRunner, error) {
+	buildResult, err := Build(opts, fuzzTest)
+	if err != nil {
+		return nil, err
+	}
+	runner, err := NewRunner(opts, fuzzTest, buildResult)
+	if err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+// Runner runs a built fuzz test with single inputs, e.g. the crashing
+// input of a finding, and parses the finding it reports. The command is
+// built by the same runners which are used by 'cifuzz run', so the
+// dictionary and the engine args are passed to the fuzz test as well.
+type Runner struct {
+	// If set, the output of the fuzz test is written to Output in
+	// addition to being parsed
+	Output io.Writer
+	// If set, the command is printed before it's run, else it's only
+	// printed in verbose mode
+	PrintCommand bool
+
+	// The command which runs the fuzz test, without the libFuzzer
+	// arguments and inputs which are passed to Run
+	command []string
+	// The environment variables which are set for the fuzz test in
+	// addition to the environment of cifuzz
+	fuzzerEnv []string
+	// The working directory of the fuzz test
+	dir        string
+	parserOpts *libfuzzer_parser.Options
+	// Jazzer.js doesn't accept libFuzzer arguments on the command line,
+	// they are passed via the environment instead, see jazzerJSEnv
+	jazzerJSOpts *jazzerjs.RunnerOptions
+}
+
+// NewRunner returns a runner for the fuzz test which was built with the
+// build options. The fuzz test of JVM findings has the form
+// <class>::<method>, the fuzz test of Node.js findings is the jest test
+// path pattern.
+func NewRunner(opts *BuildOptions, fuzzTest string, buildResult *build.BuildResult) (*Runner, error) {
+	r := &Runner{
+		dir: opts.ProjectDir,
+		parserOpts: &libfuzzer_parser.Options{
+			ProjectDir: opts.ProjectDir,
+		},
+	}
+
+	runnerOpts := &libfuzzer.RunnerOptions{
+		Dictionary: opts.Dictionary,
+		EngineArgs: opts.EngineArgs,
+		ProjectDir: opts.ProjectDir,
+		// Avoid that the fuzz test or the libFuzzer flags which are
+		// added by cifuzz for fuzzing runs interfere
+		EnvVars: []string{"NO_CIFUZZ=1"},
+	}
+
+	if opts.BuildSystem == config.BuildSystemNodeJS {
+		r.jazzerJSOpts = &jazzerjs.RunnerOptions{
+			LibfuzzerOptions: runnerOpts,
+			TestPathPattern:  fuzzTest,
+		}
+		r.command = jazzerjs.NewRunner(r.jazzerJSOpts).Command()
+		r.parserOpts.SupportJazzerJS = true
+		// The environment depends on the arguments, see jazzerJSEnv
+		return r, nil
+	}
+
+	var err error
+	if IsJavaBuildSystem(opts.BuildSystem) {
+		targetClass, targetMethod, _ := strings.Cut(fuzzTest, "::")
+		jazzerRunner := jazzer.NewRunner(&jazzer.RunnerOptions{
+			LibfuzzerOptions: runnerOpts,
+			TargetClass:      targetClass,
+			TargetMethod:     targetMethod,
+			ClassPaths:       buildResult.RuntimeDeps,
+		})
+		r.command, err = jazzerRunner.Command()
+		if err != nil {
+			return nil, err
+		}
+		r.command = append(r.command, jazzerRunner.UserArgs()...)
+		r.fuzzerEnv, err = jazzerRunner.FuzzerEnvironment()
+		if err != nil {
+			return nil, err
+		}
+
+		sourceMap, err := javaSourceMap(opts)
+		if err != nil {
+			return nil, err
+		}
+		r.parserOpts.SupportJazzer = true
+		r.parserOpts.SourceMap = sourceMap
+		return r, nil
+	}
+
+	runnerOpts.FuzzTarget = buildResult.Executable
+	runnerOpts.LibraryDirs, err = ldd.LibraryPaths(buildResult.Executable)
+	if err != nil {
+		return nil, err
+	}
+	libfuzzerRunner := libfuzzer.NewRunner(runnerOpts)
+	r.command = append([]string{buildResult.Executable}, libfuzzerRunner.UserArgs()...)
+	r.fuzzerEnv, err = libfuzzerRunner.FuzzerEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func javaSourceMap(opts *BuildOptions) (*sourcemap.SourceMap, error) {
+	sourceDirs, err := java.SourceDirs(opts.ProjectDir, opts.BuildSystem)
+	if err != nil {
+		return nil, err
+	}
+	testDirs, err := java.TestDirs(opts.ProjectDir, opts.BuildSystem)
+	if err != nil {
+		return nil, err
+	}
+	rootDir, err := java.RootDirectory(opts.ProjectDir, opts.BuildSystem)
+	if err != nil {
+		return nil, err
+	}
+	return sourcemap.CreateSourceMap(rootDir, append(sourceDirs, testDirs...))
+}
+
+// FuzzerEnv returns the environment variables which are set for the
+// fuzz test in addition to the environment of cifuzz, e.g. the sanitizer
+// options and the library path. It's not available for Node.js fuzz
+// tests, whose environment depends on the arguments.
+func (r *Runner) FuzzerEnv() []string {
+	return r.fuzzerEnv
+}
+
+// commandAndFuzzerEnv returns the command which runs the fuzz test with
+// the arguments and the environment variables which are set in addition
+// to the environment of cifuzz.
+func (r *Runner) commandAndFuzzerEnv(args []string) ([]string, []string, error) {
+	if r.jazzerJSOpts == nil {
+		return append(append([]string{}, r.command...), args...), r.fuzzerEnv, nil
+	}
+	env, err := r.jazzerJSEnv(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.command, env, nil
+}
+
+// jazzerJSEnv returns the environment variables which run the Jazzer.js
+// fuzz test with the libFuzzer arguments in addition to the configured
+// engine args. Jazzer.js passes inputs which are specified as arguments
+// to libFuzzer, which runs them once instead of fuzzing.
+func (r *Runner) jazzerJSEnv(args []string) ([]string, error) {
+	libfuzzerOpts := *r.jazzerJSOpts.LibfuzzerOptions
+	libfuzzerOpts.EngineArgs = append(append([]string{}, libfuzzerOpts.EngineArgs...), args...)
+	jazzerJSOpts := *r.jazzerJSOpts
+	jazzerJSOpts.LibfuzzerOptions = &libfuzzerOpts
+
+	return jazzerjs.NewRunner(&jazzerJSOpts).FuzzerEnvironment()
+}
+
+// CommandContext returns the command which runs the fuzz test with the
+// arguments, including the working directory and the environment.
+func (r *Runner) CommandContext(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	cmd, _, err := r.commandContext(ctx, args)
+	return cmd, err
+}
+
+func (r *Runner) commandContext(ctx context.Context, args []string) (*exec.Cmd, []string, error) {
+	command, fuzzerEnv, err := r.commandAndFuzzerEnv(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = r.dir
+	cmd.Env, err = envutil.Copy(os.Environ(), fuzzerEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmd, fuzzerEnv, nil
+}
+
+// Run runs the fuzz test with the arguments, which are libFuzzer flags
+// and inputs, and returns the output and the first finding which was
+// reported, or nil if the fuzz test didn't crash.
+func (r *Runner) Run(ctx context.Context, args ...string) (*finding.Finding, string, error) {
+	cmd, fuzzerEnv, err := r.commandContext(ctx, args)
+	if err != nil {
+		return nil, "", err
+	}
+	var output bytes.Buffer
+	var w io.Writer = &output
+	if r.Output != nil {
+		w = io.MultiWriter(&output, r.Output)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if r.PrintCommand {
+		log.Printf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, fuzzerEnv))
+	} else {
+		log.Debugf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, fuzzerEnv))
+	}
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, "", errors.WithStack(err)
+	}
+	// A non-zero exit code is expected if the fuzz test crashes, the
+	// crash is reported by the parser
+
+	f, err := ParseFinding(ctx, output.String(), r.parserOpts)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, output.String(), nil
+}
+
+// ParseFinding parses the libFuzzer output and returns the first
+// finding which it contains, or nil if it doesn't contain a finding.
+func ParseFinding(ctx context.Context, output string, opts *libfuzzer_parser.Options) (*finding.Finding, error) {
+	reportsCh := make(chan *report.Report)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- libfuzzer_parser.NewLibfuzzerOutputParser(opts).Parse(ctx, strings.NewReader(output), reportsCh)
+	}()
+
+	var res *finding.Finding
+	for r := range reportsCh {
+		if r.Finding != nil && res == nil {
+			res = r.Finding
+		}
+	}
+	err := <-errCh
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package findingutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	libfuzzer_parser "code-intelligence.com/cifuzz/pkg/parser/libfuzzer"
+	"code-intelligence.com/cifuzz/util/envutil"
+)
+
+const crashingFuzzTest = `#!/bin/sh
+if [ "$1" = "crash" ]; then
+  echo "==16==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000 (pc 0x000000000000 bp 0x7fffb9492290 sp 0x7fffb9492158 T0)" >&2
+  echo "==16==ABORTING" >&2
+  exit 1
+fi
+echo "Executed $1 in 1 ms"
+`
+
+func TestRunner_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake fuzz test is a shell script")
+	}
+
+	dir := t.TempDir()
+	fuzzTest := filepath.Join(dir, "fuzz_test")
+	err := os.WriteFile(fuzzTest, []byte(crashingFuzzTest), 0o755)
+	require.NoError(t, err)
+
+	r := &Runner{
+		command:    []string{fuzzTest},
+		dir:        dir,
+		parserOpts: &libfuzzer_parser.Options{ProjectDir: dir},
+	}
+
+	f, output, err := r.Run(context.Background(), "crash")
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	assert.Equal(t, finding.ErrorTypeCrash, f.Type)
+	assert.Contains(t, f.Details, "SEGV on unknown address")
+	assert.Contains(t, output, "==16==ABORTING")
+
+	f, output, err = r.Run(context.Background(), "no-crash")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.Contains(t, output, "Executed no-crash")
+}
+
+func TestNewRunner_NodeJS(t *testing.T) {
+	projectDir := t.TempDir()
+	r, err := NewRunner(&BuildOptions{
+		ProjectDir:  projectDir,
+		BuildSystem: config.BuildSystemNodeJS,
+		Dictionary:  filepath.Join(projectDir, "fuzz.dict"),
+		EngineArgs:  []string{"-rss_limit_mb=4096"},
+	}, "myTest", &build.BuildResult{})
+	require.NoError(t, err)
+
+	// The input is passed to Jazzer.js via the environment, together
+	// with the dictionary and the engine args
+	command, env, err := r.commandAndFuzzerEnv([]string{"crashing-input"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npx", "jest", "--testPathPattern='myTest'", "--testNamePattern=''", "--testFailureExitCode='77'"}, command)
+	assert.Equal(t, "1", envutil.Getenv(env, "JAZZER_FUZZ"))
+	assert.Equal(t, "1", envutil.Getenv(env, "NO_CIFUZZ"))
+	dictFlag := "-dict=" + filepath.Join(projectDir, "fuzz.dict")
+	assert.Equal(t, fmt.Sprintf(`[%q,"-rss_limit_mb=4096","crashing-input"]`, dictFlag), envutil.Getenv(env, "JAZZER_FUZZER_OPTIONS"))
+	assert.True(t, r.parserOpts.SupportJazzerJS)
+}
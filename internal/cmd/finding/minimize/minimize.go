@@ -0,0 +1,217 @@
+package minimize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	findingutil.Options `mapstructure:",squash"`
+
+	TimeBudget time.Duration
+}
+
+func (opts *options) validate() error {
+	err := opts.Options.Validate("Minimizing findings")
+	if err != nil {
+		return err
+	}
+
+	if opts.TimeBudget <= 0 {
+		msg := "Flag \"time-budget\" must be a positive duration"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+type minimizeCmd struct {
+	*cobra.Command
+	opts        *options
+	buildRunner findingutil.BuildRunnerFunc
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "minimize <name>",
+		Short: "Minimize the crashing input of a finding",
+		Long: `This command builds the fuzz test of a local finding and uses
+libFuzzer's crash minimization (-minimize_crash=1) to search for a smaller
+input which triggers the same crash. For Java fuzz tests, Jazzer's crash
+minimization is used.
+
+The minimized input is only kept if it produces the same error and the
+same top stack frames as the original crashing input. It is stored as
+crashing-input-minimized next to the original crashing input in the
+finding directory, the original input is not modified.
+
+<name> is the name of a finding.
+Run 'cifuzz findings' to get a list of all available findings.
+
+Only CMake, Maven, Gradle, Node.js and other build systems are supported.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.FindingName = args[0]
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			err := opts.CheckDependencies()
+			if err != nil {
+				return err
+			}
+			cmd := minimizeCmd{Command: c, opts: opts, buildRunner: findingutil.BuildRunner}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+	)
+	cmd.Flags().DurationVar(&opts.TimeBudget, "time-budget", time.Minute,
+		"Maximum time to spend on minimizing the crashing input, e.g. \"30s\", \"5m\".")
+
+	return cmd
+}
+
+func (c *minimizeCmd) run() error {
+	f, inputPath, err := findingutil.LoadFinding(c.opts.ProjectDir, c.opts.FindingName)
+	if err != nil {
+		return err
+	}
+
+	runner, err := c.buildRunner(c.opts.BuildOptions(c.opts.ProjectDir), f.FuzzTest)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// Determine the signature of the original crash with the current
+	// build, because the stored signature might stem from an older
+	// version of the code
+	log.Infof("Running %s with the crashing input", f.FuzzTest)
+	original, output, err := runner.Run(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		log.Debug(output)
+		return errors.Errorf("Finding %s doesn't reproduce with the current build of %s", f.Name, f.FuzzTest)
+	}
+	originalSignature := signature.ForFinding(original, c.opts.SignatureOpts)
+
+	tempDir, err := os.MkdirTemp("", "cifuzz-minimize-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+	minimizedPath := filepath.Join(tempDir, "crashing-input-minimized")
+
+	log.Infof("Minimizing the crashing input for %s", c.opts.TimeBudget)
+	_, output, err = runner.Run(ctx, minimizeArgs(inputPath, minimizedPath, c.opts.TimeBudget)...)
+	if err != nil {
+		return err
+	}
+	log.Debug(output)
+
+	exists, err := fileutil.Exists(minimizedPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Notef("The crashing input of finding %s could not be minimized", f.Name)
+		return nil
+	}
+
+	// Verify that the minimized input triggers the same bug
+	minimized, output, err := runner.Run(ctx, minimizedPath)
+	if err != nil {
+		return err
+	}
+	if minimized == nil {
+		log.Debug(output)
+		return errors.Errorf("The minimized input doesn't reproduce finding %s, it was discarded", f.Name)
+	}
+	if s := signature.ForFinding(minimized, c.opts.SignatureOpts); s != originalSignature {
+		return errors.Errorf(`The minimized input produces a different crash than finding %s, it was discarded:
+  original:  %s
+  minimized: %s`, f.Name, originalSignature, s)
+	}
+
+	err = f.SaveMinimizedInput(c.opts.ProjectDir, minimizedPath)
+	if err != nil {
+		return err
+	}
+
+	originalSize, err := fileSize(inputPath)
+	if err != nil {
+		return err
+	}
+	minimizedSize, err := fileSize(minimizedPath)
+	if err != nil {
+		return err
+	}
+	log.Successf("Minimized the crashing input of finding %s from %d to %d bytes", f.Name, originalSize, minimizedSize)
+	log.Printf("The minimized input was stored in %s", f.MinimizedInputFile)
+	return nil
+}
+
+// minimizeArgs returns the libFuzzer arguments which minimize the
+// crashing input and write the result to outputPath. The same arguments
+// are supported by Jazzer.
+func minimizeArgs(inputPath, outputPath string, timeBudget time.Duration) []string {
+	return []string{
+		"-minimize_crash=1",
+		// libFuzzer only accepts whole seconds
+		fmt.Sprintf("-max_total_time=%d", max(int(timeBudget.Seconds()), 1)),
+		"-exact_artifact_path=" + outputPath,
+		inputPath,
+	}
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,119 @@
+package minimize
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/builder"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+)
+
+func TestMain(m *testing.M) {
+	// Set finder install dir to project root. This way the
+	// finder finds the required error-details.json in the
+	// project dir instead of the cifuzz install dir.
+	sourceDir, err := builder.FindProjectDir()
+	if err != nil {
+		log.Fatalf("Failed to find cifuzz project dir")
+	}
+
+	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
+
+	m.Run()
+}
+
+func TestMinimizeArgs(t *testing.T) {
+	args := minimizeArgs("input", "output", 90*time.Second)
+	assert.Equal(t, []string{
+		"-minimize_crash=1",
+		"-max_total_time=90",
+		"-exact_artifact_path=output",
+		"input",
+	}, args)
+
+	// Time budgets below one second are rounded up
+	args = minimizeArgs("input", "output", 500*time.Millisecond)
+	assert.Contains(t, args, "-max_total_time=1")
+}
+
+// stubRunner crashes with the same finding for every input and writes
+// a minimized input if libFuzzer's crash minimization is requested.
+type stubRunner struct {
+	crash *finding.Finding
+}
+
+func (r *stubRunner) Run(_ context.Context, args ...string) (*finding.Finding, string, error) {
+	for _, arg := range args {
+		if path, ok := strings.CutPrefix(arg, "-exact_artifact_path="); ok {
+			err := os.WriteFile(path, []byte("min"), 0o644)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return r.crash, "", nil
+}
+
+func TestMinimize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Minimizing findings is not supported on Windows")
+	}
+
+	projectDir := t.TempDir()
+	f := &finding.Finding{
+		Name:      "test_finding",
+		FuzzTest:  "my_fuzz_test",
+		InputData: []byte("crashing input"),
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		Options: findingutil.Options{
+			ProjectDir:   projectDir,
+			BuildSystem:  config.BuildSystemOther,
+			BuildCommand: "make",
+			FindingName:  f.Name,
+		},
+		TimeBudget: time.Second,
+	}
+	err = opts.validate()
+	require.NoError(t, err)
+
+	runner := &stubRunner{crash: &finding.Finding{
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "src/parser.cpp", Line: 12},
+		},
+	}}
+	cmd := &minimizeCmd{
+		opts: opts,
+		buildRunner: func(buildOpts *findingutil.BuildOptions, fuzzTest string) (findingutil.FuzzTestRunner, error) {
+			assert.Equal(t, projectDir, buildOpts.ProjectDir)
+			assert.Equal(t, f.FuzzTest, fuzzTest)
+			return runner, nil
+		},
+	}
+	err = cmd.run()
+	require.NoError(t, err)
+
+	saved, err := finding.LoadFinding(projectDir, f.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, saved.MinimizedInputFile)
+	content, err := os.ReadFile(filepath.Join(projectDir, saved.MinimizedInputFile))
+	require.NoError(t, err)
+	assert.Equal(t, "min", string(content))
+}
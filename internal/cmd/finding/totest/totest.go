@@ -0,0 +1,218 @@
+package totest
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/regressiontest"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+	BuildSystem string `mapstructure:"build-system"`
+
+	FindingName string
+	OutputPath  string
+}
+
+func (opts *options) validate() error {
+	var err error
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.ValidateBuildSystem(opts.BuildSystem)
+}
+
+type toTestCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "to-test <name>",
+		Short: "Generate a regression test from a finding",
+		Long: `This command generates a unit test which calls the fuzz test of a
+local finding with its crashing input. Once the bug is fixed, the test
+guards against it coming back. If the crashing input of the finding was
+minimized with 'cifuzz finding minimize', the minimized input is used.
+
+The test is placed next to the source file of the fuzz test:
+
+  * C/C++: a test with a main function which passes the crashing input
+    to LLVMFuzzerTestOneInput. It must be compiled together with the
+    fuzz test, but without -fsanitize=fuzzer.
+  * Java/Kotlin: no code is generated, the crashing input is added to
+    the inputs of the @FuzzTest method in the test resources, e.g.
+    src/test/resources/com/example/MyFuzzTestInputs/myFuzzTest/<name>.
+    Jazzer replays these inputs when the fuzz test is run as a regular
+    JUnit test.
+  * JavaScript/TypeScript: a jest test which calls the fuzz tests of the
+    fuzz test file with the crashing input.
+
+<name> is the name of a finding.
+Run 'cifuzz findings' to get a list of all available findings.
+
+For build systems for which the source file of the fuzz test can't be
+determined, the path of the test must be specified via --output.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.FindingName = args[0]
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := toTestCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "",
+		"Output path of the regression test or, for JVM fuzz tests, of the crashing input (default: next to the source file of the fuzz test)")
+
+	return cmd
+}
+
+func (c *toTestCmd) run() error {
+	f, inputPath, err := findingutil.LoadFinding(c.opts.ProjectDir, c.opts.FindingName)
+	if err != nil {
+		return err
+	}
+	if f.MinimizedInputFile != "" {
+		log.Infof("Using the minimized crashing input of finding %s", f.Name)
+		inputPath = filepath.Join(c.opts.ProjectDir, f.MinimizedInputFile)
+	}
+	input, err := os.ReadFile(inputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if findingutil.IsJavaBuildSystem(c.opts.BuildSystem) {
+		return c.addJVMInput(f, input)
+	}
+
+	fuzzTestFile, err := resolve.SourceFile(f.FuzzTest, c.opts.BuildSystem, c.opts.ProjectDir)
+	if err != nil {
+		if c.opts.OutputPath == "" {
+			return cmdutils.WrapIncorrectUsageError(errors.WithMessagef(err,
+				"Failed to find the source file of fuzz test %s, please specify the path of the regression test via --output",
+				f.FuzzTest))
+		}
+		log.Debugf("Failed to find the source file of fuzz test %s: %v", f.FuzzTest, err)
+	}
+
+	outputPath := c.opts.OutputPath
+	if outputPath == "" {
+		outputPath, err = regressiontest.Filename(fuzzTestFile, f.Name)
+		if err != nil {
+			return err
+		}
+	}
+	err = checkNotExists(outputPath)
+	if err != nil {
+		return err
+	}
+
+	content, err := regressiontest.Generate(outputPath, &regressiontest.Options{
+		FindingName:  f.Name,
+		FuzzTest:     f.FuzzTest,
+		FuzzTestFile: fuzzTestFile,
+		Input:        input,
+	})
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(outputPath, content, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Successf("Created regression test for finding %s: %s", f.Name, fileutil.PrettifyPath(outputPath))
+	return nil
+}
+
+// addJVMInput adds the crashing input to the inputs of the @FuzzTest
+// method, which Jazzer replays when the fuzz test is run as a JUnit
+// test. The source file of the fuzz test is only used to find the test
+// resources directory of the module, so it's optional.
+func (c *toTestCmd) addJVMInput(f *finding.Finding, input []byte) error {
+	outputPath := c.opts.OutputPath
+	if outputPath == "" {
+		fuzzTestFile, err := resolve.SourceFile(f.FuzzTest, c.opts.BuildSystem, c.opts.ProjectDir)
+		if err != nil {
+			log.Debugf("Failed to find the source file of fuzz test %s: %v", f.FuzzTest, err)
+		}
+		outputPath, err = regressiontest.JVMInputPath(c.opts.ProjectDir, fuzzTestFile, f.FuzzTest, f.Name)
+		if err != nil {
+			return err
+		}
+	}
+	err := checkNotExists(outputPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(outputPath), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(outputPath, input, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Successf("Added the crashing input of finding %s to the inputs of %s: %s",
+		f.Name, f.FuzzTest, fileutil.PrettifyPath(outputPath))
+	log.Print("Jazzer replays it when the fuzz test is run as a JUnit test.")
+	return nil
+}
+
+func checkNotExists(outputPath string) error {
+	exists, err := fileutil.Exists(outputPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("Regression test %s already exists", outputPath)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package totest
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/builder"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func TestMain(m *testing.M) {
+	// Set finder install dir to project root. This way the
+	// finder finds the required error-details.json in the
+	// project dir instead of the cifuzz install dir.
+	sourceDir, err := builder.FindProjectDir()
+	if err != nil {
+		log.Fatalf("Failed to find cifuzz project dir")
+	}
+
+	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
+
+	m.Run()
+}
+
+func TestToTestCmd_CMake(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-to-test-cmd-")
+	err := os.MkdirAll(filepath.Join(projectDir, "src"), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "src", "CMakeLists.txt"),
+		[]byte("add_fuzz_test(my_fuzz_test fuzz_test.cpp)\n"), 0o644)
+	require.NoError(t, err)
+
+	f := &finding.Finding{
+		Name:      "focused_turing",
+		FuzzTest:  "my_fuzz_test",
+		InputData: []byte("FUZZ"),
+	}
+	err = f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		ProjectDir:  projectDir,
+		ConfigDir:   projectDir,
+		BuildSystem: config.BuildSystemCMake,
+	}
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name)
+	require.NoError(t, err)
+
+	testPath := filepath.Join(projectDir, "src", "focused_turing_regression_test.cpp")
+	content, err := os.ReadFile(testPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "0x46, 0x55, 0x5a, 0x5a,")
+
+	// The existing test is not overwritten
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name)
+	require.Error(t, err)
+}
+
+func TestToTestCmd_Other(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-to-test-cmd-")
+
+	f := &finding.Finding{
+		Name:      "focused_turing",
+		FuzzTest:  "my_fuzz_test",
+		InputData: []byte("FUZZ"),
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		ProjectDir:  projectDir,
+		ConfigDir:   projectDir,
+		BuildSystem: config.BuildSystemOther,
+	}
+	// The source file of the fuzz test can't be determined for other
+	// build systems
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name)
+	require.Error(t, err)
+
+	testPath := filepath.Join(projectDir, "regression_test.c")
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name, "--output", testPath)
+	require.NoError(t, err)
+	exists, err := fileutil.Exists(testPath)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestToTestCmd_Maven(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-to-test-cmd-")
+
+	f := &finding.Finding{
+		Name:      "focused_turing",
+		FuzzTest:  "com.example.FuzzTestCase::myFuzzTest",
+		InputData: []byte("FUZZ"),
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+
+	opts := &options{
+		ProjectDir:  projectDir,
+		ConfigDir:   projectDir,
+		BuildSystem: config.BuildSystemMaven,
+	}
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name)
+	require.NoError(t, err)
+
+	// The crashing input is added to the inputs of the @FuzzTest method,
+	// which Jazzer replays in regression mode
+	inputPath := filepath.Join(projectDir, "src", "test", "resources", "com", "example", "FuzzTestCaseInputs", "myFuzzTest", f.Name)
+	content, err := os.ReadFile(inputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "FUZZ", string(content))
+}
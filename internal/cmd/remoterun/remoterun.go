@@ -46,10 +46,6 @@ func (opts *remoteRunOpts) Validate() error {
 		return err
 	}
 
-	if opts.BuildSystem == config.BuildSystemNodeJS && !config.AllowUnsupportedPlatforms() {
-		return errors.Errorf(config.NotSupportedErrorMessage("remote run", opts.BuildSystem))
-	}
-
 	if opts.BundlePath == "" {
 		// We need to build a bundle, so we validate the bundler options
 		// as well
@@ -262,11 +258,13 @@ func (c *runRemoteCmd) run() error {
 		buildPrinter := logging.NewBuildPrinter(c.ErrOrStderr(), log.BundleInProgressMsg)
 
 		b := bundler.New(&c.opts.Opts)
-		_, err = b.Bundle()
+		bundleResult, err := b.Bundle()
 		if err != nil {
 			buildPrinter.StopOnError(log.BundleInProgressErrorMsg)
 			return err
 		}
+		// The bundler adds the extension of the configured compression
+		c.opts.BundlePath = bundleResult.BundlePath
 
 		buildPrinter.StopOnSuccess(log.BundleInProgressSuccessMsg, true)
 	}
@@ -0,0 +1,300 @@
+package reproduce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/config"
+	findingPkg "code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+const (
+	debuggerGDB  = "gdb"
+	debuggerLLDB = "lldb"
+)
+
+// The functions which are called by the sanitizers when they report an
+// error, before the process is terminated
+var sanitizerReportFunctions = []string{
+	"__asan::ReportGenericError",
+	"__ubsan_on_report",
+	"__sanitizer::Die",
+}
+
+// The exceptions which Jazzer throws when it detects a bug
+const jazzerSecurityIssuePrefix = "com.code_intelligence.jazzer.api.FuzzerSecurityIssue"
+
+// validateDebugOptions checks the flags of the debug mode.
+func (opts *options) validateDebugOptions() error {
+	if !opts.Debug {
+		if opts.Debugger != "" || opts.VSCode {
+			return errors.New(`Flags "debugger" and "vscode" require flag "debug"`)
+		}
+		return nil
+	}
+
+	if opts.BuildSystem == config.BuildSystemNodeJS {
+		return errors.New("Debugging findings is not supported for Node.js")
+	}
+	switch opts.Debugger {
+	case "", debuggerGDB, debuggerLLDB:
+	default:
+		return errors.Errorf(`Flag "debugger" must be %q or %q`, debuggerGDB, debuggerLLDB)
+	}
+	return nil
+}
+
+// debug runs the fuzz test with the crashing input of the finding in a
+// debugger (C/C++) or in a JVM which waits for a debugger to attach
+// (Java).
+func (c *reproduceCmd) debug(finding *findingPkg.Finding, runner *findingutil.Runner) error {
+	cmd, err := runner.CommandContext(context.Background(), finding.InputFile)
+	if err != nil {
+		return err
+	}
+
+	if findingutil.IsJavaBuildSystem(c.opts.BuildSystem) {
+		return c.debugJava(finding, cmd, runner.FuzzerEnv())
+	}
+	return c.debugC(finding, cmd, runner.FuzzerEnv())
+}
+
+func (c *reproduceCmd) debugC(finding *findingPkg.Finding, cmd *exec.Cmd, fuzzerEnv []string) error {
+	debugger := c.opts.Debugger
+	if debugger == "" {
+		debugger = defaultDebugger()
+	}
+	breakpoints := cBreakpoints(finding)
+
+	if c.opts.VSCode {
+		// VS Code starts the fuzz test in the debugger itself
+		return c.writeVSCodeLaunchConfig(finding, cppLaunchConfig(finding, debugger, cmd, fuzzerEnv, breakpoints))
+	}
+
+	debuggerPath, err := exec.LookPath(debugger)
+	if err != nil {
+		return errors.Errorf("%s is required to debug findings, but it is not installed or not in the PATH", debugger)
+	}
+	args := debuggerArgs(debugger, cmd.Args, breakpoints)
+	debugCmd := exec.Command(debuggerPath, args...)
+	debugCmd.Dir = cmd.Dir
+	debugCmd.Env = cmd.Env
+	return c.runInteractive(debugCmd, fuzzerEnv)
+}
+
+func (c *reproduceCmd) debugJava(finding *findingPkg.Finding, cmd *exec.Cmd, fuzzerEnv []string) error {
+	if c.opts.VSCode {
+		err := c.writeVSCodeLaunchConfig(finding, javaLaunchConfig(finding, c.opts.DebugPort))
+		if err != nil {
+			return err
+		}
+	}
+
+	cmd.Args = jdwpArgs(cmd.Args, c.opts.DebugPort)
+
+	log.Infof("The JVM waits for a debugger to attach on localhost:%d", c.opts.DebugPort)
+	msg := fmt.Sprintf("Set an exception breakpoint on %s*", jazzerSecurityIssuePrefix)
+	if len(finding.StackTrace) > 0 {
+		msg += fmt.Sprintf(" and a breakpoint on %s", finding.SourceLocation())
+	}
+	log.Print(msg)
+	return c.runInteractive(cmd, fuzzerEnv)
+}
+
+func (c *reproduceCmd) runInteractive(cmd *exec.Cmd, fuzzerEnv []string) error {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	log.Printf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, fuzzerEnv))
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return errors.WithStack(err)
+	}
+	// The exit code of the debugger or the JVM reflects the crash of
+	// the fuzz test, which is not an error of this command
+	return nil
+}
+
+func defaultDebugger() string {
+	if runtime.GOOS == "darwin" {
+		return debuggerLLDB
+	}
+	if _, err := exec.LookPath(debuggerGDB); err != nil {
+		return debuggerLLDB
+	}
+	return debuggerGDB
+}
+
+// cBreakpoints returns the locations of the breakpoints for C/C++
+// findings: the sanitizer report functions and the top stack frame of
+// the finding, which is in user code.
+func cBreakpoints(finding *findingPkg.Finding) []string {
+	breakpoints := append([]string{}, sanitizerReportFunctions...)
+	if len(finding.StackTrace) > 0 && finding.StackTrace[0].SourceFile != "" && finding.StackTrace[0].Line != 0 {
+		frame := finding.StackTrace[0]
+		breakpoints = append(breakpoints, fmt.Sprintf("%s:%d", frame.SourceFile, frame.Line))
+	}
+	return breakpoints
+}
+
+// debuggerArgs returns the arguments of the debugger which set the
+// breakpoints and run the command.
+func debuggerArgs(debugger string, command, breakpoints []string) []string {
+	var args []string
+	switch debugger {
+	case debuggerGDB:
+		// The breakpoints in shared libraries can only be resolved once
+		// the program is running
+		args = append(args, "-q", "-ex", "set breakpoint pending on")
+		for _, b := range breakpoints {
+			args = append(args, "-ex", "break "+b)
+		}
+		args = append(args, "-ex", "run", "--args")
+	case debuggerLLDB:
+		for _, b := range breakpoints {
+			if i := strings.LastIndex(b, ":"); i > 0 && isLineNumber(b[i+1:]) {
+				args = append(args, "-o", fmt.Sprintf("breakpoint set --file %s --line %s", b[:i], b[i+1:]))
+			} else {
+				args = append(args, "-o", "breakpoint set --name "+b)
+			}
+		}
+		args = append(args, "-o", "run", "--")
+	}
+	return append(args, command...)
+}
+
+func isLineNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// jdwpArgs returns the arguments of the java command with the JDWP agent
+// which suspends the JVM until a debugger attaches on the port. JDWP
+// has no authentication and allows executing arbitrary code, so the
+// agent only listens on the loopback interface.
+func jdwpArgs(args []string, port int) []string {
+	agent := fmt.Sprintf("-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=localhost:%d", port)
+	return append([]string{args[0], agent}, args[1:]...)
+}
+
+func launchConfigName(finding *findingPkg.Finding) string {
+	return "cifuzz: debug " + finding.Name
+}
+
+// cppLaunchConfig returns a VS Code launch configuration of the C/C++
+// extension which runs the command in the debugger.
+func cppLaunchConfig(finding *findingPkg.Finding, debugger string, cmd *exec.Cmd, fuzzerEnv, breakpoints []string) map[string]any {
+	// Only the environment variables which are set by cifuzz are added,
+	// VS Code passes its own environment to the fuzz test
+	var environment []map[string]string
+	for _, v := range fuzzerEnv {
+		key, value, _ := strings.Cut(v, "=")
+		environment = append(environment, map[string]string{"name": key, "value": value})
+	}
+	var setupCommands []map[string]any
+	for _, b := range breakpoints {
+		setupCommands = append(setupCommands, map[string]any{
+			"description":    "Break on " + b,
+			"text":           "-break-insert -f " + b,
+			"ignoreFailures": true,
+		})
+	}
+	return map[string]any{
+		"name":          launchConfigName(finding),
+		"type":          "cppdbg",
+		"request":       "launch",
+		"program":       cmd.Args[0],
+		"args":          cmd.Args[1:],
+		"cwd":           cmd.Dir,
+		"environment":   environment,
+		"MIMode":        debugger,
+		"setupCommands": setupCommands,
+	}
+}
+
+// javaLaunchConfig returns a VS Code launch configuration of the Java
+// extension which attaches to the JVM.
+func javaLaunchConfig(finding *findingPkg.Finding, port int) map[string]any {
+	return map[string]any{
+		"name":     launchConfigName(finding),
+		"type":     "java",
+		"request":  "attach",
+		"hostName": "localhost",
+		"port":     port,
+	}
+}
+
+// writeVSCodeLaunchConfig adds the configuration to .vscode/launch.json,
+// replacing an existing configuration with the same name. If the
+// existing file can't be parsed, e.g. because it contains comments, the
+// configuration is printed instead.
+func (c *reproduceCmd) writeVSCodeLaunchConfig(finding *findingPkg.Finding, launchConfig map[string]any) error {
+	launchPath := filepath.Join(c.opts.ProjectDir, ".vscode", "launch.json")
+	exists, err := fileutil.Exists(launchPath)
+	if err != nil {
+		return err
+	}
+
+	launch := map[string]any{"version": "0.2.0"}
+	if exists {
+		content, err := os.ReadFile(launchPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = json.Unmarshal(content, &launch)
+		if err != nil {
+			configJSON, err := json.MarshalIndent(launchConfig, "", "  ")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			log.Printf(`Add the following configuration to .vscode/launch.json to debug
+finding %s in VS Code:
+
+%s
+`, finding.Name, configJSON)
+			return nil
+		}
+	}
+
+	configs, _ := launch["configurations"].([]any)
+	replaced := false
+	for i, existing := range configs {
+		if m, ok := existing.(map[string]any); ok && m["name"] == launchConfig["name"] {
+			configs[i] = launchConfig
+			replaced = true
+		}
+	}
+	if !replaced {
+		configs = append(configs, launchConfig)
+	}
+	launch["configurations"] = configs
+
+	content, err := json.MarshalIndent(launch, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(filepath.Dir(launchPath), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(launchPath, append(content, '\n'), 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Successf("Added the launch configuration %q to .vscode/launch.json", launchConfig["name"])
+	return nil
+}
@@ -0,0 +1,128 @@
+package reproduce
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestValidateDebugOptions(t *testing.T) {
+	opts := &options{Debug: true, Debugger: "lldb", Options: findingutil.Options{BuildSystem: config.BuildSystemCMake}}
+	assert.NoError(t, opts.validateDebugOptions())
+
+	opts = &options{Debug: true, Debugger: "windbg", Options: findingutil.Options{BuildSystem: config.BuildSystemCMake}}
+	assert.Error(t, opts.validateDebugOptions())
+
+	opts = &options{Debug: true, Options: findingutil.Options{BuildSystem: config.BuildSystemNodeJS}}
+	assert.Error(t, opts.validateDebugOptions())
+
+	opts = &options{VSCode: true, Options: findingutil.Options{BuildSystem: config.BuildSystemCMake}}
+	assert.Error(t, opts.validateDebugOptions())
+}
+
+func TestCBreakpoints(t *testing.T) {
+	f := &finding.Finding{
+		StackTrace: []*stacktrace.StackFrame{
+			{SourceFile: "src/explore_me.cpp", Line: 18, Function: "exploreMe"},
+		},
+	}
+	breakpoints := cBreakpoints(f)
+	assert.Equal(t, append(append([]string{}, sanitizerReportFunctions...), "src/explore_me.cpp:18"), breakpoints)
+
+	assert.Equal(t, sanitizerReportFunctions, cBreakpoints(&finding.Finding{}))
+}
+
+func TestDebuggerArgs(t *testing.T) {
+	command := []string{"fuzz_test", "crashing-input"}
+	breakpoints := []string{"__sanitizer::Die", "src/explore_me.cpp:18"}
+
+	args := debuggerArgs(debuggerGDB, command, breakpoints)
+	assert.Equal(t, []string{
+		"-q",
+		"-ex", "set breakpoint pending on",
+		"-ex", "break __sanitizer::Die",
+		"-ex", "break src/explore_me.cpp:18",
+		"-ex", "run",
+		"--args", "fuzz_test", "crashing-input",
+	}, args)
+
+	args = debuggerArgs(debuggerLLDB, command, breakpoints)
+	assert.Equal(t, []string{
+		"-o", "breakpoint set --name __sanitizer::Die",
+		"-o", "breakpoint set --file src/explore_me.cpp --line 18",
+		"-o", "run",
+		"--", "fuzz_test", "crashing-input",
+	}, args)
+}
+
+func TestJDWPArgs(t *testing.T) {
+	args := jdwpArgs([]string{"java", "-cp", "classes", "Main"}, 5005)
+	assert.Equal(t, []string{
+		"java",
+		"-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=localhost:5005",
+		"-cp", "classes", "Main",
+	}, args)
+
+	// The debugger port must only be reachable from the local machine
+	agent := args[1]
+	assert.Contains(t, agent, "address=localhost:5005")
+	assert.NotContains(t, agent, "*:")
+	assert.NotContains(t, agent, "0.0.0.0")
+}
+
+func TestWriteVSCodeLaunchConfig(t *testing.T) {
+	projectDir := t.TempDir()
+	c := &reproduceCmd{opts: &options{Options: findingutil.Options{ProjectDir: projectDir}}}
+	f := &finding.Finding{Name: "focused_turing"}
+	launchPath := filepath.Join(projectDir, ".vscode", "launch.json")
+
+	readConfigs := func() []any {
+		content, err := os.ReadFile(launchPath)
+		require.NoError(t, err)
+		var launch map[string]any
+		err = json.Unmarshal(content, &launch)
+		require.NoError(t, err)
+		return launch["configurations"].([]any)
+	}
+
+	// The file is created if it doesn't exist
+	err := c.writeVSCodeLaunchConfig(f, javaLaunchConfig(f, 5005))
+	require.NoError(t, err)
+	configs := readConfigs()
+	require.Len(t, configs, 1)
+	assert.Equal(t, "cifuzz: debug focused_turing", configs[0].(map[string]any)["name"])
+
+	// A configuration with the same name is replaced, others are kept
+	err = os.WriteFile(launchPath, []byte(`{"version": "0.2.0", "configurations": [{"name": "other"}, {"name": "cifuzz: debug focused_turing", "port": 1}]}`), 0o644)
+	require.NoError(t, err)
+	cmd := &exec.Cmd{Args: []string{"fuzz_test", "crashing-input"}, Dir: projectDir}
+	err = c.writeVSCodeLaunchConfig(f, cppLaunchConfig(f, debuggerGDB, cmd, []string{"ASAN_OPTIONS=detect_leaks=1"}, sanitizerReportFunctions))
+	require.NoError(t, err)
+	configs = readConfigs()
+	require.Len(t, configs, 2)
+	assert.Equal(t, "other", configs[0].(map[string]any)["name"])
+	launchConfig := configs[1].(map[string]any)
+	assert.Equal(t, "cppdbg", launchConfig["type"])
+	assert.Equal(t, "fuzz_test", launchConfig["program"])
+	assert.Equal(t, []any{map[string]any{"name": "ASAN_OPTIONS", "value": "detect_leaks=1"}}, launchConfig["environment"])
+
+	// A file which can't be parsed is not overwritten
+	content := []byte("{\n  // comment\n}\n")
+	err = os.WriteFile(launchPath, content, 0o644)
+	require.NoError(t, err)
+	err = c.writeVSCodeLaunchConfig(f, javaLaunchConfig(f, 5005))
+	require.NoError(t, err)
+	actual, err := os.ReadFile(launchPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, actual)
+}
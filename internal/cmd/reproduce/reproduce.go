@@ -1,66 +1,52 @@
 package reproduce
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 
 	"code-intelligence.com/cifuzz/internal/api"
-	"code-intelligence.com/cifuzz/internal/build"
-	"code-intelligence.com/cifuzz/internal/build/other"
-	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
-	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	findingPkg "code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/runner"
 	"code-intelligence.com/cifuzz/util/envutil"
 )
 
 type options struct {
-	ProjectDir   string `mapstructure:"project-dir"`
-	ConfigDir    string `mapstructure:"config-dir"`
-	Interactive  bool   `mapstructure:"interactive"`
-	Server       string `mapstructure:"server"`
-	Project      string `mapstructure:"project"`
-	BuildSystem  string `mapstructure:"build-system"`
-	BuildCommand string `mapstructure:"build-command"`
-	CleanCommand string `mapstructure:"clean-command"`
-
-	FindingName string
-
-	buildStdout io.Writer
-	buildStderr io.Writer
-}
+	findingutil.Options `mapstructure:",squash"`
 
-func (opts *options) validate() error {
-	var err error
+	Interactive bool   `mapstructure:"interactive"`
+	Server      string `mapstructure:"server"`
+	Project     string `mapstructure:"project"`
 
-	if opts.BuildSystem == "" {
-		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
-		if err != nil {
-			return err
-		}
-	}
+	Debug     bool
+	Debugger  string
+	DebugPort int
+	VSCode    bool
+}
 
-	err = config.ValidateBuildSystem(opts.BuildSystem)
+func (opts *options) validate() error {
+	// Unlike the finding commands, reproducing findings is supported on
+	// Windows as well
+	err := opts.ValidateBuildOptions("Reproducing findings")
 	if err != nil {
 		return err
 	}
 
-	// To build with other build systems, a build command must be provided
-	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
-		msg := "Flag \"build-command\" must be set when using build system type \"other\""
-		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	err = opts.validateDebugOptions()
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
 	}
 
 	return nil
@@ -91,7 +77,25 @@ remote fuzzing server. You can specify this token via the CIFUZZ_API_TOKEN
 environment variable or by running 'cifuzz login' first.
 Remote finding data is downloaded and stored in the local project.
 
-Note that only other build systems are supported for now.
+The fuzz test of the finding is built and run with the crashing input
+of the finding. C/C++ fuzz tests are run directly, Java fuzz tests via
+Jazzer and Node.js fuzz tests via Jazzer.js. Afterwards, it's shown
+whether the crashing input still triggers the same error.
+
+With --debug, the fuzz test is run in a debugger instead:
+
+  * C/C++: gdb or lldb is started with the sanitizer options, the library
+    paths and the crashing input of the finding. Breakpoints are set on
+    the sanitizer report functions and on the top stack frame of the
+    finding.
+  * Java: the JVM is started with JDWP and waits for a debugger to attach
+    on the local port specified via --debug-port.
+
+With --vscode, a launch configuration for the finding is added to
+.vscode/launch.json. For C/C++, VS Code then starts the fuzz test in the
+debugger itself, for Java it attaches to the waiting JVM.
+
+Only other, Maven, Gradle and Node.js build systems are supported.
 `,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: completion.ValidFindings,
@@ -105,8 +109,8 @@ Note that only other build systems are supported for now.
 				return err
 			}
 			opts.FindingName = args[0]
-			opts.buildStdout = cmd.OutOrStdout()
-			opts.buildStderr = cmd.OutOrStderr()
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
 
 			return opts.validate()
 		},
@@ -133,18 +137,24 @@ Note that only other build systems are supported for now.
 		cmdutils.AddCleanCommandFlag,
 		cmdutils.AddBuildJobsFlag,
 	)
+	cmd.Flags().BoolVar(&opts.Debug, "debug", false, "Run the fuzz test with the crashing input in a debugger.")
+	cmd.Flags().StringVar(&opts.Debugger, "debugger", "",
+		"The debugger to use for C/C++ findings, \"gdb\" or \"lldb\" (default: gdb if installed, lldb otherwise).")
+	cmd.Flags().IntVar(&opts.DebugPort, "debug-port", 5005, "The local port on which the JVM waits for a debugger to attach.")
+	cmd.Flags().BoolVar(&opts.VSCode, "vscode", false, "Add a launch configuration for the finding to .vscode/launch.json.")
 
 	return cmd
 }
 
 func (c *reproduceCmd) run() error {
-	adapter, err := adapter.NewAdapter(c.opts.BuildSystem)
+	err := c.opts.CheckDependencies()
 	if err != nil {
 		return err
 	}
-	err = adapter.CheckDependencies(c.opts.ProjectDir)
-	if err != nil {
-		return err
+
+	if c.opts.BuildSystem == config.BuildSystemCMake {
+		msg := fmt.Sprintf("Reproducing findings is not supported for build system %q.", c.opts.BuildSystem)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
 	finding, err := c.loadLocalOrRemoteFinding()
@@ -152,82 +162,73 @@ func (c *reproduceCmd) run() error {
 		return err
 	}
 
-	if c.opts.BuildSystem == config.BuildSystemOther {
-		cBuildResult, err := c.wrapBuild(finding.FuzzTest, c.buildOther)
-		if err != nil {
-			return err
-		}
-
-		var env []string
-		env, err = setSanitizerOptions(env)
-		if err != nil {
-			return err
-		}
-
-		// execute fuzz test binary with input file from finding
-		cmd := exec.Command(cBuildResult.Executable, finding.InputFile)
-		cmd.Dir = c.opts.ProjectDir
-		cmd.Stdout = c.OutOrStdout()
-		cmd.Stderr = c.OutOrStdout()
-		cmd.Env, err = envutil.Copy(os.Environ(), env)
+	if finding.InputFile == "" && len(finding.InputData) > 0 {
+		// Saving the finding writes the input data to a file
+		err = finding.Save(c.opts.ProjectDir)
 		if err != nil {
 			return err
 		}
-		log.Printf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, env))
-		_ = cmd.Run()
-	} else {
-		return errors.New("Only other build systems are supported for now.")
 	}
-
-	return nil
-}
-
-func (c *reproduceCmd) wrapBuild(fuzzTest string, build func(string) (*build.CBuildResult, error)) (*build.CBuildResult, error) {
-	var err error
-	if logging.ShouldLogBuildToFile() {
-		c.opts.buildStdout, err = logging.BuildOutputToFile(c.opts.ProjectDir, []string{fuzzTest})
-		if err != nil {
-			return nil, err
-		}
-		c.opts.buildStderr = c.opts.buildStdout
+	if finding.InputFile == "" {
+		return errors.Errorf("Finding %s has no crashing input", finding.Name)
 	}
 
-	buildPrinter := logging.NewBuildPrinter(os.Stdout, log.BuildInProgressMsg)
-	log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(fuzzTest))
-	cBuildResult, err := build(fuzzTest)
+	buildOpts := c.opts.BuildOptions(c.opts.ProjectDir)
+	buildResult, err := findingutil.Build(buildOpts, finding.FuzzTest)
 	if err != nil {
-		buildPrinter.StopOnError(log.BuildInProgressErrorMsg)
-		return nil, err
+		return err
+	}
+	runner, err := findingutil.NewRunner(buildOpts, finding.FuzzTest, buildResult)
+	if err != nil {
+		return err
+	}
+	if c.opts.Debug {
+		return c.debug(finding, runner)
+	}
+	runner.Output = c.OutOrStdout()
+	runner.PrintCommand = true
+
+	// Run the fuzz test with the input file from the finding. The path
+	// of the input file is relative to the project directory, which is
+	// the working directory of the fuzz test.
+	log.Infof("Running %s with the crashing input of finding %s",
+		pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(finding.FuzzTest), finding.Name)
+	reproduced, _, err := runner.Run(context.Background(), finding.InputFile)
+	if err != nil {
+		return err
 	}
-	buildPrinter.StopOnSuccess(log.BuildInProgressSuccessMsg, true)
 
-	return cBuildResult, nil
+	c.printResult(finding, reproduced)
+	return nil
 }
 
-func (c *reproduceCmd) buildOther(fuzzTest string) (*build.CBuildResult, error) {
-	builder, err := other.NewBuilder(&other.BuilderOptions{
-		ProjectDir:   c.opts.ProjectDir,
-		BuildCommand: c.opts.BuildCommand,
-		CleanCommand: c.opts.CleanCommand,
-		Sanitizers:   []string{"address", "undefined"},
-		Stdout:       c.opts.buildStdout,
-		Stderr:       c.opts.buildStderr,
-	})
-	if err != nil {
-		return nil, err
+// printResult shows whether the finding which was reported when running
+// the crashing input has the same error as the original finding.
+func (c *reproduceCmd) printResult(finding, reproduced *findingPkg.Finding) {
+	if reproduced == nil {
+		log.Successf("Finding %s did not reproduce, the fuzz test didn't report an error", finding.Name)
+		return
 	}
 
-	err = builder.Clean()
-	if err != nil {
-		return nil, err
+	// Remote findings might not have a stack trace, in which case only
+	// the error IDs can be compared
+	compared := reproduced
+	if len(finding.StackTrace) == 0 {
+		withoutStackTrace := *reproduced
+		withoutStackTrace.StackTrace = nil
+		compared = &withoutStackTrace
 	}
+	expected := signature.ForFinding(finding, c.opts.SignatureOpts)
+	actual := signature.ForFinding(compared, c.opts.SignatureOpts)
+	log.Debugf("Signature of finding %s: %q, signature of reproduced finding: %q", finding.Name, expected, actual)
 
-	cBuildResult, err := builder.Build(fuzzTest)
-	if err != nil {
-		return nil, err
+	if expected == "" || expected == actual {
+		log.Warnf("Finding %s reproduced: %s", finding.Name, reproduced.ShortDescription())
+		return
 	}
-
-	return cBuildResult, nil
+	log.Warnf(`The crashing input of finding %s triggers a different error:
+  original:   %s
+  reproduced: %s`, finding.Name, finding.ShortDescription(), reproduced.ShortDescription())
 }
 
 // loadLocalOrRemoteFinding loads the finding if it exists locally, otherwise
@@ -300,7 +301,9 @@ func (c *reproduceCmd) downloadRemoteFinding() (*findingPkg.Finding, error) {
 	return nil, errors.New(fmt.Sprintf("%s not found in CI Sense project: %s", c.opts.FindingName, c.opts.Project))
 }
 
-func setSanitizerOptions(env []string) ([]string, error) {
+// SetSanitizerOptions sets the ASan and UBSan options which are used
+// when reproducing a finding in the environment.
+func SetSanitizerOptions(env []string) ([]string, error) {
 	var err error
 	if os.Getenv("ASAN_OPTIONS") != "" {
 		env, err = envutil.Setenv(env, "ASAN_OPTIONS", os.Getenv("ASAN_OPTIONS"))
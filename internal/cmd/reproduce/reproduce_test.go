@@ -15,6 +15,7 @@ import (
 	"code-intelligence.com/cifuzz/integration-tests/shared"
 	"code-intelligence.com/cifuzz/integration-tests/shared/mockserver"
 	"code-intelligence.com/cifuzz/internal/builder"
+	"code-intelligence.com/cifuzz/internal/cmd/finding/findingutil"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
@@ -34,10 +35,10 @@ func TestReproduceCmdFailsIfNoCIFuzzProject(t *testing.T) {
 	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
 	projectDir := testutil.MkdirTemp(t, "", "reproduce-cmd-test-")
 
-	opts := &options{
+	opts := &options{Options: findingutil.Options{
 		ProjectDir: projectDir,
 		ConfigDir:  projectDir,
-	}
+	}}
 	_, stdErr, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "test_finding")
 	require.Error(t, err)
 	assert.Contains(t, stdErr, "Failed to parse cifuzz.yaml")
@@ -105,13 +106,13 @@ func TestIntegration_ReproduceFailsForWrongBuildSystem(t *testing.T) {
 	err = finding.Save(projectDir)
 	require.NoError(t, err)
 
-	opts := &options{
+	opts := &options{Options: findingutil.Options{
 		ProjectDir: projectDir,
 		ConfigDir:  projectDir,
-	}
+	}}
 	_, stderr, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "test_finding")
 	require.Error(t, err)
-	assert.Contains(t, stderr, "Only other build systems are supported for now.")
+	assert.Contains(t, stderr, `Reproducing findings is not supported for build system "cmake".`)
 }
 
 func TestIntegration_ReproduceLocalFinding(t *testing.T) {
@@ -3,6 +3,7 @@ package adapter
 import (
 	"github.com/pkg/errors"
 
+	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/cmd/run/reporthandler"
 	"code-intelligence.com/cifuzz/internal/config"
 )
@@ -33,3 +34,33 @@ func NewAdapter(buildSystem string) (Adapter, error) {
 	}
 	return adapter, nil
 }
+
+// Build builds the fuzz test in the same way as Run, without running
+// it. It's used by the commands which run the fuzz test of a finding,
+// so that the fuzz test is built with the same flags and sanitizers as
+// by 'cifuzz run'. Node.js fuzz tests don't need to be built, so an
+// empty build result is returned for them.
+func Build(opts *RunOptions) (*build.BuildResult, error) {
+	switch opts.BuildSystem {
+	case config.BuildSystemCMake:
+		cBuildResult, err := wrapBuild[build.CBuildResult](opts, (&CMakeAdapter{}).build)
+		if err != nil {
+			return nil, err
+		}
+		return cBuildResult.BuildResult, nil
+	case config.BuildSystemOther:
+		cBuildResult, err := wrapBuild[build.CBuildResult](opts, (&OtherAdapter{}).build)
+		if err != nil {
+			return nil, err
+		}
+		return cBuildResult.BuildResult, nil
+	case config.BuildSystemMaven:
+		return wrapBuild[build.BuildResult](opts, (&MavenAdapter{}).build)
+	case config.BuildSystemGradle:
+		return wrapBuild[build.BuildResult](opts, (&GradleAdapter{}).build)
+	case config.BuildSystemNodeJS:
+		return &build.BuildResult{}, nil
+	default:
+		return nil, errors.Errorf("Building fuzz tests is not supported for build system \"%s\"", opts.BuildSystem)
+	}
+}
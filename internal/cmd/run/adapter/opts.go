@@ -10,23 +10,27 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding/suppression"
 )
 
 type RunOptions struct {
-	BuildSystem           string        `mapstructure:"build-system"`
-	BuildCommand          string        `mapstructure:"build-command"`
-	CleanCommand          string        `mapstructure:"clean-command"`
-	NumBuildJobs          uint          `mapstructure:"build-jobs"`
-	Dictionary            string        `mapstructure:"dict"`
-	EngineArgs            []string      `mapstructure:"engine-args"`
-	SeedCorpusDirs        []string      `mapstructure:"seed-corpus-dirs"`
-	Timeout               time.Duration `mapstructure:"timeout"`
-	Interactive           bool          `mapstructure:"interactive"`
-	Server                string        `mapstructure:"server"`
-	Project               string        `mapstructure:"project"`
-	UseSandbox            bool          `mapstructure:"use-sandbox"`
-	PrintJSON             bool          `mapstructure:"print-json"`
-	BuildOnly             bool          `mapstructure:"build-only"`
+	cmdutils.DedupOptions `mapstructure:",squash"`
+
+	BuildSystem           string              `mapstructure:"build-system"`
+	BuildCommand          string              `mapstructure:"build-command"`
+	CleanCommand          string              `mapstructure:"clean-command"`
+	NumBuildJobs          uint                `mapstructure:"build-jobs"`
+	Dictionary            string              `mapstructure:"dict"`
+	EngineArgs            []string            `mapstructure:"engine-args"`
+	SeedCorpusDirs        []string            `mapstructure:"seed-corpus-dirs"`
+	Timeout               time.Duration       `mapstructure:"timeout"`
+	Interactive           bool                `mapstructure:"interactive"`
+	Server                string              `mapstructure:"server"`
+	Project               string              `mapstructure:"project"`
+	UseSandbox            bool                `mapstructure:"use-sandbox"`
+	PrintJSON             bool                `mapstructure:"print-json"`
+	BuildOnly             bool                `mapstructure:"build-only"`
+	Suppressions          []*suppression.Rule `mapstructure:"suppressions"`
 	ResolveSourceFilePath bool
 
 	ProjectDir      string
@@ -40,6 +44,10 @@ type RunOptions struct {
 
 	Stdout io.Writer
 	Stderr io.Writer
+
+	// The rules of the suppression file and of the "suppressions"
+	// setting, set by Validate
+	SuppressionRules []*suppression.Rule
 }
 
 func (opts *RunOptions) Validate() error {
@@ -76,6 +84,16 @@ func (opts *RunOptions) Validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	_, err = opts.SignatureOptions()
+	if err != nil {
+		return err
+	}
+
+	opts.SuppressionRules, err = suppression.Load(opts.ProjectDir, opts.Suppressions)
+	if err != nil {
+		return err
+	}
+
 	if opts.Timeout != 0 && opts.Timeout < time.Second {
 		msg := fmt.Sprintf("invalid argument %q for \"--timeout\" flag: timeout can't be less than a second", opts.Timeout)
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
@@ -91,6 +91,11 @@ func createReportHandler(opts *RunOptions, buildResult *build.BuildResult) (*rep
 		jsonOutput = os.Stdout
 	}
 
+	signatureOpts, err := opts.SignatureOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize the report handler. Only do this right before we start
 	// the fuzz test, because this is storing a timestamp which is used
 	// to figure out how long the fuzzing run is running.
@@ -103,6 +108,8 @@ func createReportHandler(opts *RunOptions, buildResult *build.BuildResult) (*rep
 			GeneratedCorpusDir:   buildResult.GeneratedCorpus,
 			PrinterOutput:        printerOutput,
 			JSONOutput:           jsonOutput,
+			SignatureOptions:     signatureOpts,
+			Suppressions:         opts.SuppressionRules,
 		},
 	)
 }
@@ -19,9 +19,12 @@ import (
 	"code-intelligence.com/cifuzz/internal/names"
 	"code-intelligence.com/cifuzz/pkg/desktop"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+	"code-intelligence.com/cifuzz/pkg/finding/suppression"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/vcs"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -34,6 +37,12 @@ type ReportHandlerOptions struct {
 	JSONOutput           io.Writer
 	PrinterOutput        io.Writer
 	SkipSavingFinding    bool
+	// The options for computing the crash signatures which are used to
+	// detect duplicate findings
+	SignatureOptions *signature.Options
+	// Findings which match one of these rules are recorded, but not
+	// notified and not uploaded
+	Suppressions []*suppression.Rule
 }
 
 type ReportHandler struct {
@@ -53,6 +62,16 @@ type ReportHandler struct {
 
 	FuzzTest string
 	Findings []*finding.Finding
+	// Findings which were recorded as occurrences of an existing finding
+	// with the same crash signature. They are not part of Findings, so
+	// that they are neither counted nor uploaded again.
+	duplicates []*finding.Finding
+
+	// The Git revision which is stored in the occurrences of findings.
+	// It's only read once per fuzzing run.
+	gitRevisionRead bool
+	gitCommit       string
+	gitBranch       string
 }
 
 func NewReportHandler(fuzzTest string, options *ReportHandlerOptions) (*ReportHandler, error) {
@@ -137,17 +156,19 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 	}
 
 	if r.Finding != nil {
-		// save finding
-		h.Findings = append(h.Findings, r.Finding)
-
-		if len(h.Findings) == 1 {
+		if len(h.Findings) == 0 && len(h.duplicates) == 0 {
 			h.PrintFindingInstruction()
 		}
 
-		err = h.handleFinding(r.Finding)
+		duplicate, err := h.handleFinding(r.Finding)
 		if err != nil {
 			return err
 		}
+		if duplicate {
+			h.duplicates = append(h.duplicates, r.Finding)
+		} else {
+			h.Findings = append(h.Findings, r.Finding)
+		}
 	}
 
 	if h.JSONOutput != io.Discard && h.usingUpdatingPrinter {
@@ -183,7 +204,9 @@ func (h *ReportHandler) writeJSONReport(r *report.Report) error {
 	return nil
 }
 
-func (h *ReportHandler) handleFinding(f *finding.Finding) error {
+// handleFinding saves the finding and reports it. It returns true if
+// the finding was recorded as a duplicate of an existing finding.
+func (h *ReportHandler) handleFinding(f *finding.Finding) (bool, error) {
 	var err error
 
 	f.CreatedAt = time.Now()
@@ -213,40 +236,158 @@ func (h *ReportHandler) handleFinding(f *finding.Finding) error {
 	// produce a distinct new finding in that case.
 	nameSeed := append(stacktrace.EncodeStackTrace(f.StackTrace), f.InputData...)
 	f.Name = names.GetDeterministicName(nameSeed)
+	f.FuzzTest = h.FuzzTest
 
-	if f.InputFile != "" && !h.SkipSavingFinding {
-		if h.ManagedSeedCorpusDir == "" {
+	// Findings which match a suppression rule are recorded like any
+	// other finding, but they are marked as suppressed, so that they
+	// are neither notified nor uploaded.
+	suppression.Apply(h.Suppressions, f, f.CreatedAt)
+
+	// Findings which are caused by the same bug but were reached via a
+	// different input or call path get different names. To avoid
+	// near-duplicate findings, they are clustered by their crash
+	// signature: if a finding with the same signature already exists,
+	// the crash is recorded as an occurrence of that finding instead.
+	// Note that this also applies to the scenario described above, in
+	// which a fixed bug is found again via a different input.
+	f.Signature = signature.ForFinding(f, h.SignatureOptions)
+
+	var existing *finding.Finding
+	if !h.SkipSavingFinding {
+		if f.InputFile != "" && h.ManagedSeedCorpusDir == "" {
 			// Handle the case that the seed corpus directory was not set. In
 			// the case of Java fuzz tests, the seed corpus directory is
 			// printed by Jazzer. We parse that output and send it to the
 			// report handler via a report with an empty finding. If we did
 			// not receive that report yet, we cannot copy the input file to
 			// the seed corpus directory.
-			return errors.New("finding before seed corpus directory was set")
+			return false, errors.New("finding before seed corpus directory was set")
 		}
-		err = f.CopyInputFileAndUpdateFinding(h.ProjectDir, h.ManagedSeedCorpusDir)
+
+		// Look up the existing finding and save the new one while
+		// holding the lock, so that concurrent runs which find the same
+		// bug don't both create a new finding
+		err = finding.WithSignatureLock(h.ProjectDir, func() error {
+			existing, err = finding.FindingWithSignature(h.ProjectDir, f.Signature)
+			if err != nil {
+				return err
+			}
+			if existing != nil && existing.Name != f.Name {
+				return h.saveDuplicate(f, existing)
+			}
+			existing = nil
+			return h.saveFinding(f)
+		})
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
-	f.FuzzTest = h.FuzzTest
+	if existing != nil {
+		h.reportDuplicate(f, existing)
+		return true, nil
+	}
 
-	// Do not mutate f after this call.
-	if !h.SkipSavingFinding {
-		err = f.Save(h.ProjectDir)
+	if f.Suppressed {
+		log.Infof("Suppressed finding %s (%s)", f.ShortDescriptionWithName(), f.SuppressionReason)
+		return false, nil
+	}
+
+	log.Finding(f.ShortDescriptionWithName())
+
+	desktop.Notify("cifuzz finding", f.ShortDescriptionWithName())
+
+	return false, nil
+}
+
+// saveFinding copies the input file of the finding to the finding
+// directory and the seed corpus and saves the finding.
+func (h *ReportHandler) saveFinding(f *finding.Finding) error {
+	if f.InputFile != "" {
+		err := f.CopyInputFileAndUpdateFinding(h.ProjectDir, h.ManagedSeedCorpusDir)
 		if err != nil {
 			return err
 		}
 	}
 
-	log.Finding(f.ShortDescriptionWithName())
+	// Do not mutate f after this call.
+	occurrence := h.newOccurrence(f)
+	occurrence.InputFile = f.InputFile
+	f.AddOccurrence(occurrence)
+	return f.Save(h.ProjectDir)
+}
 
-	desktop.Notify("cifuzz finding", f.ShortDescriptionWithName())
+// saveDuplicate records the finding as an occurrence of the existing
+// finding which has the same crash signature. The input file is still
+// added to the seed corpus, like the inputs of all other findings.
+func (h *ReportHandler) saveDuplicate(f *finding.Finding, existing *finding.Finding) error {
+	if f.InputFile != "" {
+		err := f.CopyInputFileToSeedCorpus(h.ManagedSeedCorpusDir)
+		if err != nil {
+			return err
+		}
+	}
+	err := existing.AddDuplicate(h.ProjectDir, f, h.newOccurrence(f))
+	if err != nil {
+		return err
+	}
+	log.Debugf("Finding %s has the same crash signature as %s: %s", f.Name, existing.Name, f.Signature)
 
+	// Report the crash under the name of the existing finding, so that
+	// it can be looked up via 'cifuzz finding'
+	f.Name = existing.Name
 	return nil
 }
 
+func (h *ReportHandler) reportDuplicate(f *finding.Finding, existing *finding.Finding) {
+	if f.Suppressed {
+		log.Infof("Suppressed finding %s (duplicate, seen %d times) (%s)",
+			f.ShortDescriptionWithName(), existing.Count(), f.SuppressionReason)
+		return
+	}
+	log.Finding(fmt.Sprintf("%s (duplicate, seen %d times)", f.ShortDescriptionWithName(), existing.Count()))
+}
+
+// UnsuppressedFindings returns the findings of the run which don't match
+// a suppression rule.
+func (h *ReportHandler) UnsuppressedFindings() []*finding.Finding {
+	var findings []*finding.Finding
+	for _, f := range h.Findings {
+		if !f.Suppressed {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// newOccurrence returns an occurrence of the finding with the current
+// Git revision of the project.
+func (h *ReportHandler) newOccurrence(f *finding.Finding) *finding.Occurrence {
+	if !h.gitRevisionRead {
+		h.gitRevisionRead = true
+		var err error
+		h.gitCommit, err = vcs.GitCommit()
+		if err != nil {
+			// The project is not a Git repository or git is not
+			// installed, so we store the occurrence without revision
+			log.Debugf("Failed to get Git commit: %v", err)
+		} else {
+			h.gitBranch, err = vcs.GitBranch()
+			if err != nil {
+				log.Debugf("Failed to get Git branch: %v", err)
+			}
+		}
+	}
+
+	return &finding.Occurrence{
+		Name:      f.Name,
+		FuzzTest:  f.FuzzTest,
+		CreatedAt: f.CreatedAt,
+		GitCommit: h.gitCommit,
+		GitBranch: h.gitBranch,
+	}
+}
+
 func (h *ReportHandler) PrintFindingInstruction() {
 	log.Note(`
 Use 'cifuzz finding <finding name>' for details on a finding.
@@ -257,9 +398,11 @@ Use 'cifuzz finding <finding name>' for details on a finding.
 func (h *ReportHandler) PrintCrashingInputNote() {
 	var crashingInputs []string
 
-	for _, f := range h.Findings {
-		if f.GetSeedPath() != "" {
-			crashingInputs = append(crashingInputs, fileutil.PrettifyPath(f.GetSeedPath()))
+	for _, findings := range [][]*finding.Finding{h.Findings, h.duplicates} {
+		for _, f := range findings {
+			if f.GetSeedPath() != "" {
+				crashingInputs = append(crashingInputs, fileutil.PrettifyPath(f.GetSeedPath()))
+			}
 		}
 	}
 
@@ -347,10 +490,19 @@ func (h *ReportHandler) PrintFinalMetrics() error {
 	// runs show "Ran for 0s".
 	durationStr := (duration.Truncate(time.Second) + time.Second).String()
 
+	numFindings := len(h.UnsuppressedFindings())
+	findingsStr := metrics.NumberString("%d", numFindings)
+	if numSuppressed := len(h.Findings) - numFindings; numSuppressed > 0 {
+		findingsStr += metrics.DescString(" (%s suppressed)", metrics.NumberString("%d", numSuppressed))
+	}
+	if numDuplicates := len(h.duplicates); numDuplicates > 0 {
+		findingsStr += metrics.DescString(" (%s duplicates)", metrics.NumberString("%d", numDuplicates))
+	}
+
 	lines := []string{
 		metrics.DescString("Execution time:\t") + metrics.NumberString(durationStr),
 		metrics.DescString("Average exec/s:\t") + averageExecsStr,
-		metrics.DescString("Findings:\t") + metrics.NumberString("%d", len(h.Findings)),
+		metrics.DescString("Findings:\t") + findingsStr,
 		metrics.DescString("Corpus entries:\t") + metrics.NumberString("%d", numCorpusEntries) +
 			metrics.DescString(" (+%s)", metrics.NumberString("%d", newCorpusEntries)),
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 	"code-intelligence.com/cifuzz/internal/cmd/run/reporthandler/metrics"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/finding/suppression"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/pkg/report"
 )
 
@@ -172,6 +175,79 @@ func TestReportHandler_GenerateName(t *testing.T) {
 	assert.Equal(t, "adventurous_pangolin", findingReport.Finding.Name)
 }
 
+func TestReportHandler_Duplicate(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	h, err := NewReportHandler("my_fuzz_test", &ReportHandlerOptions{ProjectDir: testDir, ManagedSeedCorpusDir: "seed_corpus"})
+	require.NoError(t, err)
+
+	newFinding := func(input string, line uint32) *finding.Finding {
+		return &finding.Finding{
+			Details:     "heap-buffer-overflow on address 0x602000000e31",
+			MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+			InputData:   []byte(input),
+			StackTrace: []*stacktrace.StackFrame{
+				{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: line},
+			},
+		}
+	}
+
+	first := newFinding("first", 10)
+	err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: first})
+	require.NoError(t, err)
+
+	// A finding with the same crash signature is recorded as an
+	// occurrence of the first finding
+	duplicate := newFinding("second", 11)
+	err = os.WriteFile("crash-second", []byte("second"), 0o644)
+	require.NoError(t, err)
+	duplicate.InputFile = "crash-second"
+	err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: duplicate})
+	require.NoError(t, err)
+	assert.Equal(t, first.Name, duplicate.Name)
+	assert.Len(t, findingDirs(t, testDir), 1)
+
+	// The duplicate is neither counted nor uploaded again, but its
+	// input is added to the seed corpus
+	assert.Equal(t, []*finding.Finding{first}, h.Findings)
+	assert.Equal(t, []*finding.Finding{first}, h.UnsuppressedFindings())
+	require.NotEmpty(t, duplicate.GetSeedPath())
+	seed, err := os.ReadFile(duplicate.GetSeedPath())
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(seed))
+	f, err := finding.FindingWithSignature(testDir, first.Signature)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	assert.Equal(t, first.Name, f.Name)
+	require.Len(t, f.Occurrences, 2)
+	assert.Equal(t, first.Name, f.Occurrences[0].Name)
+	occurrence := f.Occurrences[1]
+	assert.NotEqual(t, first.Name, occurrence.Name)
+	assert.Equal(t, "my_fuzz_test", occurrence.FuzzTest)
+	input, err := os.ReadFile(filepath.Join(testDir, occurrence.InputFile))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(input))
+
+	// A finding with a different signature is a new finding
+	other := newFinding("third", 10)
+	other.MoreDetails.ID = "heap_use_after_free"
+	err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: other})
+	require.NoError(t, err)
+	assert.Len(t, findingDirs(t, testDir), 2)
+	assert.Equal(t, []*finding.Finding{first, other}, h.Findings)
+}
+
+func findingDirs(t *testing.T, projectDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(projectDir, ".cifuzz-findings"))
+	require.NoError(t, err)
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	return dirs
+}
+
 func checkOutput(t *testing.T, r io.Reader, s ...string) {
 	output, err := io.ReadAll(r)
 	require.NoError(t, err)
@@ -179,3 +255,48 @@ func checkOutput(t *testing.T, r io.Reader, s ...string) {
 		require.Contains(t, string(output), str)
 	}
 }
+
+func TestReportHandler_Suppressed(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	err := os.WriteFile(filepath.Join(testDir, suppression.FileName), []byte(`
+- file: third_party/**
+  reason: Third-party code
+  expires: 2999-12-31
+`), 0o644)
+	require.NoError(t, err)
+	suppressions, err := suppression.Load(testDir, nil)
+	require.NoError(t, err)
+	h, err := NewReportHandler("my_fuzz_test", &ReportHandlerOptions{ProjectDir: testDir, Suppressions: suppressions})
+	require.NoError(t, err)
+
+	suppressed := &finding.Finding{
+		Details:   "heap-buffer-overflow on address 0x602000000e31",
+		InputData: []byte("suppressed"),
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "third_party/lib/parser.c", Line: 10},
+			{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 20},
+		},
+	}
+	err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: suppressed})
+	require.NoError(t, err)
+	checkOutput(t, logOutput, "Suppressed finding", "(Third-party code)")
+
+	reported := &finding.Finding{
+		Details:   "heap-use-after-free on address 0x602000000e31",
+		InputData: []byte("reported"),
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 20},
+		},
+	}
+	err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: reported})
+	require.NoError(t, err)
+
+	// Suppressed findings are recorded, but marked as suppressed
+	f, err := finding.FindingWithSignature(testDir, suppressed.Signature)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	assert.True(t, f.Suppressed)
+	assert.Equal(t, "Third-party code", f.SuppressionReason)
+	assert.Equal(t, []*finding.Finding{reported}, h.UnsuppressedFindings())
+	assert.Len(t, h.Findings, 2)
+}
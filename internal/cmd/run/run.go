@@ -324,8 +324,9 @@ func (c *runCmd) run() error {
 			return nil
 		}
 
-		// check if there are findings that should be uploaded
-		if token != "" && len(c.reportHandler.Findings) > 0 {
+		// check if there are findings that should be uploaded,
+		// suppressed findings are not uploaded
+		if token != "" && len(c.reportHandler.UnsuppressedFindings()) > 0 {
 			err = c.uploadFindings(c.getFuzzTestNameForCampaignRun(), c.opts.BuildSystem, c.reportHandler.FirstMetrics, c.reportHandler.LastMetrics, token)
 			if err != nil {
 				return err
@@ -386,7 +387,8 @@ Findings have *not* been uploaded. Please check the 'project' entry in your cifu
 	}
 
 	// upload findings
-	for _, finding := range c.reportHandler.Findings {
+	findings := c.reportHandler.UnsuppressedFindings()
+	for _, finding := range findings {
 		err = finding.EnhanceWithErrorDetails()
 		if err != nil {
 			return err
@@ -401,7 +403,7 @@ Findings have *not* been uploaded. Please check the 'project' entry in your cifu
 			return errors.WithMessage(err, fmt.Sprintf("Failed to remove finding %s", finding.Name))
 		}
 	}
-	log.Notef("Uploaded %d findings to CI Sense at: %s", len(c.reportHandler.Findings), c.opts.Server)
+	log.Notef("Uploaded %d findings to CI Sense at: %s", len(findings), c.opts.Server)
 	log.Infof("You can view the findings at %s/app/%s/findings?origin=cli", c.opts.Server, campaignRunName)
 
 	return nil
@@ -0,0 +1,23 @@
+package cmdutils
+
+import (
+	"code-intelligence.com/cifuzz/pkg/finding/signature"
+)
+
+// DedupOptions are the options which configure the crash signatures
+// that are used to deduplicate findings. Commands embed them in their
+// options with `mapstructure:",squash"`.
+type DedupOptions struct {
+	DedupFrames         int      `mapstructure:"dedup-frames"`
+	DedupIgnorePatterns []string `mapstructure:"dedup-ignore-patterns"`
+}
+
+// SignatureOptions returns the crash signature options. Invalid options
+// are reported as an incorrect usage error.
+func (opts *DedupOptions) SignatureOptions() (*signature.Options, error) {
+	signatureOpts, err := signature.NewOptions(opts.DedupFrames, opts.DedupIgnorePatterns)
+	if err != nil {
+		return nil, WrapIncorrectUsageError(err)
+	}
+	return signatureOpts, nil
+}
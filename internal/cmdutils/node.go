@@ -15,6 +15,7 @@ import (
 	"code-intelligence.com/cifuzz/pkg/options"
 	"code-intelligence.com/cifuzz/util/envutil"
 	"code-intelligence.com/cifuzz/util/regexutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
 )
 
 func ListNodeFuzzTestsByRegex(projectDir string, prefixFilter string) ([]string, error) {
@@ -55,6 +56,63 @@ func ListNodeFuzzTestsByRegex(projectDir string, prefixFilter string) ([]string,
 	return fuzzTests, nil
 }
 
+// NodeFuzzTest is a fuzz test defined in a Jazzer.js fuzz test file.
+type NodeFuzzTest struct {
+	// The path of the fuzz test file
+	File string
+	// The name of the fuzz test, as passed to it.fuzz
+	Name string
+	// The identifier which is used to select the fuzz test on the
+	// command line, the base name of the fuzz test file without the
+	// ".fuzz.js" or ".fuzz.ts" suffix, followed by the quoted name of
+	// the fuzz test if the file contains more than one fuzz test.
+	Identifier string
+}
+
+// ListNodeFuzzTests returns all fuzz tests in the fuzz test files of the
+// project. Files in node_modules are ignored.
+func ListNodeFuzzTests(projectDir string) ([]*NodeFuzzTest, error) {
+	// use zglob to support globbing in windows
+	fuzzTestFiles, err := zglob.Glob(filepath.Join(projectDir, "**", "*.fuzz.*"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var fuzzTests []*NodeFuzzTest
+	for _, testFile := range fuzzTestFiles {
+		if !strings.HasSuffix(testFile, ".fuzz.js") && !strings.HasSuffix(testFile, ".fuzz.ts") {
+			continue
+		}
+		relPath, err := filepath.Rel(projectDir, testFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if sliceutil.Contains(strings.Split(filepath.ToSlash(relPath), "/"), "node_modules") {
+			continue
+		}
+
+		methods, err := getTargetMethodsFromNodeTestFile(testFile)
+		if err != nil {
+			return nil, err
+		}
+
+		baseName := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(testFile), ".fuzz.js"), ".fuzz.ts")
+		for _, method := range methods {
+			identifier := baseName
+			if len(methods) > 1 {
+				identifier += ":" + fmt.Sprintf("%q", method)
+			}
+			fuzzTests = append(fuzzTests, &NodeFuzzTest{
+				File:       testFile,
+				Name:       method,
+				Identifier: identifier,
+			})
+		}
+	}
+
+	return fuzzTests, nil
+}
+
 func ValidateNodeFuzzTest(projectDir string, testPathPattern string, testNamePattern string) error {
 	var env []string
 	// enable "list fuzz tests" mode for jazzer.js
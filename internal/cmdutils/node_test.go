@@ -5,10 +5,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/otiai10/copy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"code-intelligence.com/cifuzz/integration-tests/shared"
+	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
@@ -49,3 +51,21 @@ func TestIntegration_ValidateNodeFuzzTest(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Multiple fuzz tests found")
 }
+
+func TestListNodeFuzzTests(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "list-node-fuzz-tests-")
+	err := copy.Copy(filepath.Join("testdata", "node"), projectDir)
+	require.NoError(t, err)
+	// Fuzz tests of dependencies are ignored
+	err = copy.Copy(filepath.Join("testdata", "node", "FuzzTestCase.fuzz.js"),
+		filepath.Join(projectDir, "node_modules", "dep", "Dep.fuzz.js"))
+	require.NoError(t, err)
+
+	fuzzTests, err := ListNodeFuzzTests(projectDir)
+	require.NoError(t, err)
+	require.Len(t, fuzzTests, 2)
+	assert.Equal(t, filepath.Join(projectDir, "FuzzTestCase.fuzz.js"), fuzzTests[0].File)
+	assert.Equal(t, "My fuzz test", fuzzTests[0].Name)
+	assert.Equal(t, `FuzzTestCase:"My fuzz test"`, fuzzTests[0].Identifier)
+	assert.Equal(t, `FuzzTestCase:"My other fuzz test"`, fuzzTests[1].Identifier)
+}
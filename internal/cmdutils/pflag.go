@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/pkg/cicheck"
 )
 
 var BundleFlags = []string{
@@ -116,6 +118,24 @@ func AddCommitFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddCompressionFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("compression", "gzip",
+		"The compression `format` of the bundle: gzip, zstd or none.\n"+
+			"zstd is considerably faster than gzip for large bundles.")
+	return func() {
+		ViperMustBindPFlag("compression", cmd.Flags().Lookup("compression"))
+	}
+}
+
+func AddDetachedSignatureFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("detached-signature", "",
+		"Write the signature of the bundle manifest to this `file` instead of\n"+
+			"storing it in the bundle. Requires --signing-key.")
+	return func() {
+		ViperMustBindPFlag("detached-signature", cmd.Flags().Lookup("detached-signature"))
+	}
+}
+
 func AddDictFlag(cmd *cobra.Command) func() {
 	// TODO(afl): Also link to https://github.com/AFLplusplus/AFLplusplus/blob/stable/dictionaries/README.md
 	cmd.Flags().String("dict", "",
@@ -184,6 +204,15 @@ func AddMinFindingSeverityFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddIncludeSourcesFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("include-sources", false,
+		"Include the source files of the project in the bundle, so that stack traces and coverage\n"+
+			"reports of remote runs can be resolved against them.")
+	return func() {
+		ViperMustBindPFlag("include-sources", cmd.Flags().Lookup("include-sources"))
+	}
+}
+
 func AddInteractiveFlag(cmd *cobra.Command) func() {
 	cmd.Flags().Bool("interactive", true, "Toggle interactive prompting in the terminal")
 	return func() {
@@ -235,6 +264,13 @@ func AddPrintJSONFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddPrintSARIFFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("sarif", false, "Print output as SARIF")
+	return func() {
+		ViperMustBindPFlag("print-sarif", cmd.Flags().Lookup("sarif"))
+	}
+}
+
 func AddProjectDirFlag(cmd *cobra.Command) func() {
 	cmd.Flags().String("project-dir", "",
 		"The project root which is the parent for all the project sources.\n"+
@@ -252,6 +288,17 @@ e.g. ghcr.io/my-org/my-project`)
 	}
 }
 
+func AddReproducibleFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("reproducible", cicheck.IsCIEnvironment(),
+		"Create a byte-identical bundle for identical inputs by sorting the archive entries\n"+
+			"and normalizing timestamps, owners and file modes. Timestamps are set to the value\n"+
+			"of the SOURCE_DATE_EPOCH environment variable if it's set.\n"+
+			"Enabled by default in CI environments.")
+	return func() {
+		ViperMustBindPFlag("reproducible", cmd.Flags().Lookup("reproducible"))
+	}
+}
+
 func AddResolveSourceFileFlag(cmd *cobra.Command) func() {
 	cmd.Flags().BoolP("resolve", "r", false,
 		"Argument of the command is a path to a source file instead of a test identifier.\n"+
@@ -272,6 +319,14 @@ e.g. "my-project-c170bc17".`)
 	}
 }
 
+func AddSBOMOutputFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("sbom-output", "",
+		"Also write the CycloneDX SBOM contained in the bundle to this `file`.")
+	return func() {
+		ViperMustBindPFlag("sbom-output", cmd.Flags().Lookup("sbom-output"))
+	}
+}
+
 func AddSeedCorpusFlag(cmd *cobra.Command) func() {
 	// TODO(afl): Also link to https://aflplus.plus/docs/fuzzing_in_depth/#a-collecting-inputs
 	cmd.Flags().StringArrayP("seed-corpus", "s", nil,
@@ -284,6 +339,15 @@ func AddSeedCorpusFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddSigningKeyFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("signing-key", "",
+		"Sign the checksum manifest of the bundle with the ed25519 private key in this `file`\n"+
+			"(PEM encoded PKCS #8, as created by 'openssl genpkey -algorithm ed25519').")
+	return func() {
+		ViperMustBindPFlag("signing-key", cmd.Flags().Lookup("signing-key"))
+	}
+}
+
 func AddServerFlag(cmd *cobra.Command) func() {
 	cmd.PersistentFlags().String("server", "https://app.code-intelligence.com", "Address of CI Sense")
 	return func() {
@@ -164,6 +164,89 @@ func resolve(path, buildSystem, projectDir string) (string, error) {
 	}
 }
 
+// SourceFile determines the source file of a fuzz test, which is the
+// inverse of resolve. The returned path is absolute.
+func SourceFile(fuzzTest, buildSystem, projectDir string) (string, error) {
+	switch buildSystem {
+	case config.BuildSystemCMake:
+		cmakeLists, err := findAllCMakeLists(projectDir)
+		if err != nil {
+			return "", err
+		}
+
+		for _, list := range cmakeLists {
+			var bs []byte
+			bs, err = os.ReadFile(filepath.Join(projectDir, list))
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+
+			matches, _ := regexutil.FindAllNamedGroupsMatches(cmakeFuzzTestFileNamePattern, string(bs))
+			for _, match := range matches {
+				if match["fuzzTest"] == fuzzTest {
+					return filepath.Join(projectDir, filepath.Dir(list), match["file"]), nil
+				}
+			}
+		}
+		return "", errors.Errorf("no source file found for fuzz test %s", fuzzTest)
+
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		var testDirs []string
+		var err error
+		if buildSystem == config.BuildSystemMaven {
+			testDirs, err = maven.GetTestDirs(projectDir)
+		} else {
+			testDirs, err = gradle.GetTestSourceSets(projectDir)
+		}
+		if err != nil {
+			return "", err
+		}
+		return jvmSourceFile(fuzzTest, testDirs)
+
+	case config.BuildSystemNodeJS:
+		fuzzTests, err := cmdutils.ListNodeFuzzTests(projectDir)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range fuzzTests {
+			identifier, _, _ := strings.Cut(t.Identifier, ":")
+			if identifier == fuzzTest {
+				return t.File, nil
+			}
+		}
+		return "", errors.Errorf("no source file found for fuzz test %s", fuzzTest)
+
+	default:
+		return "", errors.Errorf("Finding the source file of a fuzz test is not supported for build system %q", buildSystem)
+	}
+}
+
+// jvmSourceFile returns the Java or Kotlin source file of the class of
+// the JVM fuzz test in one of the test directories.
+func jvmSourceFile(fuzzTest string, testDirs []string) (string, error) {
+	className, _, _ := strings.Cut(fuzzTest, "::")
+	classPath := filepath.Join(strings.Split(className, ".")...)
+
+	for _, testDir := range testDirs {
+		// The test directories returned by Gradle can be the parent
+		// directories of the language specific directories, see
+		// cmdutils.ConstructJVMFuzzTestIdentifier
+		for _, dir := range []string{testDir, filepath.Join(testDir, "java"), filepath.Join(testDir, "kotlin")} {
+			for _, ext := range []string{".java", ".kt"} {
+				path := filepath.Join(dir, classPath+ext)
+				exists, err := fileutil.Exists(path)
+				if err != nil {
+					return "", err
+				}
+				if exists {
+					return path, nil
+				}
+			}
+		}
+	}
+	return "", errors.Errorf("no source file found for fuzz test %s", fuzzTest)
+}
+
 func findAllCMakeLists(projectDir string) ([]string, error) {
 	var cmakeLists []string
 
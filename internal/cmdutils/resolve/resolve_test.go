@@ -11,6 +11,7 @@ import (
 
 	"code-intelligence.com/cifuzz/integration-tests/shared"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
 )
 
 func TestResolve(t *testing.T) {
@@ -224,3 +225,46 @@ func testResolveNodeJS(t *testing.T, pwd string) {
 	require.NoError(t, err)
 	assert.Equal(t, fuzzTestName, resolved)
 }
+
+func TestSourceFile(t *testing.T) {
+	// The test data is only read, so it's used in place. This also
+	// avoids depending on the working directory, which TestResolve
+	// changes.
+	testDataDir := filepath.Join(testutil.RepoRoot(t), "internal", "cmdutils", "resolve", "testdata")
+
+	t.Run("CMake", func(t *testing.T) {
+		projectDir := filepath.Join(testDataDir, "cmake")
+
+		// fuzz test is declared in CMakeLists in same directory
+		path, err := SourceFile("fuzz_test_1", config.BuildSystemCMake, projectDir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(projectDir, "src", "fuzz_test_1", "fuzz_test.cpp"), path)
+
+		// fuzz test is declared in CMakeLists in parent directory
+		path, err = SourceFile("fuzz_test_2", config.BuildSystemCMake, projectDir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(projectDir, "src", "fuzz_test_2", "fuzz_test.cpp"), path)
+
+		_, err = SourceFile("fuzz_test_3", config.BuildSystemCMake, projectDir)
+		assert.Error(t, err)
+	})
+
+	t.Run("JVM", func(t *testing.T) {
+		testDir := filepath.Join(testDataDir, "gradle", "src", "test")
+
+		path, err := jvmSourceFile("com.example.fuzz_test_1.FuzzTestCase::myFuzzTest", []string{testDir})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(testDir, "java", "com", "example", "fuzz_test_1", "FuzzTestCase.java"), path)
+
+		_, err = jvmSourceFile("com.example.Missing", []string{testDir})
+		assert.Error(t, err)
+	})
+
+	t.Run("NodeJS", func(t *testing.T) {
+		projectDir := filepath.Join(testDataDir, "nodejs")
+
+		path, err := SourceFile("FuzzTestCase", config.BuildSystemNodeJS, projectDir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(projectDir, "src", "test", "FuzzTestCase.fuzz.js"), path)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/mattn/go-zglob"
+	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"golang.org/x/text/cases"
@@ -149,7 +150,12 @@ func ParseProjectConfig(configDir string, opts interface{}) error {
 		}
 	}
 
-	err = viper.Unmarshal(opts)
+	err = viper.Unmarshal(opts, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		// The default hooks of viper
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		dateToStringHook,
+	)))
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -174,6 +180,16 @@ func ParseProjectConfig(configDir string, opts interface{}) error {
 	return nil
 }
 
+// dateToStringHook converts dates, which are parsed as time.Time from
+// unquoted YAML values like 2024-12-31, to strings in the same format,
+// e.g. for the expiry dates of suppression rules.
+func dateToStringHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if t, ok := data.(time.Time); ok && to.Kind() == reflect.String {
+		return t.Format(time.DateOnly), nil
+	}
+	return data, nil
+}
+
 func ValidateBuildSystem(buildSystem string) error {
 	if os.Getenv(AllowUnsupportedPlatformsEnv) != "" {
 		log.Infof("%s is set. Be aware that this skips all OS/build system checks and can cause unforeseen results.", AllowUnsupportedPlatformsEnv)
@@ -173,6 +173,30 @@ func TestParseProjectConfigCMake(t *testing.T) {
 	require.Equal(t, BuildSystemCMake, opts.BuildSystem)
 }
 
+func TestParseProjectConfig_Dates(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	opts := &struct {
+		BuildSystem string `mapstructure:"build-system"`
+		Rules       []struct {
+			Expires string `mapstructure:"expires"`
+		} `mapstructure:"rules"`
+	}{}
+
+	configFile := filepath.Join(projectDir, ProjectConfigFile)
+	err = os.WriteFile(configFile, []byte("build-system: other\nrules:\n  - expires: 2024-12-31\n  - expires: \"2025-01-31\"\n"), 0o644)
+	require.NoError(t, err)
+
+	// Unquoted dates are decoded as strings in the same format
+	err = ParseProjectConfig(projectDir, opts)
+	require.NoError(t, err)
+	require.Len(t, opts.Rules, 2)
+	require.Equal(t, "2024-12-31", opts.Rules[0].Expires)
+	require.Equal(t, "2025-01-31", opts.Rules[1].Expires)
+}
+
 func TestDetermineBuildSystem_CMake(t *testing.T) {
 	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
 	require.NoError(t, err)
@@ -0,0 +1,308 @@
+package blockers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type Options struct {
+	// MinFileCoverage is the line coverage (in percent) a file must
+	// have for its uncovered functions to be reported
+	MinFileCoverage float64
+	// SnippetLines is the number of source lines shown before and
+	// after the line of a blocker
+	SnippetLines int
+	// MaxResults limits the number of reported functions and branches
+	MaxResults int
+}
+
+var DefaultOptions = Options{
+	MinFileCoverage: 50,
+	SnippetLines:    2,
+	MaxResults:      20,
+}
+
+// Report lists the places in the code at which fuzzing most likely
+// stalls.
+type Report struct {
+	UncoveredFunctions []*UncoveredFunction
+	PartialBranches    []*PartialBranch
+}
+
+// UncoveredFunction is a function which was never entered, although
+// other code in the same file is well covered.
+type UncoveredFunction struct {
+	File         string
+	Name         string
+	Line         int
+	FileCoverage float64
+	Snippet      []SnippetLine
+}
+
+// PartialBranch is a line with branches of which only some were taken.
+type PartialBranch struct {
+	File          string
+	Line          int
+	BranchesFound int
+	BranchesHit   int
+	// BlockedLines is the number of instrumented lines after the
+	// branch which were never executed. It's used to estimate how much
+	// code sits behind the branches which were not taken.
+	BlockedLines int
+	Snippet      []SnippetLine
+}
+
+type SnippetLine struct {
+	Number     int
+	Source     string
+	Executions int
+	// Instrumented is false for lines without coverage information,
+	// like comments or empty lines
+	Instrumented bool
+}
+
+// Analyze searches the LCOV report for functions which are never
+// entered although they sit in files with high coverage, and for
+// partially taken branches, ranked by the amount of uncovered code
+// behind them. Relative source file names are resolved against
+// sourceDir to read the source snippets.
+func Analyze(report *coverage.LCOVReport, sourceDir string, opts *Options) *Report {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	result := &Report{}
+
+	for _, sf := range report.SourceFiles {
+		executions := map[int]int{}
+		for _, l := range sf.LineInformation {
+			executions[l.Number] += l.Executions
+		}
+		var source []string
+		readSource := func() []string {
+			if source == nil {
+				source = readSourceLines(sf.Name, sourceDir)
+			}
+			return source
+		}
+
+		functionStarts := uncoveredFunctions(sf, executions, readSource, opts, result)
+		partialBranches(sf, executions, functionStarts, readSource, opts, result)
+	}
+
+	sort.SliceStable(result.UncoveredFunctions, func(i, j int) bool {
+		return result.UncoveredFunctions[i].FileCoverage > result.UncoveredFunctions[j].FileCoverage
+	})
+	sort.SliceStable(result.PartialBranches, func(i, j int) bool {
+		return result.PartialBranches[i].BlockedLines > result.PartialBranches[j].BlockedLines
+	})
+	if opts.MaxResults > 0 {
+		if len(result.UncoveredFunctions) > opts.MaxResults {
+			result.UncoveredFunctions = result.UncoveredFunctions[:opts.MaxResults]
+		}
+		if len(result.PartialBranches) > opts.MaxResults {
+			result.PartialBranches = result.PartialBranches[:opts.MaxResults]
+		}
+	}
+
+	return result
+}
+
+// uncoveredFunctions adds the functions of the source file which were
+// never executed to the report and returns the sorted start lines of
+// all functions in the file.
+func uncoveredFunctions(sf *coverage.SourceFile, executions map[int]int, readSource func() []string, opts *Options, result *Report) []int {
+	functionExecutions := map[string]int{}
+	for _, f := range sf.FunctionExecutions {
+		functionExecutions[f.Name] += f.Executions
+	}
+
+	var starts []int
+	for _, f := range sf.FunctionInformation {
+		starts = append(starts, f.Line)
+	}
+	sort.Ints(starts)
+
+	linesFound, linesHit := sf.LinesFound, sf.LinesHit
+	if linesFound == 0 {
+		for _, e := range executions {
+			linesFound++
+			if e > 0 {
+				linesHit++
+			}
+		}
+	}
+	if linesFound == 0 || linesHit == 0 {
+		return starts
+	}
+	fileCoverage := float64(linesHit) * 100 / float64(linesFound)
+	if fileCoverage < opts.MinFileCoverage {
+		return starts
+	}
+
+	for _, f := range sf.FunctionInformation {
+		if e, found := functionExecutions[f.Name]; !found || e > 0 {
+			continue
+		}
+		result.UncoveredFunctions = append(result.UncoveredFunctions, &UncoveredFunction{
+			File:         sf.Name,
+			Name:         f.Name,
+			Line:         f.Line,
+			FileCoverage: fileCoverage,
+			Snippet:      snippet(readSource(), executions, f.Line, opts.SnippetLines),
+		})
+	}
+	return starts
+}
+
+func partialBranches(sf *coverage.SourceFile, executions map[int]int, functionStarts []int, readSource func() []string, opts *Options, result *Report) {
+	type branchLine struct {
+		found, hit int
+	}
+	lines := map[int]*branchLine{}
+	var order []int
+	for _, b := range sf.BranchInformation {
+		bl, ok := lines[b.Line]
+		if !ok {
+			bl = &branchLine{}
+			lines[b.Line] = bl
+			order = append(order, b.Line)
+		}
+		bl.found++
+		if b.Executions > 0 {
+			bl.hit++
+		}
+	}
+
+	var instrumented []int
+	for l := range executions {
+		instrumented = append(instrumented, l)
+	}
+	sort.Ints(instrumented)
+
+	for _, line := range order {
+		bl := lines[line]
+		// Only branches which were reached, but not taken in all
+		// directions, are blockers
+		if bl.hit == 0 || bl.hit == bl.found {
+			continue
+		}
+		result.PartialBranches = append(result.PartialBranches, &PartialBranch{
+			File:          sf.Name,
+			Line:          line,
+			BranchesFound: bl.found,
+			BranchesHit:   bl.hit,
+			BlockedLines:  blockedLines(line, instrumented, executions, functionStarts),
+			Snippet:       snippet(readSource(), executions, line, opts.SnippetLines),
+		})
+	}
+}
+
+// blockedLines counts the uncovered instrumented lines which directly
+// follow the branch line, stopping at the next executed line or at the
+// start of the next function.
+func blockedLines(line int, instrumented []int, executions map[int]int, functionStarts []int) int {
+	end := -1
+	i := sort.SearchInts(functionStarts, line+1)
+	if i < len(functionStarts) {
+		end = functionStarts[i]
+	}
+
+	count := 0
+	for j := sort.SearchInts(instrumented, line+1); j < len(instrumented); j++ {
+		l := instrumented[j]
+		if end != -1 && l >= end {
+			break
+		}
+		if executions[l] > 0 {
+			// Skip executed lines directly after the branch, which
+			// belong to the branch that was taken
+			if count == 0 {
+				continue
+			}
+			break
+		}
+		count++
+	}
+	return count
+}
+
+func snippet(source []string, executions map[int]int, line int, context int) []SnippetLine {
+	if len(source) == 0 || line < 1 || line > len(source) {
+		return nil
+	}
+	var lines []SnippetLine
+	for l := max(1, line-context); l <= min(len(source), line+context); l++ {
+		e, instrumented := executions[l]
+		lines = append(lines, SnippetLine{
+			Number:       l,
+			Source:       source[l-1],
+			Executions:   e,
+			Instrumented: instrumented,
+		})
+	}
+	return lines
+}
+
+func readSourceLines(name string, sourceDir string) []string {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(sourceDir, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Debugf("Unable to read source file for blocker analysis: %v", err)
+		return []string{}
+	}
+	return strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+}
+
+// Print writes the report in a human-readable format to w.
+func (r *Report) Print(w io.Writer) {
+	header := pterm.Style{pterm.Reset, pterm.Bold}
+
+	_, _ = fmt.Fprintln(w, header.Sprint("Uncovered functions in well covered files:"))
+	if len(r.UncoveredFunctions) == 0 {
+		_, _ = fmt.Fprintln(w, "  none")
+	}
+	for _, f := range r.UncoveredFunctions {
+		_, _ = fmt.Fprintf(w, "\n  %s (%s:%d, file coverage %.1f%%)\n",
+			f.Name, fileutil.PrettifyPath(f.File), f.Line, f.FileCoverage)
+		printSnippet(w, f.Snippet, f.Line)
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, header.Sprint("Partially taken branches:"))
+	if len(r.PartialBranches) == 0 {
+		_, _ = fmt.Fprintln(w, "  none")
+	}
+	for _, b := range r.PartialBranches {
+		_, _ = fmt.Fprintf(w, "\n  %s:%d (%d/%d branches taken, %d uncovered lines behind it)\n",
+			fileutil.PrettifyPath(b.File), b.Line, b.BranchesHit, b.BranchesFound, b.BlockedLines)
+		printSnippet(w, b.Snippet, b.Line)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+func printSnippet(w io.Writer, lines []SnippetLine, highlight int) {
+	for _, l := range lines {
+		marker := " "
+		if l.Number == highlight {
+			marker = ">"
+		}
+		hits := ""
+		if l.Instrumented {
+			hits = fmt.Sprint(l.Executions)
+		}
+		_, _ = fmt.Fprintf(w, "  %s %5d %8s | %s\n", marker, l.Number, hits, l.Source)
+	}
+}
@@ -0,0 +1,95 @@
+package blockers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+)
+
+const lcovReport = `SF:explore_me.cpp
+FN:1,explore_me
+FN:9,parse_header
+FNDA:12,explore_me
+FNDA:0,parse_header
+FNF:2
+FNH:1
+DA:1,12
+DA:2,12
+DA:3,12
+DA:4,0
+DA:5,0
+DA:6,0
+DA:7,12
+DA:9,0
+DA:10,0
+LF:9
+LH:5
+BRDA:2,0,0,12
+BRDA:2,0,1,-
+BRF:2
+BRH:1
+end_of_record
+SF:barely_covered.cpp
+FN:1,other
+FNDA:0,other
+DA:1,1
+DA:2,0
+DA:3,0
+DA:4,0
+end_of_record
+`
+
+const source = `int explore_me(int a) {
+  if (a < 100) {
+    return 1;
+    // unreachable with the current corpus
+    a++;
+    a++;
+  return a;
+}
+int parse_header(int a) {
+  return a;
+}
+`
+
+func TestAnalyze(t *testing.T) {
+	sourceDir := testutil.MkdirTemp(t, "", "blockers-")
+	err := os.WriteFile(filepath.Join(sourceDir, "explore_me.cpp"), []byte(source), 0o644)
+	require.NoError(t, err)
+
+	report, err := coverage.ParseLCOVFileIntoLCOVReport(strings.NewReader(lcovReport))
+	require.NoError(t, err)
+
+	result := Analyze(report, sourceDir, nil)
+
+	// The uncovered function of the barely covered file is not reported
+	require.Len(t, result.UncoveredFunctions, 1)
+	f := result.UncoveredFunctions[0]
+	assert.Equal(t, "parse_header", f.Name)
+	assert.Equal(t, 9, f.Line)
+	assert.InDelta(t, 55.6, f.FileCoverage, 0.1)
+	require.Len(t, f.Snippet, 5)
+	assert.Equal(t, "int parse_header(int a) {", f.Snippet[2].Source)
+
+	require.Len(t, result.PartialBranches, 1)
+	b := result.PartialBranches[0]
+	assert.Equal(t, 2, b.Line)
+	assert.Equal(t, 2, b.BranchesFound)
+	assert.Equal(t, 1, b.BranchesHit)
+	// Lines 4 to 6 are behind the branch which was not taken
+	assert.Equal(t, 3, b.BlockedLines)
+
+	out := &bytes.Buffer{}
+	result.Print(out)
+	assert.Contains(t, out.String(), "parse_header (explore_me.cpp:9, file coverage 55.6%)")
+	assert.Contains(t, out.String(), "explore_me.cpp:2 (1/2 branches taken, 3 uncovered lines behind it)")
+	assert.Contains(t, out.String(), ">     2       12 |   if (a < 100) {")
+}
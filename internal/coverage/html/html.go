@@ -0,0 +1,328 @@
+package html
+
+import (
+	"bufio"
+	"crypto/sha256"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+)
+
+//go:embed index.html.tmpl
+var indexTemplate string
+
+//go:embed file.html.tmpl
+var fileTemplate string
+
+// filesDir is the subdirectory of the output directory which contains
+// the source views of the individual files.
+const filesDir = "files"
+
+var templateFuncs = template.FuncMap{
+	"percent": percent,
+	"rating":  rating,
+}
+
+var (
+	indexTmpl = template.Must(template.New("index").Funcs(templateFuncs).Parse(indexTemplate))
+	fileTmpl  = template.Must(template.New("file").Funcs(templateFuncs).Parse(fileTemplate))
+)
+
+type indexPage struct {
+	Title       string
+	Total       coverage.Overview
+	Directories []*directory
+}
+
+type directory struct {
+	Name     string
+	Coverage coverage.Overview
+	Files    []*file
+}
+
+type file struct {
+	Name     string
+	Link     string
+	Coverage coverage.Overview
+}
+
+type filePage struct {
+	Title           string
+	IndexLink       string
+	Coverage        coverage.Overview
+	SourceAvailable bool
+	Lines           []*sourceLine
+}
+
+type sourceLine struct {
+	Number       int
+	Source       string
+	Instrumented bool
+	Executions   int
+	Branches     []branchMarker
+	Functions    []string
+}
+
+type branchMarker struct {
+	Taken bool
+	Title string
+}
+
+// Generate renders the given LCOV report as a static HTML report in
+// outputDir, without relying on external tools like genhtml or the
+// JaCoCo CLI. The report consists of an index.html with a file tree
+// and summary percentages and a source view for every file of the
+// report. Relative source file names are resolved against sourceDir.
+func Generate(report *coverage.LCOVReport, sourceDir string, outputDir string) error {
+	err := os.MkdirAll(filepath.Join(outputDir, filesDir), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	index := &indexPage{Title: filepath.Base(outputDir)}
+	dirs := map[string]*directory{}
+
+	for _, sf := range report.SourceFiles {
+		overview := Overview(sf)
+		addOverview(&index.Total, overview)
+
+		name := displayName(sf.Name, sourceDir)
+		dirName := filepath.ToSlash(filepath.Dir(name))
+		dir, ok := dirs[dirName]
+		if !ok {
+			dir = &directory{Name: dirName}
+			dirs[dirName] = dir
+			index.Directories = append(index.Directories, dir)
+		}
+		addOverview(&dir.Coverage, overview)
+
+		link := filesDir + "/" + pageName(name)
+		dir.Files = append(dir.Files, &file{
+			Name:     filepath.Base(name),
+			Link:     link,
+			Coverage: overview,
+		})
+
+		err = writeFilePage(sf, name, sourceDir, filepath.Join(outputDir, filepath.FromSlash(link)))
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(index.Directories, func(i, j int) bool {
+		return index.Directories[i].Name < index.Directories[j].Name
+	})
+	for _, dir := range index.Directories {
+		sort.Slice(dir.Files, func(i, j int) bool {
+			return dir.Files[i].Name < dir.Files[j].Name
+		})
+	}
+
+	err = writeTemplate(indexTmpl, index, filepath.Join(outputDir, "index.html"))
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Created HTML coverage report in %s", outputDir)
+	return nil
+}
+
+// GenerateFromLCOVFile parses the LCOV report at lcovPath and renders
+// it via Generate.
+func GenerateFromLCOVFile(lcovPath string, sourceDir string, outputDir string) error {
+	f, err := os.Open(lcovPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	report, err := coverage.ParseLCOVFileIntoLCOVReport(f)
+	if err != nil {
+		return err
+	}
+	return Generate(report, sourceDir, outputDir)
+}
+
+// Overview returns the coverage overview of the source file. If the
+// report didn't contain the summary records (LF, LH, ...), they are
+// computed from the line, function and branch records.
+func Overview(sf *coverage.SourceFile) coverage.Overview {
+	overview := sf.Overview
+	if overview.LinesFound == 0 && len(sf.LineInformation) > 0 {
+		for _, l := range sf.LineInformation {
+			overview.LinesFound++
+			if l.Executions > 0 {
+				overview.LinesHit++
+			}
+		}
+	}
+	if overview.FunctionsFound == 0 && len(sf.FunctionExecutions) > 0 {
+		for _, f := range sf.FunctionExecutions {
+			overview.FunctionsFound++
+			if f.Executions > 0 {
+				overview.FunctionsHit++
+			}
+		}
+	}
+	if overview.BranchesFound == 0 && len(sf.BranchInformation) > 0 {
+		for _, b := range sf.BranchInformation {
+			overview.BranchesFound++
+			if b.Executions > 0 {
+				overview.BranchesHit++
+			}
+		}
+	}
+	return overview
+}
+
+func writeFilePage(sf *coverage.SourceFile, name string, sourceDir string, path string) error {
+	page := &filePage{
+		Title:     name,
+		IndexLink: "../index.html",
+		Coverage:  Overview(sf),
+	}
+
+	executions := map[int]int{}
+	lastLine := 0
+	for _, l := range sf.LineInformation {
+		executions[l.Number] += l.Executions
+		lastLine = max(lastLine, l.Number)
+	}
+	branches := map[int][]branchMarker{}
+	for _, b := range sf.BranchInformation {
+		title := fmt.Sprintf("Branch %d: not taken", b.Number)
+		if b.Executions > 0 {
+			title = fmt.Sprintf("Branch %d: taken %d times", b.Number, b.Executions)
+		}
+		branches[b.Line] = append(branches[b.Line], branchMarker{Taken: b.Executions > 0, Title: title})
+		lastLine = max(lastLine, b.Line)
+	}
+	functions := map[int][]string{}
+	for _, f := range sf.FunctionInformation {
+		functions[f.Line] = append(functions[f.Line], f.Name)
+	}
+
+	source, err := readSourceLines(sf.Name, sourceDir)
+	if err != nil {
+		log.Debugf("Source file of coverage report not available: %v", err)
+	}
+	page.SourceAvailable = source != nil
+	lastLine = max(lastLine, len(source))
+
+	for i := 1; i <= lastLine; i++ {
+		line := &sourceLine{
+			Number:    i,
+			Branches:  branches[i],
+			Functions: functions[i],
+		}
+		line.Executions, line.Instrumented = executions[i]
+		if i <= len(source) {
+			line.Source = source[i-1]
+		}
+		// Without the source, only show the lines we have data for
+		if source == nil && !line.Instrumented && len(line.Branches) == 0 {
+			continue
+		}
+		page.Lines = append(page.Lines, line)
+	}
+
+	return writeTemplate(fileTmpl, page, path)
+}
+
+func writeTemplate(tmpl *template.Template, data any, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to render %s", path)
+	}
+	return errors.WithStack(w.Flush())
+}
+
+func readSourceLines(name string, sourceDir string) ([]string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(sourceDir, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	// A trailing newline doesn't start a new line
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// displayName returns the name of the source file relative to the
+// source directory if possible.
+func displayName(name string, sourceDir string) string {
+	if sourceDir != "" && filepath.IsAbs(name) {
+		rel, err := filepath.Rel(sourceDir, name)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return name
+}
+
+// pageName returns the file name of the page of the source file. The
+// path separators are replaced to get a flat list of pages, so a short
+// hash of the full path is added to keep names like "a/b_c.cpp" and
+// "a_b/c.cpp" apart.
+func pageName(name string) string {
+	name = filepath.ToSlash(name)
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_")
+	hash := sha256.Sum256([]byte(name))
+	return fmt.Sprintf("%s.%x.html", replacer.Replace(strings.TrimPrefix(name, "/")), hash[:4])
+}
+
+func addOverview(total *coverage.Overview, o coverage.Overview) {
+	total.FunctionsFound += o.FunctionsFound
+	total.FunctionsHit += o.FunctionsHit
+	total.LinesFound += o.LinesFound
+	total.LinesHit += o.LinesHit
+	total.BranchesFound += o.BranchesFound
+	total.BranchesHit += o.BranchesHit
+}
+
+func percentValue(hit, found int) float64 {
+	if found == 0 {
+		return 100.0
+	}
+	return (float64(hit) * 100) / float64(found)
+}
+
+func percent(hit, found int) string {
+	return fmt.Sprintf("%.1f%%", percentValue(hit, found))
+}
+
+// rating returns the CSS class used to color a coverage percentage,
+// using the same thresholds as genhtml.
+func rating(hit, found int) string {
+	p := percentValue(hit, found)
+	switch {
+	case p >= 90:
+		return "high"
+	case p >= 75:
+		return "medium"
+	default:
+		return "low"
+	}
+}
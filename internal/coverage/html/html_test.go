@@ -0,0 +1,88 @@
+package html
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+)
+
+func TestGenerate(t *testing.T) {
+	sourceDir := testutil.MkdirTemp(t, "", "html-source-")
+	err := os.MkdirAll(filepath.Join(sourceDir, "src"), 0o755)
+	require.NoError(t, err)
+	source := `int explore_me(int a) {
+  if (a < 100) {
+    return 1;
+  }
+  return 0;
+}
+`
+	err = os.WriteFile(filepath.Join(sourceDir, "src", "explore_me.cpp"), []byte(source), 0o644)
+	require.NoError(t, err)
+
+	report := &coverage.LCOVReport{
+		SourceFiles: []*coverage.SourceFile{
+			{
+				Name:                filepath.Join(sourceDir, "src", "explore_me.cpp"),
+				FunctionInformation: []coverage.Function{{Name: "explore_me", Line: 1}},
+				FunctionExecutions:  []coverage.FunctionExecution{{Name: "explore_me", Executions: 3}},
+				LineInformation: []coverage.Line{
+					{Number: 1, Executions: 3},
+					{Number: 2, Executions: 3},
+					{Number: 3, Executions: 3},
+					{Number: 5, Executions: 0},
+				},
+				BranchInformation: []coverage.Branch{
+					{Line: 2, Number: 0, Executions: 3},
+					{Line: 2, Number: 1, Executions: 0},
+				},
+			},
+			{
+				// A file which doesn't exist in the source directory
+				Name:            "missing.cpp",
+				LineInformation: []coverage.Line{{Number: 7, Executions: 1}},
+				Overview:        coverage.Overview{LinesFound: 1, LinesHit: 1},
+			},
+		},
+	}
+
+	outputDir := testutil.MkdirTemp(t, "", "html-report-")
+	err = Generate(report, sourceDir, outputDir)
+	require.NoError(t, err)
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `<a href="files/`+pageName("src/explore_me.cpp")+`">explore_me.cpp</a>`)
+	assert.Contains(t, string(index), `<a href="files/`+pageName("missing.cpp")+`">missing.cpp</a>`)
+	// Lines and branches of explore_me.cpp, computed from the records
+	assert.Contains(t, string(index), "3 / 4 (75.0%)")
+	assert.Contains(t, string(index), "1 / 2 (50.0%)")
+	// Total lines of both files
+	assert.Contains(t, string(index), "4 / 5 (80.0%)")
+
+	page, err := os.ReadFile(filepath.Join(outputDir, "files", pageName("src/explore_me.cpp")))
+	require.NoError(t, err)
+	// The source is HTML-escaped
+	assert.Contains(t, string(page), "if (a &lt; 100) {")
+	assert.Contains(t, string(page), `<span class="taken" title="Branch 0: taken 3 times">[+]</span>`)
+	assert.Contains(t, string(page), `<span class="not-taken" title="Branch 1: not taken">[-]</span>`)
+	assert.Contains(t, string(page), `<tr id="L5" class="uncovered">`)
+	assert.NotContains(t, string(page), "The source file is not available")
+
+	page, err = os.ReadFile(filepath.Join(outputDir, "files", pageName("missing.cpp")))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "The source file is not available")
+	assert.Contains(t, string(page), `<tr id="L7" class="covered">`)
+	assert.NotContains(t, string(page), `id="L1"`)
+}
+
+func TestPageName(t *testing.T) {
+	assert.NotEqual(t, pageName("a/b_c.cpp"), pageName("a_b/c.cpp"))
+	assert.Regexp(t, `^a_b_c\.cpp\.[0-9a-f]{8}\.html$`, pageName("a/b_c.cpp"))
+}
@@ -0,0 +1,272 @@
+package finding
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ExploitabilityRating string
+
+const (
+	ExploitabilityLikely   ExploitabilityRating = "likely"
+	ExploitabilityPossibly ExploitabilityRating = "possibly"
+	ExploitabilityUnlikely ExploitabilityRating = "unlikely"
+)
+
+// Exploitability is an estimate of how likely a memory error reported by
+// AddressSanitizer can be exploited by an attacker, together with the
+// properties of the invalid memory access it is based on.
+type Exploitability struct {
+	Rating ExploitabilityRating `json:"rating"`
+	// Why the error was rated this way
+	Reason string `json:"reason,omitempty"`
+	// The bug type reported by AddressSanitizer, e.g.
+	// "heap-buffer-overflow"
+	BugType string `json:"bug_type,omitempty"`
+	// "read" or "write", if known
+	Access     string `json:"access,omitempty"`
+	AccessSize int    `json:"access_size,omitempty"`
+	// "heap", "stack" or "global", if known
+	Region string `json:"region,omitempty"`
+	// The number of bytes between the accessed address and the bounds
+	// of the region, if the address is outside of the region
+	Distance int64 `json:"distance,omitempty"`
+	// Whether the accessed address is in the first pages of the address
+	// space, which are not mapped, i.e. a null pointer dereference
+	NearNull bool `json:"near_null,omitempty"`
+	// Whether the accessed address doesn't belong to any known region
+	WildAddress bool `json:"wild_address,omitempty"`
+	// Whether the error occurred when executing code at the address,
+	// i.e. the program counter was corrupted
+	Execute bool `json:"execute,omitempty"`
+}
+
+// Addresses below this limit are never mapped on Linux and macOS (see
+// vm.mmap_min_addr), so accessing them is a null pointer dereference.
+const nearNullLimit = 0x10000
+
+// Reads of at most this many bytes beyond the bounds of a region rarely
+// disclose anything useful to an attacker.
+const smallReadLimit = 8
+
+var (
+	asanErrorRegex    = regexp.MustCompile(`ERROR: AddressSanitizer: (?:attempting )?([\w-]+)`)
+	asanAddressRegex  = regexp.MustCompile(`on (?:unknown )?address (0x[0-9a-f]+)(?: \(pc (0x[0-9a-f]+))?`)
+	asanAccessRegex   = regexp.MustCompile(`^(READ|WRITE) of size (\d+) at`)
+	asanSignalRegex   = regexp.MustCompile(`The signal is caused by a (READ|WRITE) memory access`)
+	asanLocationRegex = regexp.MustCompile(`is located (\d+) bytes (to the left|to the right|before|after|inside) of (?:(global variable)|\d+-byte region)`)
+	asanStackRegex    = regexp.MustCompile(`is located in stack of thread`)
+	asanZeroPageRegex = regexp.MustCompile(`Hint: (?:address|pc) points to the zero page`)
+)
+
+// Bug types which corrupt or disclose memory of a valid region
+var asanMemoryCorruptionBugTypes = map[string]bool{
+	"heap-buffer-overflow":          true,
+	"stack-buffer-overflow":         true,
+	"stack-buffer-underflow":        true,
+	"dynamic-stack-buffer-overflow": true,
+	"global-buffer-overflow":        true,
+	"container-overflow":            true,
+	"heap-use-after-free":           true,
+	"stack-use-after-return":        true,
+	"stack-use-after-scope":         true,
+	"use-after-poison":              true,
+}
+
+// Bug types which usually only lead to a denial of service
+var asanDenialOfServiceBugTypes = map[string]bool{
+	"stack-overflow":           true,
+	"allocation-size-too-big":  true,
+	"calloc-overflow":          true,
+	"out-of-memory":            true,
+	"odr-violation":            true,
+	"alloc-dealloc-mismatch":   true,
+	"new-delete-type-mismatch": true,
+	"memcpy-param-overlap":     true,
+	"strcpy-param-overlap":     true,
+	"strncpy-param-overlap":    true,
+}
+
+// ClassifyExploitability analyses the AddressSanitizer report in the
+// logs of a finding. It returns nil if the logs don't contain an
+// AddressSanitizer report.
+func ClassifyExploitability(logs []string) *Exploitability {
+	var e *Exploitability
+	var address, pc uint64
+
+	for _, line := range logs {
+		line = strings.TrimSpace(line)
+		if e == nil {
+			match := asanErrorRegex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			e = &Exploitability{BugType: match[1]}
+			switch e.BugType {
+			case "free":
+				// "attempting free on address which was not malloc()-ed"
+				e.BugType = "bad-free"
+			case "requested":
+				// "requested allocation size exceeds maximum supported size"
+				e.BugType = "allocation-size-too-big"
+			}
+			if match := asanAddressRegex.FindStringSubmatch(line); match != nil {
+				address, _ = strconv.ParseUint(match[1], 0, 64)
+				if match[2] != "" {
+					pc, _ = strconv.ParseUint(match[2], 0, 64)
+				}
+			}
+			continue
+		}
+
+		if match := asanAccessRegex.FindStringSubmatch(line); match != nil {
+			e.Access = strings.ToLower(match[1])
+			e.AccessSize, _ = strconv.Atoi(match[2])
+		} else if match := asanSignalRegex.FindStringSubmatch(line); match != nil {
+			e.Access = strings.ToLower(match[1])
+		} else if match := asanLocationRegex.FindStringSubmatch(line); match != nil && e.Region == "" {
+			if match[2] != "inside" {
+				e.Distance, _ = strconv.ParseInt(match[1], 10, 64)
+			}
+			e.Region = "heap"
+			if match[3] != "" {
+				e.Region = "global"
+			}
+		} else if asanStackRegex.MatchString(line) {
+			e.Region = "stack"
+		} else if asanZeroPageRegex.MatchString(line) {
+			e.NearNull = true
+		}
+	}
+	if e == nil {
+		return nil
+	}
+
+	if e.BugType == "SEGV" {
+		e.Execute = address != 0 && address == pc
+		if address < nearNullLimit {
+			e.NearNull = true
+		}
+		e.WildAddress = !e.NearNull
+	}
+	if e.Region == "" {
+		for _, region := range []string{"heap", "stack", "global"} {
+			if strings.HasPrefix(e.BugType, region+"-") {
+				e.Region = region
+			}
+		}
+	}
+
+	e.Rating, e.Reason = e.rate()
+	return e
+}
+
+func (e *Exploitability) rate() (ExploitabilityRating, string) {
+	switch {
+	case e.BugType == "double-free" || e.BugType == "bad-free":
+		return ExploitabilityLikely, "freeing invalid memory can corrupt the metadata of the allocator"
+
+	case e.BugType == "SEGV":
+		switch {
+		case e.NearNull:
+			return ExploitabilityUnlikely, "null pointer dereference"
+		case e.Execute:
+			return ExploitabilityLikely, "execution of code at a wild address, the program counter is corrupted"
+		case e.Access == "write":
+			return ExploitabilityLikely, "write to a wild address"
+		case e.Access == "read":
+			return ExploitabilityPossibly, "read from a wild address"
+		default:
+			return ExploitabilityPossibly, "access to a wild address"
+		}
+
+	case asanMemoryCorruptionBugTypes[e.BugType]:
+		location := e.location()
+		switch {
+		case e.Access == "write":
+			return ExploitabilityLikely, fmt.Sprintf("write of %d bytes %s", e.AccessSize, location)
+		case e.Access == "read" && e.Distance+int64(e.AccessSize) <= smallReadLimit && !strings.Contains(e.BugType, "use-after"):
+			return ExploitabilityUnlikely, fmt.Sprintf("read of %d bytes just %s", e.AccessSize, location)
+		case e.Access == "read":
+			return ExploitabilityPossibly, fmt.Sprintf("read of %d bytes %s can disclose memory contents", e.AccessSize, location)
+		default:
+			return ExploitabilityPossibly, "invalid memory access " + location
+		}
+
+	case asanDenialOfServiceBugTypes[e.BugType]:
+		return ExploitabilityUnlikely, e.BugType + " usually only leads to a denial of service"
+
+	default:
+		return ExploitabilityPossibly, "unknown memory error " + e.BugType
+	}
+}
+
+// location describes where the invalid memory access happened, e.g.
+// "4 bytes outside of a heap region".
+func (e *Exploitability) location() string {
+	region := "a memory region"
+	if e.Region != "" {
+		region = "a " + e.Region + " region"
+	}
+	switch {
+	case strings.Contains(e.BugType, "use-after"):
+		return "in freed memory of " + region
+	case e.Distance > 0:
+		return fmt.Sprintf("%d bytes outside of %s", e.Distance, region)
+	default:
+		return "outside of " + region
+	}
+}
+
+// AdjustSeverity returns a copy of the severity which is raised for
+// likely exploitable and lowered for unlikely exploitable errors. The
+// level of the adjusted severity has the same casing as the original
+// level, e.g. "High" for the title case levels of error-details.json.
+func (e *Exploitability) AdjustSeverity(severity *Severity) *Severity {
+	if severity == nil {
+		return nil
+	}
+	score := severity.Score
+	switch e.Rating {
+	case ExploitabilityLikely:
+		score += 2
+	case ExploitabilityUnlikely:
+		score -= 2
+	default:
+		return severity
+	}
+	score = min(max(score, 0), 10)
+	return &Severity{Level: matchLevelCase(severityLevelForScore(score), severity.Level), Score: score}
+}
+
+// matchLevelCase returns the level in the casing of the original level,
+// which is either upper case like the SeverityLevel constants or title
+// case like the levels of error-details.json.
+func matchLevelCase(level, original SeverityLevel) SeverityLevel {
+	if original == "" || strings.ToUpper(string(original)) == string(original) {
+		return level
+	}
+	lower := strings.ToLower(string(level))
+	return SeverityLevel(strings.ToUpper(lower[:1]) + lower[1:])
+}
+
+func (e *Exploitability) String() string {
+	return fmt.Sprintf("%s (%s)", e.Rating, e.Reason)
+}
+
+// severityLevelForScore returns the level of the score according to the
+// qualitative severity rating scale of CVSS.
+func severityLevelForScore(score float32) SeverityLevel {
+	switch {
+	case score >= 9:
+		return SeverityLevelCritical
+	case score >= 7:
+		return SeverityLevelHigh
+	case score >= 4:
+		return SeverityLevelMedium
+	default:
+		return SeverityLevelLow
+	}
+}
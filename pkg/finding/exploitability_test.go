@@ -0,0 +1,213 @@
+package finding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyExploitability(t *testing.T) {
+	tests := []struct {
+		name     string
+		logs     []string
+		expected *Exploitability
+	}{
+		{
+			name: "heap buffer overflow write",
+			logs: []string{
+				"==6862==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x0001054009b1 at pc 0x000102ec2228 bp 0x00016d6162d0 sp 0x00016d615a90",
+				"WRITE of size 9 at 0x0001054009b1 thread T0",
+				"    #0 0x102ec2224 in wrap_strcpy",
+				"0x0001054009b1 is located 0 bytes to the right of 1-byte region [0x0001054009b0,0x0001054009b1)",
+			},
+			expected: &Exploitability{
+				Rating:     ExploitabilityLikely,
+				Reason:     "write of 9 bytes outside of a heap region",
+				BugType:    "heap-buffer-overflow",
+				Access:     "write",
+				AccessSize: 9,
+				Region:     "heap",
+			},
+		},
+		{
+			name: "global buffer overflow small read",
+			logs: []string{
+				"==3727==ERROR: AddressSanitizer: global-buffer-overflow on address 0x000104f0cd21 at pc 0x000105592228 bp 0x00016af462e0 sp 0x00016af45aa0",
+				"READ of size 1 at 0x000104f0cd21 thread T0",
+				"0x000104f0cd21 is located 2 bytes to the right of global variable 'test' defined in 'global_buffer_overflow.cpp:5' (0x104f0cd20) of size 1",
+			},
+			expected: &Exploitability{
+				Rating:     ExploitabilityUnlikely,
+				Reason:     "read of 1 bytes just 2 bytes outside of a global region",
+				BugType:    "global-buffer-overflow",
+				Access:     "read",
+				AccessSize: 1,
+				Region:     "global",
+				Distance:   2,
+			},
+		},
+		{
+			name: "heap use after free read",
+			logs: []string{
+				"==3885==ERROR: AddressSanitizer: heap-use-after-free on address 0x000106f0c4d0 at pc 0x0001049924bc bp 0x00016bb2a350 sp 0x00016bb29ae0",
+				" READ of size 3 at 0x000106f0c4d0 thread T0",
+				" 0x000106f0c4d0 is located 0 bytes inside of 1-byte region [0x000106f0c4d0,0x000106f0c4d1)",
+				" freed by thread T0 here:",
+			},
+			expected: &Exploitability{
+				Rating:     ExploitabilityPossibly,
+				Reason:     "read of 3 bytes in freed memory of a heap region can disclose memory contents",
+				BugType:    "heap-use-after-free",
+				Access:     "read",
+				AccessSize: 3,
+				Region:     "heap",
+			},
+		},
+		{
+			name: "stack use after return write",
+			logs: []string{
+				"==9361==ERROR: AddressSanitizer: stack-use-after-return on address 0x000106495020 at pc 0x00010444549c bp 0x00016b9c6400 sp 0x00016b9c63f8",
+				"  WRITE of size 4 at 0x000106495020 thread T0",
+				"  Address 0x000106495020 is located in stack of thread T0 at offset 32 in frame",
+			},
+			expected: &Exploitability{
+				Rating:     ExploitabilityLikely,
+				Reason:     "write of 4 bytes in freed memory of a stack region",
+				BugType:    "stack-use-after-return",
+				Access:     "write",
+				AccessSize: 4,
+				Region:     "stack",
+			},
+		},
+		{
+			name: "null pointer dereference",
+			logs: []string{
+				"==8210==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000 (pc 0x000100081550 bp 0x00016fd8a350 sp 0x00016fd8a300 T0)",
+				"  ==8210==The signal is caused by a WRITE memory access.",
+				"  ==8210==Hint: address points to the zero page.",
+			},
+			expected: &Exploitability{
+				Rating:   ExploitabilityUnlikely,
+				Reason:   "null pointer dereference",
+				BugType:  "SEGV",
+				Access:   "write",
+				NearNull: true,
+			},
+		},
+		{
+			name: "wild write",
+			logs: []string{
+				"==16==ERROR: AddressSanitizer: SEGV on unknown address 0x41414141414 (pc 0x000100081550 bp 0x7fffb9492290 sp 0x7fffb9492158 T0)",
+				"==16==The signal is caused by a WRITE memory access.",
+			},
+			expected: &Exploitability{
+				Rating:      ExploitabilityLikely,
+				Reason:      "write to a wild address",
+				BugType:     "SEGV",
+				Access:      "write",
+				WildAddress: true,
+			},
+		},
+		{
+			name: "wild jump",
+			logs: []string{
+				"==16==ERROR: AddressSanitizer: SEGV on unknown address 0x414141414141 (pc 0x414141414141 bp 0x7fffb9492290 sp 0x7fffb9492158 T0)",
+				"==16==The signal is caused by a READ memory access.",
+			},
+			expected: &Exploitability{
+				Rating:      ExploitabilityLikely,
+				Reason:      "execution of code at a wild address, the program counter is corrupted",
+				BugType:     "SEGV",
+				Access:      "read",
+				WildAddress: true,
+				Execute:     true,
+			},
+		},
+		{
+			name: "double free",
+			logs: []string{
+				" ==13480==ERROR: AddressSanitizer: attempting double-free on 0x000103414550 in thread T0:",
+				"  0x000103414550 is located 0 bytes inside of 4-byte region [0x000103414550,0x000103414554)",
+			},
+			expected: &Exploitability{
+				Rating:  ExploitabilityLikely,
+				Reason:  "freeing invalid memory can corrupt the metadata of the allocator",
+				BugType: "double-free",
+				Region:  "heap",
+			},
+		},
+		{
+			name: "stack overflow",
+			logs: []string{
+				" ==9076==ERROR: AddressSanitizer: stack-overflow on address 0x00016b43fff0 (pc 0x00010487988c bp 0x00016b440040 sp 0x00016b440030 T0)",
+			},
+			expected: &Exploitability{
+				Rating:  ExploitabilityUnlikely,
+				Reason:  "stack-overflow usually only leads to a denial of service",
+				BugType: "stack-overflow",
+				Region:  "stack",
+			},
+		},
+		{
+			name: "no AddressSanitizer report",
+			logs: []string{
+				"==4254== ERROR: libFuzzer: out-of-memory (used: 2078Mb; limit: 2048Mb)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyExploitability(tt.logs))
+		})
+	}
+}
+
+func TestExploitability_AdjustSeverity(t *testing.T) {
+	severity := &Severity{Level: "High", Score: 8}
+
+	// The adjusted level keeps the casing of the original level
+	likely := &Exploitability{Rating: ExploitabilityLikely}
+	assert.Equal(t, &Severity{Level: "Critical", Score: 10}, likely.AdjustSeverity(severity))
+	assert.Equal(t, &Severity{Level: SeverityLevelCritical, Score: 10}, likely.AdjustSeverity(&Severity{Level: SeverityLevelHigh, Score: 8}))
+
+	possibly := &Exploitability{Rating: ExploitabilityPossibly}
+	assert.Equal(t, severity, possibly.AdjustSeverity(severity))
+
+	unlikely := &Exploitability{Rating: ExploitabilityUnlikely}
+	assert.Equal(t, &Severity{Level: "Medium", Score: 6}, unlikely.AdjustSeverity(severity))
+
+	// The original severity is not modified
+	assert.Equal(t, float32(8), severity.Score)
+	assert.Nil(t, likely.AdjustSeverity(nil))
+}
+
+func TestEnhanceWithErrorDetails_Exploitability(t *testing.T) {
+	f := &Finding{
+		Details:     "heap-buffer-overflow on address 0x0001054009b1",
+		MoreDetails: &ErrorDetails{ID: "heap_buffer_overflow"},
+		Logs: []string{
+			"==6862==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x0001054009b1 at pc 0x000102ec2228 bp 0x00016d6162d0 sp 0x00016d615a90",
+			"READ of size 1 at 0x0001054009b1 thread T0",
+			"0x0001054009b1 is located 0 bytes to the right of 1-byte region [0x0001054009b0,0x0001054009b1)",
+		},
+	}
+	severity, err := SeverityForErrorID("heap_buffer_overflow")
+	require.NoError(t, err)
+	require.NotNil(t, severity)
+
+	err = f.EnhanceWithErrorDetails()
+	require.NoError(t, err)
+	require.NotNil(t, f.Exploitability)
+	assert.Equal(t, ExploitabilityUnlikely, f.Exploitability.Rating)
+	assert.Equal(t, severity.Score-2, f.MoreDetails.Severity.Score)
+	// The level has the same casing as the levels of the error details
+	assert.Equal(t, SeverityLevel("High"), f.MoreDetails.Severity.Level)
+	assert.Equal(t, SeverityLevel("Critical"), severity.Level)
+
+	// Enhancing the finding again doesn't lower the severity further
+	err = f.EnhanceWithErrorDetails()
+	require.NoError(t, err)
+	assert.Equal(t, severity.Score-2, f.MoreDetails.Severity.Score)
+}
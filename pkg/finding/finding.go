@@ -20,10 +20,14 @@ import (
 )
 
 const (
-	nameCrashingInput = "crashing-input"
-	nameJSONFile      = "finding.json"
-	nameFindingsDir   = ".cifuzz-findings"
-	lockFile          = ".lock"
+	nameCrashingInput  = "crashing-input"
+	nameMinimizedInput = "crashing-input-minimized"
+	nameJSONFile       = "finding.json"
+	nameFindingsDir    = ".cifuzz-findings"
+	lockFile           = ".lock"
+	// The lock file in the findings directory which serializes the
+	// lookup of findings by their signature and the creation of findings
+	signatureLockFile = ".signature.lock"
 )
 
 type Finding struct {
@@ -49,6 +53,82 @@ type Finding struct {
 	// We also store the name of the fuzz test that found this finding so that
 	// we can show it in the finding overview and use it to reproduce the finding.
 	FuzzTest string `json:"fuzz_test,omitempty"`
+
+	// The crash signature which is used to detect duplicates of this
+	// finding, see the signature package.
+	Signature string `json:"signature,omitempty"`
+	// The history of the crashes which were recorded as this finding,
+	// i.e. the crashes with the same name (which overwrite the finding)
+	// and the duplicates with the same signature.
+	Occurrences []*Occurrence `json:"occurrences,omitempty"`
+	// The path of the minimized crashing input, relative to the project
+	// directory, see 'cifuzz finding minimize'.
+	MinimizedInputFile string `json:"minimized_input_file,omitempty"`
+	// Whether the finding matches a suppression rule and the reason of
+	// the rule, see the suppression package. Suppressed findings are
+	// recorded, but not notified and not uploaded.
+	Suppressed        bool   `json:"suppressed,omitempty"`
+	SuppressionReason string `json:"suppression_reason,omitempty"`
+	// The first commit in which the crash occurs, see 'cifuzz finding
+	// bisect'.
+	FirstBadCommit string `json:"first_bad_commit,omitempty"`
+	// How reliably the crashing input reproduces the finding, see
+	// 'cifuzz finding check-flaky'.
+	Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+	// The exploitability of native memory errors, which is determined
+	// from the AddressSanitizer report in the logs
+	Exploitability *Exploitability `json:"exploitability,omitempty"`
+}
+
+// Occurrence is a crash which was recorded as a finding.
+type Occurrence struct {
+	// The name of the finding which was created for the crash. If it
+	// differs from the name of the finding which the occurrence belongs
+	// to, the crash is a duplicate with the same signature.
+	Name      string    `json:"name,omitempty"`
+	FuzzTest  string    `json:"fuzz_test,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// The path of the crashing input, relative to the project directory
+	InputFile string `json:"input_file,omitempty"`
+	// The Git revision of the project when the crash occurred
+	GitCommit string `json:"git_commit,omitempty"`
+	GitBranch string `json:"git_branch,omitempty"`
+}
+
+// Reproducibility is the result of replaying the crashing input of a
+// finding multiple times.
+type Reproducibility struct {
+	// The number of times the crashing input was replayed
+	Runs int `json:"runs"`
+	// The number of runs which crashed with the same signature as the
+	// finding
+	Reproduced int `json:"reproduced"`
+	// The ratio of reproduced runs, between 0 and 1
+	Rate      float64   `json:"rate"`
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+}
+
+// NewReproducibility returns the reproducibility of a finding which was
+// reproduced in the given number of runs.
+func NewReproducibility(runs, reproduced int) *Reproducibility {
+	r := &Reproducibility{
+		Runs:       runs,
+		Reproduced: reproduced,
+		CheckedAt:  time.Now(),
+	}
+	if runs > 0 {
+		r.Rate = float64(reproduced) / float64(runs)
+	}
+	return r
+}
+
+// IsFlaky returns true if the finding was not reproduced in all runs.
+func (r *Reproducibility) IsFlaky() bool {
+	return r.Reproduced < r.Runs
+}
+
+func (r *Reproducibility) String() string {
+	return fmt.Sprintf("%.0f%% (%d of %d runs)", r.Rate*100, r.Reproduced, r.Runs)
 }
 
 type ErrorType string
@@ -125,13 +205,25 @@ func (f *Finding) Exists(projectDir string) (bool, error) {
 	return fileutil.Exists(jsonPath)
 }
 
+// Save writes the finding to the JSON file of the finding. If the
+// finding was saved before, the occurrences and the creation date of the
+// saved finding are preserved.
 func (f *Finding) Save(projectDir string) error {
+	return f.withLock(projectDir, func() error {
+		return f.save(projectDir)
+	})
+}
+
+func (f *Finding) save(projectDir string) error {
 	findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
 	jsonPath := filepath.Join(findingDir, nameJSONFile)
 
-	err := os.MkdirAll(findingDir, 0o755)
-	if err != nil {
-		return errors.WithStack(err)
+	saved, err := loadJSON(jsonPath)
+	if err != nil && !IsNotExistError(err) {
+		return err
+	}
+	if saved != nil {
+		f.mergeHistory(saved)
 	}
 
 	// write InputData to file if an input file does not already exist
@@ -154,6 +246,14 @@ func (f *Finding) Save(projectDir string) error {
 		f.InputFile = inputFilePath
 	}
 
+	// The name of a finding depends on the crashing input, so all
+	// occurrences with the same name have the same input
+	for _, o := range f.Occurrences {
+		if o.Name == f.Name && o.InputFile == "" {
+			o.InputFile = f.InputFile
+		}
+	}
+
 	err = f.saveJSON(jsonPath)
 	if err != nil {
 		return err
@@ -187,14 +287,247 @@ func (f *Finding) Remove(projectDir string) error {
 // CopyInputFileAndUpdateFinding copies the input file to the finding directory and
 // the seed corpus directory and adjusts the finding logs accordingly.
 func (f *Finding) CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir string) error {
-	// Acquire a file lock to avoid races with other cifuzz processes
-	// running in parallel
-	findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
-	err := os.MkdirAll(findingDir, 0o755)
+	return f.withLock(projectDir, func() error {
+		return f.copyInputFile(projectDir, seedCorpusDir)
+	})
+}
+
+// AddOccurrence adds the occurrence to the history of the finding. The
+// history is stored when the finding is saved.
+func (f *Finding) AddOccurrence(occurrence *Occurrence) {
+	f.Occurrences = append(f.Occurrences, occurrence)
+}
+
+// FirstSeen returns the time of the first occurrence of the finding.
+func (f *Finding) FirstSeen() time.Time {
+	firstSeen := f.CreatedAt
+	for _, o := range f.Occurrences {
+		if firstSeen.IsZero() || o.CreatedAt.Before(firstSeen) {
+			firstSeen = o.CreatedAt
+		}
+	}
+	return firstSeen
+}
+
+// LastSeen returns the time of the last occurrence of the finding.
+func (f *Finding) LastSeen() time.Time {
+	lastSeen := f.CreatedAt
+	for _, o := range f.Occurrences {
+		if o.CreatedAt.After(lastSeen) {
+			lastSeen = o.CreatedAt
+		}
+	}
+	return lastSeen
+}
+
+// Count returns the number of occurrences of the finding. Findings which
+// were saved before occurrences were tracked count as one occurrence.
+func (f *Finding) Count() int {
+	if len(f.Occurrences) == 0 {
+		return 1
+	}
+	return len(f.Occurrences)
+}
+
+// mergeHistory prepends the occurrences of the saved finding to the
+// occurrences of f and keeps the creation date of the saved finding.
+func (f *Finding) mergeHistory(saved *Finding) {
+	occurrences := saved.Occurrences
+	if len(occurrences) == 0 && len(f.Occurrences) > 0 {
+		occurrences = saved.initialOccurrences()
+	}
+	for _, o := range f.Occurrences {
+		// The occurrences of f are already contained in the saved
+		// finding if f was loaded from the JSON file
+		if !containsOccurrence(occurrences, o) {
+			occurrences = append(occurrences, o)
+		}
+	}
+	f.Occurrences = occurrences
+
+	if !saved.CreatedAt.IsZero() {
+		f.CreatedAt = saved.CreatedAt
+	}
+	if f.MinimizedInputFile == "" {
+		f.MinimizedInputFile = saved.MinimizedInputFile
+	}
+	if f.FirstBadCommit == "" {
+		f.FirstBadCommit = saved.FirstBadCommit
+	}
+	if f.Reproducibility == nil {
+		f.Reproducibility = saved.Reproducibility
+	}
+}
+
+// initialOccurrences returns the occurrence which created the finding
+// for findings which were saved before occurrences were tracked.
+func (f *Finding) initialOccurrences() []*Occurrence {
+	if f.CreatedAt.IsZero() {
+		return nil
+	}
+	return []*Occurrence{{Name: f.Name, FuzzTest: f.FuzzTest, CreatedAt: f.CreatedAt, InputFile: f.InputFile}}
+}
+
+func containsOccurrence(occurrences []*Occurrence, o *Occurrence) bool {
+	for _, other := range occurrences {
+		if other.Name == o.Name && other.CreatedAt.Equal(o.CreatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddDuplicate records the duplicate as an occurrence of the finding in
+// the JSON file of the finding and stores its crashing input in the
+// finding directory.
+func (f *Finding) AddDuplicate(projectDir string, duplicate *Finding, occurrence *Occurrence) error {
+	return f.withLock(projectDir, func() error {
+		findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
+		jsonPath := filepath.Join(findingDir, nameJSONFile)
+
+		// Reload the finding to not lose occurrences which were added
+		// by other cifuzz processes in the meantime
+		saved, err := loadJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+
+		inputPath := filepath.Join(findingDir, nameCrashingInput+"-"+duplicate.Name)
+		switch {
+		case duplicate.InputFile != "":
+			err = copy.Copy(duplicate.InputFile, inputPath)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		case len(duplicate.InputData) > 0:
+			err = os.WriteFile(inputPath, duplicate.InputData, 0o644)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		default:
+			inputPath = ""
+		}
+		if inputPath != "" {
+			// The path in the InputFile field is expected to be relative
+			// to the project directory
+			occurrence.InputFile, err = filepath.Rel(projectDir, inputPath)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if len(saved.Occurrences) == 0 {
+			saved.Occurrences = saved.initialOccurrences()
+		}
+		saved.AddOccurrence(occurrence)
+		// The suppression rules are applied to each crash, so the
+		// finding is suppressed if its latest crash is suppressed
+		saved.Suppressed = duplicate.Suppressed
+		saved.SuppressionReason = duplicate.SuppressionReason
+		err = saved.saveJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		f.Occurrences = saved.Occurrences
+		f.Suppressed = saved.Suppressed
+		f.SuppressionReason = saved.SuppressionReason
+		return nil
+	})
+}
+
+// SaveMinimizedInput copies the minimized crashing input to the finding
+// directory, next to the original crashing input, and records it in the
+// JSON file of the finding.
+func (f *Finding) SaveMinimizedInput(projectDir, inputPath string) error {
+	return f.withLock(projectDir, func() error {
+		findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
+		jsonPath := filepath.Join(findingDir, nameJSONFile)
+
+		saved, err := loadJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+
+		minimizedInputPath := filepath.Join(findingDir, nameMinimizedInput)
+		err = copy.Copy(inputPath, minimizedInputPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// The path in the MinimizedInputFile field is expected to be
+		// relative to the project directory
+		saved.MinimizedInputFile, err = filepath.Rel(projectDir, minimizedInputPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = saved.saveJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		f.MinimizedInputFile = saved.MinimizedInputFile
+		return nil
+	})
+}
+
+// SaveFirstBadCommit records the first commit in which the crash occurs
+// in the JSON file of the finding.
+func (f *Finding) SaveFirstBadCommit(projectDir, commit string) error {
+	return f.withLock(projectDir, func() error {
+		jsonPath := filepath.Join(projectDir, nameFindingsDir, f.Name, nameJSONFile)
+		saved, err := loadJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		saved.FirstBadCommit = commit
+		err = saved.saveJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		f.FirstBadCommit = commit
+		return nil
+	})
+}
+
+// SaveReproducibility records the reproducibility of the finding in its
+// JSON file.
+func (f *Finding) SaveReproducibility(projectDir string, r *Reproducibility) error {
+	return f.withLock(projectDir, func() error {
+		jsonPath := filepath.Join(projectDir, nameFindingsDir, f.Name, nameJSONFile)
+		saved, err := loadJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		saved.Reproducibility = r
+		err = saved.saveJSON(jsonPath)
+		if err != nil {
+			return err
+		}
+		f.Reproducibility = r
+		return nil
+	})
+}
+
+// withLock runs the function while holding a file lock on the finding
+// directory, to avoid races with other cifuzz processes running in
+// parallel.
+func (f *Finding) withLock(projectDir string, fn func() error) error {
+	return withFileLock(filepath.Join(projectDir, nameFindingsDir, f.Name, lockFile), fn)
+}
+
+// WithSignatureLock calls fn while holding a lock which is shared by
+// all cifuzz processes using the same project directory. It's used to
+// look up the finding with the signature of a new finding and save the
+// new finding atomically, so that concurrent fuzzing runs don't create
+// multiple findings with the same signature.
+func WithSignatureLock(projectDir string, fn func() error) error {
+	return withFileLock(filepath.Join(projectDir, nameFindingsDir, signatureLockFile), fn)
+}
+
+func withFileLock(lockFile string, fn func() error) error {
+	err := os.MkdirAll(filepath.Dir(lockFile), 0o755)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	lockFile := filepath.Join(findingDir, lockFile)
 	mutex, err := filemutex.New(lockFile)
 	if err != nil {
 		return errors.WithStack(err)
@@ -204,8 +537,7 @@ func (f *Finding) CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir string
 		return errors.WithStack(err)
 	}
 
-	// Actually copy the input file
-	err = f.copyInputFile(projectDir, seedCorpusDir)
+	err = fn()
 
 	// Release the file lock
 	unlockErr := mutex.Close()
@@ -230,19 +562,10 @@ func (f *Finding) copyInputFile(projectDir, seedCorpusDir string) error {
 		return errors.WithStack(err)
 	}
 
-	// Copy the input file to the seed corpus dir.
-	err = os.MkdirAll(seedCorpusDir, 0o755)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	// Different inputs can result in the same finding, so we append the
-	// original basename to avoid basename collisions.
-	f.seedPath = filepath.Join(seedCorpusDir, f.Name+"-"+filepath.Base(f.InputFile))
-	err = copy.Copy(f.InputFile, f.seedPath)
+	err = f.CopyInputFileToSeedCorpus(seedCorpusDir)
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
-	log.Debugf("Copied input file from %s to %s", f.InputFile, f.seedPath)
 
 	// Replace the old filename in the finding logs. Replace it with the
 	// relative path to not leak the directory structure of the current
@@ -270,6 +593,26 @@ func (f *Finding) copyInputFile(projectDir, seedCorpusDir string) error {
 	return nil
 }
 
+// CopyInputFileToSeedCorpus copies the input file to the seed corpus
+// directory, without adding it to the finding directory. It's used for
+// the inputs of findings which are recorded as duplicates of another
+// finding.
+func (f *Finding) CopyInputFileToSeedCorpus(seedCorpusDir string) error {
+	err := os.MkdirAll(seedCorpusDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Different inputs can result in the same finding, so we append the
+	// original basename to avoid basename collisions.
+	f.seedPath = filepath.Join(seedCorpusDir, f.Name+"-"+filepath.Base(f.InputFile))
+	err = copy.Copy(f.InputFile, f.seedPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Debugf("Copied input file from %s to %s", f.InputFile, f.seedPath)
+	return nil
+}
+
 func (f *Finding) SourceLocation() string {
 	if f.StackTrace != nil && len(f.StackTrace) > 0 {
 		stackFrame := f.StackTrace[0]
@@ -347,6 +690,10 @@ func LocalFindings(projectDir string) ([]*Finding, error) {
 
 	var res []*Finding
 	for _, e := range entries {
+		if !e.IsDir() {
+			// Skip the lock files
+			continue
+		}
 		f, err := LoadFinding(projectDir, e.Name())
 		if err != nil {
 			return nil, err
@@ -369,6 +716,61 @@ func LocalFindings(projectDir string) ([]*Finding, error) {
 func LoadFinding(projectDir, findingName string) (*Finding, error) {
 	findingDir := filepath.Join(projectDir, nameFindingsDir, findingName)
 	jsonPath := filepath.Join(findingDir, nameJSONFile)
+	f, err := loadJSON(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Origin = "Local"
+	err = f.EnhanceWithErrorDetails()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// FindingWithSignature returns the oldest local finding which has the
+// specified crash signature, or nil if there is no such finding.
+func FindingWithSignature(projectDir, signature string) (*Finding, error) {
+	if signature == "" {
+		return nil, nil
+	}
+
+	findingsDir := filepath.Join(projectDir, nameFindingsDir)
+	entries, err := os.ReadDir(findingsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var res *Finding
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		f, err := loadJSON(filepath.Join(findingsDir, e.Name(), nameJSONFile))
+		if IsNotExistError(err) {
+			// The finding directory is currently being created by
+			// another cifuzz process
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.Signature != signature {
+			continue
+		}
+		if res == nil || f.CreatedAt.Before(res.CreatedAt) {
+			res = f
+		}
+	}
+	return res, nil
+}
+
+func loadJSON(jsonPath string) (*Finding, error) {
 	bytes, err := os.ReadFile(jsonPath)
 	if os.IsNotExist(err) {
 		return nil, WrapNotExistError(err)
@@ -381,23 +783,18 @@ func LoadFinding(projectDir, findingName string) (*Finding, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-
-	f.Origin = "Local"
-	err = f.EnhanceWithErrorDetails()
-	if err != nil {
-		return nil, err
-	}
-
 	return &f, nil
 }
 
 // EnhanceWithErrorDetails adds more details to the finding by parsing the
-// error details file.
+// error details file. The severity from the error details is adjusted
+// according to the exploitability of the finding.
 func (f *Finding) EnhanceWithErrorDetails() error {
 	errorDetails, err := ErrorDetailsCollection()
 	if err != nil {
 		return err
 	}
+	f.Exploitability = ClassifyExploitability(f.Logs)
 	for _, d := range errorDetails {
 		if (f.MoreDetails != nil && f.MoreDetails.ID == d.ID) ||
 			strings.Contains(
@@ -415,6 +812,9 @@ func (f *Finding) EnhanceWithErrorDetails() error {
 			if originalID != "" {
 				f.MoreDetails.ID = originalID
 			}
+			if f.Exploitability != nil {
+				f.MoreDetails.Severity = f.Exploitability.AdjustSeverity(f.MoreDetails.Severity)
+			}
 			return nil
 		}
 	}
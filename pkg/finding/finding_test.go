@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,6 +27,8 @@ func TestMain(m *testing.M) {
 	}
 
 	runfiles.Finder = runfiles.RunfilesFinderImpl{InstallDir: sourceDir}
+
+	m.Run()
 }
 
 func TestFinding_Save_LoadFinding(t *testing.T) {
@@ -100,6 +103,9 @@ func TestGetLocalFindings(t *testing.T) {
 
 	err := finding.Save(testBaseDir)
 	require.NoError(t, err)
+	// The signature lock file in the findings directory is ignored
+	err = WithSignatureLock(testBaseDir, func() error { return nil })
+	require.NoError(t, err)
 
 	// Check that the finding is listed
 	findings, err := LocalFindings(testBaseDir)
@@ -108,6 +114,140 @@ func TestGetLocalFindings(t *testing.T) {
 	require.Equal(t, finding, findings[0])
 }
 
+func TestFinding_AddDuplicate(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	finding := testFinding()
+	finding.Signature = "heap_buffer_overflow|exploreMe@src/explore_me.cpp"
+	err := finding.Save(testBaseDir)
+	require.NoError(t, err)
+
+	f, err := FindingWithSignature(testBaseDir, finding.Signature)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	assert.Equal(t, finding.Name, f.Name)
+	f, err = FindingWithSignature(testBaseDir, "other")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	duplicate := &Finding{Name: "duplicate-name", FuzzTest: "my_fuzz_test", InputData: []byte("other input")}
+	err = finding.AddDuplicate(testBaseDir, duplicate, &Occurrence{Name: duplicate.Name, FuzzTest: duplicate.FuzzTest})
+	require.NoError(t, err)
+	require.Len(t, finding.Occurrences, 1)
+	assert.Equal(t, "duplicate-name", finding.Occurrences[0].Name)
+	assert.Equal(t, "my_fuzz_test", finding.Occurrences[0].FuzzTest)
+	input, err := os.ReadFile(filepath.Join(testBaseDir, finding.Occurrences[0].InputFile))
+	require.NoError(t, err)
+	assert.Equal(t, "other input", string(input))
+
+	// Check that the occurrence was stored in the JSON file
+	f, err = FindingWithSignature(testBaseDir, finding.Signature)
+	require.NoError(t, err)
+	assert.Equal(t, finding.Occurrences, f.Occurrences)
+}
+
+func TestFinding_Save_History(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	firstSeen := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(48 * time.Hour)
+
+	finding := testFinding()
+	finding.CreatedAt = firstSeen
+	finding.AddOccurrence(&Occurrence{Name: finding.Name, CreatedAt: firstSeen, GitCommit: "1111", GitBranch: "main"})
+	err := finding.Save(testBaseDir)
+	require.NoError(t, err)
+
+	// Saving the same finding again adds the new occurrence to the
+	// history and keeps the original creation date
+	rediscovered := testFinding()
+	rediscovered.CreatedAt = lastSeen
+	rediscovered.AddOccurrence(&Occurrence{Name: finding.Name, CreatedAt: lastSeen, GitCommit: "2222", GitBranch: "feature"})
+	err = rediscovered.Save(testBaseDir)
+	require.NoError(t, err)
+
+	saved, err := loadJSON(filepath.Join(testBaseDir, nameFindingsDir, finding.Name, nameJSONFile))
+	require.NoError(t, err)
+	assert.True(t, saved.CreatedAt.Equal(firstSeen))
+	assert.True(t, saved.FirstSeen().Equal(firstSeen))
+	assert.True(t, saved.LastSeen().Equal(lastSeen))
+	assert.Equal(t, 2, saved.Count())
+	assert.Equal(t, "1111", saved.Occurrences[0].GitCommit)
+	assert.Equal(t, "feature", saved.Occurrences[1].GitBranch)
+
+	// Saving a loaded finding doesn't duplicate its occurrences
+	err = saved.Save(testBaseDir)
+	require.NoError(t, err)
+	saved, err = loadJSON(filepath.Join(testBaseDir, nameFindingsDir, finding.Name, nameJSONFile))
+	require.NoError(t, err)
+	assert.Equal(t, 2, saved.Count())
+}
+
+func TestFinding_SaveMinimizedInput(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	finding := testFinding()
+	err := finding.Save(testBaseDir)
+	require.NoError(t, err)
+
+	minimizedInput := filepath.Join(testBaseDir, "minimized")
+	err = os.WriteFile(minimizedInput, []byte("in"), 0o644)
+	require.NoError(t, err)
+	err = finding.SaveMinimizedInput(testBaseDir, minimizedInput)
+	require.NoError(t, err)
+	expectedPath := filepath.Join(nameFindingsDir, finding.Name, nameMinimizedInput)
+	assert.Equal(t, expectedPath, finding.MinimizedInputFile)
+	input, err := os.ReadFile(filepath.Join(testBaseDir, finding.MinimizedInputFile))
+	require.NoError(t, err)
+	assert.Equal(t, "in", string(input))
+
+	// Saving the finding again keeps the minimized input
+	err = testFinding().Save(testBaseDir)
+	require.NoError(t, err)
+	saved, err := loadJSON(filepath.Join(testBaseDir, nameFindingsDir, finding.Name, nameJSONFile))
+	require.NoError(t, err)
+	assert.Equal(t, expectedPath, saved.MinimizedInputFile)
+}
+
+func TestFinding_SaveFirstBadCommit(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	finding := testFinding()
+	err := finding.Save(testBaseDir)
+	require.NoError(t, err)
+
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	err = finding.SaveFirstBadCommit(testBaseDir, commit)
+	require.NoError(t, err)
+	assert.Equal(t, commit, finding.FirstBadCommit)
+
+	// Saving the finding again keeps the first bad commit
+	err = testFinding().Save(testBaseDir)
+	require.NoError(t, err)
+	saved, err := loadJSON(filepath.Join(testBaseDir, nameFindingsDir, finding.Name, nameJSONFile))
+	require.NoError(t, err)
+	assert.Equal(t, commit, saved.FirstBadCommit)
+}
+
+func TestFinding_SaveReproducibility(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	finding := testFinding()
+	err := finding.Save(testBaseDir)
+	require.NoError(t, err)
+
+	r := NewReproducibility(4, 3)
+	assert.Equal(t, 0.75, r.Rate)
+	assert.True(t, r.IsFlaky())
+	assert.Equal(t, "75% (3 of 4 runs)", r.String())
+	err = finding.SaveReproducibility(testBaseDir, r)
+	require.NoError(t, err)
+
+	// Saving the finding again keeps the reproducibility
+	err = testFinding().Save(testBaseDir)
+	require.NoError(t, err)
+	saved, err := loadJSON(filepath.Join(testBaseDir, nameFindingsDir, finding.Name, nameJSONFile))
+	require.NoError(t, err)
+	require.NotNil(t, saved.Reproducibility)
+	assert.Equal(t, 3, saved.Reproducibility.Reproduced)
+	assert.Equal(t, 4, saved.Reproducibility.Runs)
+}
+
 func testFinding() *Finding {
 	return &Finding{
 		Origin: "Local",
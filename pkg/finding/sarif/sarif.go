@@ -0,0 +1,290 @@
+// Package sarif converts findings to the Static Analysis Results
+// Interchange Format (SARIF) 2.1.0, which is understood by code scanning
+// tools like GitHub code scanning.
+//
+// Only the subset of the format which is needed to represent findings
+// is implemented, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+package sarif
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName           = "cifuzz"
+	toolInformationURI = "https://github.com/CodeIntelligenceTesting/cifuzz"
+	// The URI base ID of source files which are relative to the project
+	// directory
+	srcRootURIBaseID = "%SRCROOT%"
+	// The key of the partial fingerprint which contains the crash
+	// signature of the finding, see the signature package
+	signatureFingerprint = "cifuzzSignature/v1"
+)
+
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []*Run `json:"runs"`
+}
+
+type Run struct {
+	Tool    *Tool     `json:"tool"`
+	Results []*Result `json:"results"`
+}
+
+type Tool struct {
+	Driver *Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string  `json:"name"`
+	Version        string  `json:"version,omitempty"`
+	InformationURI string  `json:"informationUri,omitempty"`
+	Rules          []*Rule `json:"rules,omitempty"`
+}
+
+// Rule describes a type of finding, e.g. a heap buffer overflow.
+type Rule struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name,omitempty"`
+	ShortDescription *Message        `json:"shortDescription,omitempty"`
+	FullDescription  *Message        `json:"fullDescription,omitempty"`
+	Help             *Message        `json:"help,omitempty"`
+	HelpURI          string          `json:"helpUri,omitempty"`
+	Properties       *RuleProperties `json:"properties,omitempty"`
+}
+
+type RuleProperties struct {
+	// The severity score between 0.0 and 10.0, as a string. This is the
+	// property which is used by GitHub code scanning to rank security
+	// findings.
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+// Result is a single finding.
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             *Message          `json:"message"`
+	Locations           []*Location       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Suppressions        []*Suppression    `json:"suppressions,omitempty"`
+	Properties          *ResultProperties `json:"properties,omitempty"`
+}
+
+// ResultProperties contains the properties of a finding which have no
+// equivalent in SARIF.
+type ResultProperties struct {
+	FindingName     string                   `json:"findingName,omitempty"`
+	FuzzTest        string                   `json:"fuzzTest,omitempty"`
+	SeverityLevel   finding.SeverityLevel    `json:"severityLevel,omitempty"`
+	SeverityScore   float32                  `json:"severityScore,omitempty"`
+	Exploitability  *finding.Exploitability  `json:"exploitability,omitempty"`
+	Reproducibility *finding.Reproducibility `json:"reproducibility,omitempty"`
+	FirstBadCommit  string                   `json:"firstBadCommit,omitempty"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation *ArtifactLocation `json:"artifactLocation"`
+	Region           *Region           `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type Region struct {
+	StartLine   uint32 `json:"startLine,omitempty"`
+	StartColumn uint32 `json:"startColumn,omitempty"`
+}
+
+type Suppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// FromFindings returns a SARIF log with a single run of the given
+// version of cifuzz, which contains a result for each of the findings.
+// Findings of the same type share a rule, whose security severity is
+// the highest severity score of those findings. The severity already
+// includes the exploitability of native memory errors, which is added
+// to the properties of the result as well.
+func FromFindings(findings []*finding.Finding, toolVersion string) *Log {
+	driver := &Driver{
+		Name:           toolName,
+		Version:        toolVersion,
+		InformationURI: toolInformationURI,
+	}
+	// Always marshal the results as an array, even if it's empty,
+	// because the results are required by code scanning tools
+	results := []*Result{}
+
+	ruleIndices := map[string]int{}
+	// The highest severity score of the findings of each rule
+	scores := map[string]float32{}
+	for _, f := range findings {
+		ruleID := ruleID(f)
+		ruleIndex, ok := ruleIndices[ruleID]
+		if !ok {
+			ruleIndex = len(driver.Rules)
+			ruleIndices[ruleID] = ruleIndex
+			driver.Rules = append(driver.Rules, newRule(ruleID, f))
+		}
+		if f.MoreDetails != nil && f.MoreDetails.Severity != nil {
+			score, ok := scores[ruleID]
+			if !ok || f.MoreDetails.Severity.Score > score {
+				scores[ruleID] = f.MoreDetails.Severity.Score
+			}
+		}
+
+		results = append(results, newResult(f, ruleID, ruleIndex))
+	}
+	for ruleID, score := range scores {
+		driver.Rules[ruleIndices[ruleID]].Properties.SecuritySeverity = fmt.Sprintf("%.1f", score)
+	}
+
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs: []*Run{{
+			Tool:    &Tool{Driver: driver},
+			Results: results,
+		}},
+	}
+}
+
+// ruleID returns the ID of the error details of the finding, or a
+// normalized form of its short description if it has no error details.
+func ruleID(f *finding.Finding) string {
+	if f.MoreDetails != nil && f.MoreDetails.ID != "" {
+		return f.MoreDetails.ID
+	}
+	description := f.ShortDescriptionColumns()[0]
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(description)), " ", "_")
+}
+
+func newRule(id string, f *finding.Finding) *Rule {
+	rule := &Rule{
+		ID:         id,
+		Properties: &RuleProperties{Tags: []string{"security"}},
+	}
+	details := f.MoreDetails
+	if details == nil {
+		return rule
+	}
+
+	rule.Name = details.Name
+	if details.Name != "" {
+		rule.ShortDescription = &Message{Text: details.Name}
+	}
+	if details.Description != "" {
+		rule.FullDescription = &Message{Text: details.Description}
+	}
+	if details.Mitigation != "" {
+		rule.Help = &Message{Text: details.Mitigation}
+	}
+	if len(details.Links) > 0 {
+		rule.HelpURI = details.Links[0].URL
+	}
+	if details.CweDetails != nil && details.CweDetails.ID != 0 {
+		rule.Properties.Tags = append(rule.Properties.Tags, fmt.Sprintf("external/cwe/cwe-%d", details.CweDetails.ID))
+	}
+	return rule
+}
+
+func newResult(f *finding.Finding, ruleID string, ruleIndex int) *Result {
+	result := &Result{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     level(f),
+		Message:   &Message{Text: f.ShortDescriptionWithName()},
+		Properties: &ResultProperties{
+			FindingName:     f.Name,
+			FuzzTest:        f.FuzzTest,
+			Exploitability:  f.Exploitability,
+			Reproducibility: f.Reproducibility,
+			FirstBadCommit:  f.FirstBadCommit,
+		},
+	}
+	if f.MoreDetails != nil && f.MoreDetails.Severity != nil {
+		result.Properties.SeverityLevel = f.MoreDetails.Severity.Level
+		result.Properties.SeverityScore = f.MoreDetails.Severity.Score
+	}
+
+	if location := location(f); location != nil {
+		result.Locations = []*Location{location}
+	}
+	if f.Signature != "" {
+		result.PartialFingerprints = map[string]string{signatureFingerprint: f.Signature}
+	}
+	if f.Suppressed {
+		result.Suppressions = []*Suppression{{
+			Kind:          "external",
+			Justification: f.SuppressionReason,
+		}}
+	}
+	return result
+}
+
+// location returns the location of the top frame of the stack trace of
+// the finding, or nil if the finding has no stack trace.
+func location(f *finding.Finding) *Location {
+	if len(f.StackTrace) == 0 || f.StackTrace[0].SourceFile == "" {
+		return nil
+	}
+	frame := f.StackTrace[0]
+
+	artifactLocation := &ArtifactLocation{URI: filepath.ToSlash(frame.SourceFile)}
+	if filepath.IsAbs(frame.SourceFile) {
+		artifactLocation.URI = "file://" + artifactLocation.URI
+	} else {
+		artifactLocation.URIBaseID = srcRootURIBaseID
+	}
+
+	var region *Region
+	if frame.Line != 0 {
+		region = &Region{StartLine: frame.Line, StartColumn: frame.Column}
+	}
+
+	return &Location{PhysicalLocation: &PhysicalLocation{
+		ArtifactLocation: artifactLocation,
+		Region:           region,
+	}}
+}
+
+// level returns the SARIF level which corresponds to the severity of
+// the finding. Findings without a severity are reported as errors,
+// because every finding is a crash of the fuzz test.
+func level(f *finding.Finding) string {
+	if f.MoreDetails == nil || f.MoreDetails.Severity == nil {
+		return "error"
+	}
+	// The levels in the error details are not upper case
+	switch finding.SeverityLevel(strings.ToUpper(string(f.MoreDetails.Severity.Level))) {
+	case finding.SeverityLevelMedium:
+		return "warning"
+	case finding.SeverityLevelLow:
+		return "note"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,114 @@
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestFromFindings(t *testing.T) {
+	heapOverflowDetails := func(score float32, level finding.SeverityLevel) *finding.ErrorDetails {
+		return &finding.ErrorDetails{
+			ID:          "heap_buffer_overflow",
+			Name:        "Heap Buffer Overflow",
+			Description: "A heap buffer overflow",
+			Mitigation:  "Check the bounds",
+			Severity:    &finding.Severity{Level: level, Score: score},
+			Links:       []finding.Link{{Description: "CWE", URL: "https://cwe.mitre.org/data/definitions/122.html"}},
+			CweDetails:  &finding.ExternalDetail{ID: 122},
+		}
+	}
+
+	likely := &finding.Exploitability{
+		Rating:  finding.ExploitabilityLikely,
+		BugType: "heap-buffer-overflow",
+		Access:  "write",
+	}
+	findings := []*finding.Finding{
+		{
+			Name:           "likely_finding",
+			Type:           finding.ErrorTypeCrash,
+			Details:        "heap-buffer-overflow on address 0x1234",
+			MoreDetails:    heapOverflowDetails(10, finding.SeverityLevelCritical),
+			FuzzTest:       "my_fuzz_test",
+			Signature:      "signature",
+			Exploitability: likely,
+			StackTrace: []*stacktrace.StackFrame{
+				{SourceFile: "src/parser.c", Line: 12, Column: 3, Function: "parse"},
+			},
+		},
+		{
+			Name:              "suppressed_finding",
+			Type:              finding.ErrorTypeCrash,
+			Details:           "heap-buffer-overflow on address 0x5678",
+			MoreDetails:       heapOverflowDetails(6, "Medium"),
+			Suppressed:        true,
+			SuppressionReason: "known issue",
+		},
+		{
+			Name:    "unknown_finding",
+			Type:    finding.ErrorTypeCrash,
+			Details: "deadly signal",
+		},
+	}
+
+	log := FromFindings(findings, "1.2.3")
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "cifuzz", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+
+	// Findings of the same type share a rule with the highest severity
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	rule := run.Tool.Driver.Rules[0]
+	assert.Equal(t, "heap_buffer_overflow", rule.ID)
+	assert.Equal(t, "10.0", rule.Properties.SecuritySeverity)
+	assert.Equal(t, []string{"security", "external/cwe/cwe-122"}, rule.Properties.Tags)
+	assert.Equal(t, "https://cwe.mitre.org/data/definitions/122.html", rule.HelpURI)
+	assert.Empty(t, run.Tool.Driver.Rules[1].Properties.SecuritySeverity)
+
+	require.Len(t, run.Results, 3)
+
+	result := run.Results[0]
+	assert.Equal(t, "heap_buffer_overflow", result.RuleID)
+	assert.Equal(t, 0, result.RuleIndex)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, likely, result.Properties.Exploitability)
+	assert.Equal(t, "my_fuzz_test", result.Properties.FuzzTest)
+	assert.Equal(t, map[string]string{signatureFingerprint: "signature"}, result.PartialFingerprints)
+	require.Len(t, result.Locations, 1)
+	assert.Equal(t, &PhysicalLocation{
+		ArtifactLocation: &ArtifactLocation{URI: "src/parser.c", URIBaseID: srcRootURIBaseID},
+		Region:           &Region{StartLine: 12, StartColumn: 3},
+	}, result.Locations[0].PhysicalLocation)
+
+	result = run.Results[1]
+	assert.Equal(t, 0, result.RuleIndex)
+	// The severity levels of the error details are not upper case
+	assert.Equal(t, "warning", result.Level)
+	assert.Equal(t, []*Suppression{{Kind: "external", Justification: "known issue"}}, result.Suppressions)
+	assert.Empty(t, result.Locations)
+
+	result = run.Results[2]
+	assert.Equal(t, 1, result.RuleIndex)
+	assert.Equal(t, "error", result.Level)
+	assert.Nil(t, result.Properties.Exploitability)
+
+	// Check that the exploitability is part of the marshalled log
+	out, err := json.Marshal(log)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"exploitability":{"rating":"likely"`)
+	assert.Contains(t, string(out), `"security-severity":"10.0"`)
+}
+
+func TestFromFindings_NoFindings(t *testing.T) {
+	out, err := json.Marshal(FromFindings(nil, "dev"))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"results":[]`)
+	assert.Contains(t, string(out), `"version":"2.1.0"`)
+}
@@ -0,0 +1,98 @@
+package signature
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/errorid"
+)
+
+// DefaultDepth is the number of stack frames which are included in the
+// crash signature if no depth is configured.
+const DefaultDepth = 3
+
+type Options struct {
+	// The number of stack frames which are included in the signature
+	Depth int
+	// Stack frames with a function name or source file matching one of
+	// these patterns are not included in the signature
+	IgnorePatterns []*regexp.Regexp
+}
+
+// NewOptions returns options with the specified depth and ignore
+// patterns. A depth of 0 selects the DefaultDepth.
+func NewOptions(depth int, ignorePatterns []string) (*Options, error) {
+	if depth < 0 {
+		return nil, errors.Errorf("invalid crash signature depth %d: must not be negative", depth)
+	}
+	if depth == 0 {
+		depth = DefaultDepth
+	}
+
+	opts := &Options{Depth: depth}
+	for _, pattern := range ignorePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid crash signature ignore pattern %q", pattern)
+		}
+		opts.IgnorePatterns = append(opts.IgnorePatterns, re)
+	}
+	return opts, nil
+}
+
+// ForFinding returns the crash signature of the finding, which is used to
+// detect findings that are caused by the same bug. It consists of the
+// error ID and the topmost stack frames in user code (the stack trace of
+// a finding only contains frames from source files in the project).
+//
+// In contrast to the finding name, the signature does not depend on the
+// crashing input and on the stack frames below the configured depth, so
+// that the same bug reached via a different input or a slightly different
+// call path results in the same signature. Line and column numbers are
+// not included either, to not produce a new signature when unrelated
+// changes shift the code.
+//
+// An empty string is returned if the finding has neither an error ID nor
+// a stack trace, because such a signature would match unrelated
+// findings.
+func ForFinding(f *finding.Finding, opts *Options) string {
+	if opts == nil {
+		opts = &Options{Depth: DefaultDepth}
+	}
+
+	var id string
+	if f.MoreDetails != nil && f.MoreDetails.ID != "" {
+		id = f.MoreDetails.ID
+	} else {
+		id = errorid.ForFinding(f)
+	}
+
+	var frames []string
+	for _, frame := range f.StackTrace {
+		if len(frames) == opts.Depth {
+			break
+		}
+		if ignored(frame.Function, frame.SourceFile, opts.IgnorePatterns) {
+			continue
+		}
+		frames = append(frames, fmt.Sprintf("%s@%s", frame.Function, frame.SourceFile))
+	}
+
+	if id == "" && len(frames) == 0 {
+		return ""
+	}
+	return strings.Join(append([]string{id}, frames...), "|")
+}
+
+func ignored(function, sourceFile string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(function) || re.MatchString(sourceFile) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestForFinding(t *testing.T) {
+	stackTrace := []*stacktrace.StackFrame{
+		{Function: "parse", SourceFile: "src/parser.cpp", Line: 12, Column: 3},
+		{Function: "std::vector<int>::at", SourceFile: "include/vector.h", Line: 40},
+		{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 7},
+		{Function: "handle", SourceFile: "src/handler.cpp", Line: 21},
+		{Function: "LLVMFuzzerTestOneInputNoReturn", SourceFile: "my_fuzz_test.cpp", Line: 18},
+	}
+	f := &finding.Finding{
+		Details:     "heap-buffer-overflow on address 0x602000000e31",
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace:  stackTrace,
+	}
+
+	opts, err := NewOptions(0, nil)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"heap_buffer_overflow|parse@src/parser.cpp|std::vector<int>::at@include/vector.h|exploreMe@src/explore_me.cpp",
+		ForFinding(f, opts))
+
+	// The input, line numbers and frames below the depth don't matter
+	other := &finding.Finding{
+		Details:     f.Details,
+		MoreDetails: f.MoreDetails,
+		InputData:   []byte("other input"),
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "parse", SourceFile: "src/parser.cpp", Line: 13},
+			stackTrace[1],
+			stackTrace[2],
+			{Function: "otherCaller", SourceFile: "src/other.cpp", Line: 5},
+		},
+	}
+	assert.Equal(t, ForFinding(f, opts), ForFinding(other, opts))
+
+	// Ignored frames are skipped before the depth is applied
+	opts, err = NewOptions(2, []string{`^std::`})
+	require.NoError(t, err)
+	assert.Equal(t, "heap_buffer_overflow|parse@src/parser.cpp|exploreMe@src/explore_me.cpp", ForFinding(f, opts))
+	opts, err = NewOptions(1, []string{`^src/parser\.cpp$`})
+	require.NoError(t, err)
+	assert.Equal(t, "heap_buffer_overflow|std::vector<int>::at@include/vector.h", ForFinding(f, opts))
+
+	// The error ID is determined from the details if it's not set
+	f.MoreDetails = nil
+	assert.Equal(t, "heap_buffer_overflow|std::vector<int>::at@include/vector.h", ForFinding(f, opts))
+
+	// Findings without error ID and stack trace have no signature
+	assert.Empty(t, ForFinding(&finding.Finding{Details: "something unknown"}, opts))
+}
+
+func TestNewOptions(t *testing.T) {
+	_, err := NewOptions(-1, nil)
+	assert.Error(t, err)
+	_, err = NewOptions(3, []string{"("})
+	assert.Error(t, err)
+}
@@ -0,0 +1,223 @@
+package suppression
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/errorid"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+// FileName is the name of the file in the project directory which
+// contains a list of suppression rules. Rules can also be specified via
+// the "suppressions" setting in cifuzz.yaml.
+const FileName = ".cifuzz-suppressions"
+
+// The layout of the expiry date of a rule
+const dateLayout = "2006-01-02"
+
+// Rule suppresses the findings which match all of the patterns which are
+// set in the rule. Suppressed findings are still recorded, but they are
+// not reported as failures, not notified and not uploaded.
+//
+// The patterns are globs in which "*" matches any sequence of characters
+// except "/", "**" matches any sequence of characters and "?" matches
+// any single character except "/".
+type Rule struct {
+	// Glob pattern of the error ID, e.g. "heap_buffer_overflow"
+	ErrorID string `yaml:"error-id" mapstructure:"error-id"`
+	// Glob pattern of the function name of any frame in the stack trace
+	Function string `yaml:"function" mapstructure:"function"`
+	// Glob pattern of the source file of any frame in the stack trace,
+	// relative to the project directory, e.g. "third_party/**"
+	File string `yaml:"file" mapstructure:"file"`
+	// Glob pattern of the name of the fuzz test
+	FuzzTest string `yaml:"fuzz-test" mapstructure:"fuzz-test"`
+	// The date (YYYY-MM-DD) after which the rule no longer applies, so
+	// that suppressions are reviewed regularly
+	Expires string `yaml:"expires" mapstructure:"expires"`
+	// Why the findings are suppressed
+	Reason string `yaml:"reason" mapstructure:"reason"`
+
+	errorIDRegex  *regexp.Regexp
+	functionRegex *regexp.Regexp
+	fileRegex     *regexp.Regexp
+	fuzzTestRegex *regexp.Regexp
+	expires       time.Time
+}
+
+// Load returns the rules of the suppression file in the project
+// directory, if it exists, followed by the rules from cifuzz.yaml. An
+// error is returned if any of the rules is invalid. Expired rules are
+// logged, so that they are removed or renewed.
+func Load(projectDir string, configRules []*Rule) ([]*Rule, error) {
+	var rules []*Rule
+
+	suppressionsFile := filepath.Join(projectDir, FileName)
+	content, err := os.ReadFile(suppressionsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.WithStack(err)
+	}
+	if err == nil {
+		err = yaml.Unmarshal(content, &rules)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse %s", FileName)
+		}
+	}
+	rules = append(rules, configRules...)
+
+	for i, rule := range rules {
+		err = rule.validate()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Invalid suppression rule %d", i+1)
+		}
+		if rule.Expired(time.Now()) {
+			log.Warnf("Suppression rule (%s) expired on %s, matching findings are not suppressed anymore", rule, rule.Expires)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) validate() error {
+	if r.ErrorID == "" && r.Function == "" && r.File == "" && r.FuzzTest == "" {
+		return errors.New(`at least one of "error-id", "function", "file" and "fuzz-test" must be set`)
+	}
+	r.errorIDRegex = globRegex(r.ErrorID)
+	r.functionRegex = globRegex(r.Function)
+	r.fileRegex = globRegex(filepath.ToSlash(r.File))
+	r.fuzzTestRegex = globRegex(r.FuzzTest)
+	if r.Reason == "" {
+		return errors.New(`"reason" must be set`)
+	}
+	if r.Expires == "" {
+		return errors.New(`"expires" must be set`)
+	}
+	var err error
+	r.expires, err = time.ParseInLocation(dateLayout, r.Expires, time.Local)
+	if err != nil {
+		return errors.Errorf(`invalid date %q for "expires", expected format YYYY-MM-DD`, r.Expires)
+	}
+	return nil
+}
+
+// Expired returns true if the rule doesn't apply anymore at the given
+// time. A rule applies until the end of the day of its expiry date.
+func (r *Rule) Expired(now time.Time) bool {
+	return !now.Before(r.expires.AddDate(0, 0, 1))
+}
+
+// String returns a short description of the patterns of the rule.
+func (r *Rule) String() string {
+	var patterns []string
+	for _, p := range []struct{ key, pattern string }{
+		{"error-id", r.ErrorID},
+		{"function", r.Function},
+		{"file", r.File},
+		{"fuzz-test", r.FuzzTest},
+	} {
+		if p.pattern != "" {
+			patterns = append(patterns, p.key+"="+p.pattern)
+		}
+	}
+	return strings.Join(patterns, ", ")
+}
+
+// Matches returns true if all patterns of the rule match the finding,
+// regardless of whether the rule is expired.
+func (r *Rule) Matches(f *finding.Finding) bool {
+	if r.errorIDRegex != nil && !r.errorIDRegex.MatchString(errorID(f)) {
+		return false
+	}
+	if r.fuzzTestRegex != nil && !r.fuzzTestRegex.MatchString(f.FuzzTest) {
+		return false
+	}
+	if r.functionRegex != nil && !anyFrame(f, func(frame *stacktrace.StackFrame) bool {
+		return r.functionRegex.MatchString(frame.Function)
+	}) {
+		return false
+	}
+	if r.fileRegex != nil && !anyFrame(f, func(frame *stacktrace.StackFrame) bool {
+		return r.fileRegex.MatchString(filepath.ToSlash(frame.SourceFile))
+	}) {
+		return false
+	}
+	return true
+}
+
+// Match returns the first rule which suppresses the finding at the given
+// time, or nil if the finding is not suppressed.
+func Match(rules []*Rule, f *finding.Finding, now time.Time) *Rule {
+	for _, rule := range rules {
+		if !rule.Expired(now) && rule.Matches(f) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Apply marks the finding as suppressed if one of the rules matches it
+// at the given time and returns true in that case.
+func Apply(rules []*Rule, f *finding.Finding, now time.Time) bool {
+	rule := Match(rules, f, now)
+	if rule == nil {
+		f.Suppressed = false
+		f.SuppressionReason = ""
+		return false
+	}
+	f.Suppressed = true
+	f.SuppressionReason = rule.Reason
+	return true
+}
+
+func anyFrame(f *finding.Finding, matches func(*stacktrace.StackFrame) bool) bool {
+	for _, frame := range f.StackTrace {
+		if matches(frame) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegex returns a regular expression which matches the same strings
+// as the glob pattern, or nil if the pattern is empty.
+func globRegex(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			// Matches any number of directories, including none
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func errorID(f *finding.Finding) string {
+	if f.MoreDetails != nil && f.MoreDetails.ID != "" {
+		return f.MoreDetails.ID
+	}
+	return errorid.ForFinding(f)
+}
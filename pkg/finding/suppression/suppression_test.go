@@ -0,0 +1,117 @@
+package suppression
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestLoad(t *testing.T) {
+	projectDir := t.TempDir()
+
+	// No suppression file
+	rules, err := Load(projectDir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	err = os.WriteFile(filepath.Join(projectDir, FileName), []byte(`
+- error-id: timeout
+  fuzz-test: legacy_parser_fuzz_test
+  reason: Known timeouts in the legacy parser
+  expires: 2024-06-30
+`), 0o644)
+	require.NoError(t, err)
+	configRules := []*Rule{{File: "third_party/**", Reason: "Third-party code", Expires: "2024-12-31"}}
+	rules, err = Load(projectDir, configRules)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "timeout", rules[0].ErrorID)
+	assert.Equal(t, "2024-06-30", rules[0].Expires)
+	assert.Equal(t, "error-id=timeout, fuzz-test=legacy_parser_fuzz_test", rules[0].String())
+	assert.Equal(t, configRules[0], rules[1])
+
+	invalidRules := [][]*Rule{
+		{{Reason: "No pattern", Expires: "2024-12-31"}},
+		{{ErrorID: "timeout", Expires: "2024-12-31"}},
+		{{ErrorID: "timeout", Reason: "No expiry date"}},
+		{{ErrorID: "timeout", Reason: "Invalid expiry date", Expires: "31.12.2024"}},
+	}
+	for _, r := range invalidRules {
+		_, err = Load(projectDir, r)
+		assert.Error(t, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	f := &finding.Finding{
+		Name:        "focused_turing",
+		FuzzTest:    "my_fuzz_test",
+		Details:     "heap-buffer-overflow on address 0x602000000e31",
+		MoreDetails: &finding.ErrorDetails{ID: "heap_buffer_overflow"},
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "png_read_row", SourceFile: "third_party/libpng/pngread.c", Line: 12},
+			{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 7},
+		},
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		rule    *Rule
+		matches bool
+	}{
+		{&Rule{ErrorID: "heap_*"}, true},
+		{&Rule{ErrorID: "heap_use_after_free"}, false},
+		{&Rule{Function: "png_*"}, true},
+		{&Rule{Function: "explore*"}, true},
+		{&Rule{Function: "parse"}, false},
+		{&Rule{File: "third_party/**"}, true},
+		{&Rule{File: "**/pngread.c"}, true},
+		{&Rule{File: "third_party/*.c"}, false},
+		{&Rule{FuzzTest: "my_fuzz_test"}, true},
+		{&Rule{FuzzTest: "other_fuzz_test"}, false},
+		// All patterns of a rule must match
+		{&Rule{ErrorID: "heap_buffer_overflow", FuzzTest: "my_?uzz_test"}, true},
+		{&Rule{ErrorID: "heap_buffer_overflow", FuzzTest: "other_fuzz_test"}, false},
+	}
+	for _, tt := range tests {
+		tt.rule.Reason = "Test"
+		tt.rule.Expires = "2024-12-31"
+		require.NoError(t, tt.rule.validate())
+		assert.Equal(t, tt.matches, Match([]*Rule{tt.rule}, f, now) != nil, "rule: %s", tt.rule)
+	}
+
+	// The error ID is determined from the details if it's not set
+	f.MoreDetails = nil
+	rule := &Rule{ErrorID: "heap_buffer_overflow", Reason: "Test", Expires: "2024-12-31"}
+	require.NoError(t, rule.validate())
+	assert.Equal(t, rule, Match([]*Rule{rule}, f, now))
+
+	// Rules apply until the end of the day of their expiry date
+	rule.Expires = "2024-06-01"
+	require.NoError(t, rule.validate())
+	assert.NotNil(t, Match([]*Rule{rule}, f, time.Date(2024, 6, 1, 23, 59, 0, 0, time.Local)))
+	assert.Nil(t, Match([]*Rule{rule}, f, time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local)))
+}
+
+func TestApply(t *testing.T) {
+	rule := &Rule{FuzzTest: "my_fuzz_test", Reason: "Flaky fuzz test", Expires: "2024-12-31"}
+	require.NoError(t, rule.validate())
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+
+	f := &finding.Finding{FuzzTest: "my_fuzz_test"}
+	assert.True(t, Apply([]*Rule{rule}, f, now))
+	assert.True(t, f.Suppressed)
+	assert.Equal(t, "Flaky fuzz test", f.SuppressionReason)
+
+	f.FuzzTest = "other_fuzz_test"
+	assert.False(t, Apply([]*Rule{rule}, f, now))
+	assert.False(t, f.Suppressed)
+	assert.Empty(t, f.SuppressionReason)
+}
@@ -0,0 +1,119 @@
+package coverage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// generateLCOVReport generates an lcov report of the given number of
+// source files, which is sorted by source file name.
+func generateLCOVReport(files, linesPerFile int) string {
+	sb := &strings.Builder{}
+	for i := 0; i < files; i++ {
+		fmt.Fprintf(sb, "SF:src/dir%03d/file%06d.cpp\n", i/100, i)
+		for l := 1; l <= linesPerFile; l += 10 {
+			fmt.Fprintf(sb, "FN:%d,function_%d_%d\n", l, i, l)
+			fmt.Fprintf(sb, "FNDA:%d,function_%d_%d\n", l%3, i, l)
+		}
+		fmt.Fprintf(sb, "FNF:%d\nFNH:%d\n", linesPerFile/10, linesPerFile/15)
+		for l := 1; l <= linesPerFile; l++ {
+			fmt.Fprintf(sb, "DA:%d,%d\n", l, l%4)
+		}
+		fmt.Fprintf(sb, "LF:%d\nLH:%d\n", linesPerFile, linesPerFile*3/4)
+		for l := 5; l <= linesPerFile; l += 5 {
+			fmt.Fprintf(sb, "BRDA:%d,0,0,%d\nBRDA:%d,0,1,-\n", l, l, l)
+		}
+		fmt.Fprintf(sb, "BRF:%d\nBRH:%d\n", linesPerFile/5*2, linesPerFile/5)
+		sb.WriteString("end_of_record\n")
+	}
+	return sb.String()
+}
+
+var (
+	generatedReport     string
+	benchmarkReportOnce sync.Once
+)
+
+// getBenchmarkReport lazily generates the report, so that it's not
+// generated when only the tests are run.
+func getBenchmarkReport() string {
+	benchmarkReportOnce.Do(func() {
+		generatedReport = generateLCOVReport(2000, 500)
+	})
+	return generatedReport
+}
+
+func BenchmarkParseLCOVFileIntoLCOVReport(b *testing.B) {
+	benchmarkReport := getBenchmarkReport()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkReport)))
+	for i := 0; i < b.N; i++ {
+		_, err := ParseLCOVFileIntoLCOVReport(strings.NewReader(benchmarkReport))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLCOVReportIntoSummary(b *testing.B) {
+	benchmarkReport := getBenchmarkReport()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkReport)))
+	for i := 0; i < b.N; i++ {
+		_, err := ParseLCOVReportIntoSummary(strings.NewReader(benchmarkReport))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLCOVWriter(b *testing.B) {
+	benchmarkReport := getBenchmarkReport()
+	report, err := ParseLCOVFileIntoLCOVReport(strings.NewReader(benchmarkReport))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkReport)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := NewLCOVWriter(io.Discard)
+		for _, sf := range report.SourceFiles {
+			err = w.Write(sf)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		err = w.Flush()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMergeLCOV(b *testing.B) {
+	benchmarkReport := getBenchmarkReport()
+	b.ReportAllocs()
+	b.SetBytes(int64(2 * len(benchmarkReport)))
+	for i := 0; i < b.N; i++ {
+		err := MergeLCOV(io.Discard, strings.NewReader(benchmarkReport), strings.NewReader(benchmarkReport))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertLCOVToCobertura(b *testing.B) {
+	benchmarkReport := getBenchmarkReport()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkReport)))
+	for i := 0; i < b.N; i++ {
+		err := ConvertLCOVToCobertura(strings.NewReader(benchmarkReport), io.Discard, "")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
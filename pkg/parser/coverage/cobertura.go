@@ -0,0 +1,207 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// ConvertLCOVToCobertura converts an lcov report into a Cobertura XML
+// report, which is supported by many CI systems. Source files of the
+// same directory are grouped into a package, each source file becomes
+// a class.
+//
+// The totals of the report are attributes of the root element, so the
+// packages are first written to a temporary file while the totals are
+// accumulated. Only the classes of the current package are held in
+// memory.
+func ConvertLCOVToCobertura(in io.Reader, out io.Writer, sourceDir string) error {
+	tmpFile, err := os.CreateTemp("", "cobertura-packages-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpFile.Name())
+	defer tmpFile.Close()
+
+	packages := bufio.NewWriter(tmpFile)
+	total := Overview{}
+	pkg := &coberturaPackage{}
+
+	r := NewLCOVReader(in)
+	for {
+		sf, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Dir(toSlash(sf.Name))
+		if pkg.name != name && pkg.classes.Len() > 0 {
+			err = pkg.writeTo(packages)
+			if err != nil {
+				return err
+			}
+			pkg = &coberturaPackage{}
+		}
+		pkg.name = name
+
+		overview := sourceFileOverview(sf)
+		pkg.overview.add(&overview)
+		total.add(&overview)
+		writeCoberturaClass(&pkg.classes, sf, &overview)
+	}
+	if pkg.classes.Len() > 0 {
+		err = pkg.writeTo(packages)
+		if err != nil {
+			return err
+		}
+	}
+	err = packages.Flush()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	w := bufio.NewWriter(out)
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`)
+	fmt.Fprintf(w, `<coverage line-rate="%s" branch-rate="%s" lines-covered="%d" lines-valid="%d" branches-covered="%d" branches-valid="%d" complexity="0" version="0" timestamp="%d">`+"\n",
+		rate(total.LinesHit, total.LinesFound), rate(total.BranchesHit, total.BranchesFound),
+		total.LinesHit, total.LinesFound, total.BranchesHit, total.BranchesFound, time.Now().Unix())
+	fmt.Fprintln(w, "  <sources>")
+	fmt.Fprintf(w, "    <source>%s</source>\n", escapeXML(sourceDir))
+	fmt.Fprintln(w, "  </sources>")
+	fmt.Fprintln(w, "  <packages>")
+	_, err = tmpFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.Copy(w, tmpFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Fprintln(w, "  </packages>")
+	fmt.Fprintln(w, "</coverage>")
+	return errors.WithStack(w.Flush())
+}
+
+type coberturaPackage struct {
+	name     string
+	overview Overview
+	classes  bytes.Buffer
+}
+
+func (p *coberturaPackage) writeTo(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `    <package name="%s" line-rate="%s" branch-rate="%s" complexity="0">`+"\n      <classes>\n",
+		escapeXML(p.name), rate(p.overview.LinesHit, p.overview.LinesFound),
+		rate(p.overview.BranchesHit, p.overview.BranchesFound))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = p.classes.WriteTo(w)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprint(w, "      </classes>\n    </package>\n")
+	return errors.WithStack(err)
+}
+
+func writeCoberturaClass(w *bytes.Buffer, sf *SourceFile, overview *Overview) {
+	filename := toSlash(sf.Name)
+	fmt.Fprintf(w, `        <class name="%s" filename="%s" line-rate="%s" branch-rate="%s" complexity="0">`+"\n",
+		escapeXML(strings.TrimSuffix(path.Base(filename), path.Ext(filename))), escapeXML(filename),
+		rate(overview.LinesHit, overview.LinesFound), rate(overview.BranchesHit, overview.BranchesFound))
+
+	functionExecutions := map[string]int{}
+	for _, f := range sf.FunctionExecutions {
+		functionExecutions[f.Name] += f.Executions
+	}
+	w.WriteString("          <methods>\n")
+	for _, f := range sf.FunctionInformation {
+		hits := functionExecutions[f.Name]
+		fmt.Fprintf(w, `            <method name="%s" signature="" line-rate="%s" branch-rate="0" complexity="0">`+"\n",
+			escapeXML(f.Name), rate(min(hits, 1), 1))
+		fmt.Fprintf(w, `              <lines><line number="%d" hits="%d"/></lines>`+"\n", f.Line, hits)
+		w.WriteString("            </method>\n")
+	}
+	w.WriteString("          </methods>\n")
+
+	// Branches are reported as condition coverage of their line
+	type branchCount struct{ found, hit int }
+	branches := map[int]*branchCount{}
+	for _, b := range sf.BranchInformation {
+		c, ok := branches[b.Line]
+		if !ok {
+			c = &branchCount{}
+			branches[b.Line] = c
+		}
+		c.found++
+		if b.Executions > 0 {
+			c.hit++
+		}
+	}
+	w.WriteString("          <lines>\n")
+	for _, l := range sf.LineInformation {
+		c, ok := branches[l.Number]
+		if !ok {
+			fmt.Fprintf(w, `            <line number="%d" hits="%d" branch="false"/>`+"\n", l.Number, l.Executions)
+			continue
+		}
+		fmt.Fprintf(w, `            <line number="%d" hits="%d" branch="true" condition-coverage="%d%% (%d/%d)"/>`+"\n",
+			l.Number, l.Executions, c.hit*100/c.found, c.hit, c.found)
+	}
+	w.WriteString("          </lines>\n")
+	w.WriteString("        </class>\n")
+}
+
+// sourceFileOverview returns the overview of a record, computing it
+// from the detailed records if the summary lines are missing.
+func sourceFileOverview(sf *SourceFile) Overview {
+	o := sf.Overview
+	if o.LinesFound == 0 && len(sf.LineInformation) > 0 {
+		o.LinesFound = len(sf.LineInformation)
+		for _, l := range sf.LineInformation {
+			if l.Executions > 0 {
+				o.LinesHit++
+			}
+		}
+	}
+	if o.BranchesFound == 0 && len(sf.BranchInformation) > 0 {
+		o.BranchesFound = len(sf.BranchInformation)
+		for _, b := range sf.BranchInformation {
+			if b.Executions > 0 {
+				o.BranchesHit++
+			}
+		}
+	}
+	return o
+}
+
+func rate(hit, found int) string {
+	if found == 0 {
+		return "1"
+	}
+	return fmt.Sprintf("%.4f", float64(hit)/float64(found))
+}
+
+func toSlash(p string) string {
+	return strings.ReplaceAll(p, "\\", "/")
+}
+
+func escapeXML(s string) string {
+	buf := &bytes.Buffer{}
+	// xml.EscapeText only fails if writing to the buffer fails
+	_ = xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
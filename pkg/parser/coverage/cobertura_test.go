@@ -0,0 +1,67 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertLCOVToCobertura(t *testing.T) {
+	lcovFile := `SF:src/explore_me.cpp
+FN:1,exploreMe
+FNDA:2,exploreMe
+DA:1,2
+DA:2,2
+DA:3,0
+BRDA:2,0,0,2
+BRDA:2,0,1,-
+end_of_record
+SF:src/util/<util>.cpp
+DA:1,1
+LF:1
+LH:1
+end_of_record
+`
+	out := &strings.Builder{}
+	err := ConvertLCOVToCobertura(strings.NewReader(lcovFile), out, "/project")
+	require.NoError(t, err)
+
+	var report struct {
+		LinesValid   int    `xml:"lines-valid,attr"`
+		LinesCovered int    `xml:"lines-covered,attr"`
+		LineRate     string `xml:"line-rate,attr"`
+		Source       string `xml:"sources>source"`
+		Packages     []struct {
+			Name    string `xml:"name,attr"`
+			Classes []struct {
+				Filename string `xml:"filename,attr"`
+				Lines    []struct {
+					Number            int    `xml:"number,attr"`
+					Hits              int    `xml:"hits,attr"`
+					ConditionCoverage string `xml:"condition-coverage,attr"`
+				} `xml:"lines>line"`
+			} `xml:"classes>class"`
+		} `xml:"packages>package"`
+	}
+	err = xml.Unmarshal([]byte(out.String()), &report)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.LinesValid)
+	assert.Equal(t, 3, report.LinesCovered)
+	assert.Equal(t, "0.7500", report.LineRate)
+	assert.Equal(t, "/project", report.Source)
+
+	require.Len(t, report.Packages, 2)
+	assert.Equal(t, "src", report.Packages[0].Name)
+	assert.Equal(t, "src/util", report.Packages[1].Name)
+	require.Len(t, report.Packages[1].Classes, 1)
+	assert.Equal(t, "src/util/<util>.cpp", report.Packages[1].Classes[0].Filename)
+
+	lines := report.Packages[0].Classes[0].Lines
+	require.Len(t, lines, 3)
+	assert.Equal(t, 2, lines[1].Hits)
+	assert.Equal(t, "50% (1/2)", lines[1].ConditionCoverage)
+}
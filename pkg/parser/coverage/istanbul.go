@@ -0,0 +1,364 @@
+package coverage
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// IstanbulFileCoverage is the coverage of a single file in the
+// coverage-final.json report which is produced by Istanbul, the
+// coverage tool used by jest and Jazzer.js.
+type IstanbulFileCoverage struct {
+	Path         string                           `json:"path"`
+	StatementMap map[string]IstanbulLocation      `json:"statementMap"`
+	FnMap        map[string]IstanbulFunction      `json:"fnMap"`
+	BranchMap    map[string]IstanbulBranchMapping `json:"branchMap"`
+	S            map[string]int                   `json:"s"`
+	F            map[string]int                   `json:"f"`
+	B            map[string][]int                 `json:"b"`
+	// Set if the instrumented file was transpiled, e.g. from TypeScript
+	InputSourceMap json.RawMessage `json:"inputSourceMap"`
+}
+
+type IstanbulPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type IstanbulLocation struct {
+	Start IstanbulPosition `json:"start"`
+	End   IstanbulPosition `json:"end"`
+}
+
+type IstanbulFunction struct {
+	Name string           `json:"name"`
+	Decl IstanbulLocation `json:"decl"`
+	Loc  IstanbulLocation `json:"loc"`
+	Line int              `json:"line"`
+}
+
+type IstanbulBranchMapping struct {
+	Loc       IstanbulLocation   `json:"loc"`
+	Type      string             `json:"type"`
+	Locations []IstanbulLocation `json:"locations"`
+	Line      int                `json:"line"`
+}
+
+// ReadIstanbulJSONIntoLCOV converts an Istanbul coverage-final.json
+// report into lcov source file records and passes them to handle. The
+// report is decoded one file at a time. If a file was transpiled and
+// a source map is available, either embedded in the report or
+// referenced by the transpiled file, the coverage is attributed to the
+// original source files.
+func ReadIstanbulJSONIntoLCOV(in io.Reader, handle func(sf *SourceFile) error) error {
+	decoder := json.NewDecoder(in)
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Unable to parse Istanbul coverage report")
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return errors.New("Unable to parse Istanbul coverage report: expected a JSON object")
+	}
+
+	for decoder.More() {
+		// Skip the key, which is the path of the file
+		_, err = decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "Unable to parse Istanbul coverage report")
+		}
+		fc := &IstanbulFileCoverage{}
+		err = decoder.Decode(fc)
+		if err != nil {
+			return errors.Wrap(err, "Unable to parse Istanbul coverage report")
+		}
+
+		for _, sf := range fc.toSourceFiles(fc.loadSourceMap()) {
+			err = handle(sf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParseIstanbulJSONIntoLCOVReport reads a complete Istanbul
+// coverage-final.json report into memory. Records of original source
+// files which are included in multiple transpiled files are merged.
+func ParseIstanbulJSONIntoLCOVReport(in io.Reader) (*LCOVReport, error) {
+	report := &LCOVReport{}
+	index := map[string]int{}
+	err := ReadIstanbulJSONIntoLCOV(in, func(sf *SourceFile) error {
+		if i, ok := index[sf.Name]; ok {
+			report.SourceFiles[i] = MergeSourceFiles(report.SourceFiles[i], sf)
+			return nil
+		}
+		index[sf.Name] = len(report.SourceFiles)
+		report.SourceFiles = append(report.SourceFiles, sf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(report.SourceFiles, func(i, j int) bool {
+		return report.SourceFiles[i].Name < report.SourceFiles[j].Name
+	})
+	return report, nil
+}
+
+// loadSourceMap returns the source map of the file or nil if it was
+// not transpiled or the source map can't be found.
+func (fc *IstanbulFileCoverage) loadSourceMap() *SourceMap {
+	data := []byte(fc.InputSourceMap)
+	if len(data) == 0 || string(data) == "null" {
+		var err error
+		data, err = readReferencedSourceMap(fc.Path)
+		if err != nil {
+			log.Debugf("Failed to read source map of %s: %v", fc.Path, err)
+			return nil
+		}
+		if data == nil {
+			return nil
+		}
+	}
+
+	sourceMap, err := ParseSourceMap(data)
+	if err != nil {
+		log.Debugf("Ignoring source map of %s: %v", fc.Path, err)
+		return nil
+	}
+	return sourceMap
+}
+
+// readReferencedSourceMap reads the source map which is referenced by
+// the "sourceMappingURL" comment of a generated JavaScript file.
+func readReferencedSourceMap(jsPath string) ([]byte, error) {
+	if !strings.HasSuffix(jsPath, ".js") && !strings.HasSuffix(jsPath, ".mjs") && !strings.HasSuffix(jsPath, ".cjs") {
+		return nil, nil
+	}
+	f, err := os.Open(jsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	// The comment is usually the last line, but there can be others
+	// after it, so we use the last occurrence in the file
+	var mappingURL string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLCOVLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		for _, prefix := range []string{"//# sourceMappingURL=", "//@ sourceMappingURL="} {
+			if strings.HasPrefix(line, prefix) {
+				mappingURL = strings.TrimPrefix(line, prefix)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if mappingURL == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(mappingURL, "data:") {
+		mediaType, payload, found := strings.Cut(strings.TrimPrefix(mappingURL, "data:"), ",")
+		if !found {
+			return nil, errors.Errorf("Invalid source map data URL in %s", jsPath)
+		}
+		if strings.HasSuffix(mediaType, ";base64") {
+			data, err := base64.StdEncoding.DecodeString(payload)
+			return data, errors.WithStack(err)
+		}
+		data, err := url.PathUnescape(payload)
+		return []byte(data), errors.WithStack(err)
+	}
+
+	mapPath, err := url.PathUnescape(mappingURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	data, err := os.ReadFile(ResolveSource(jsPath, mapPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
+// toSourceFiles converts the coverage of the file into lcov records.
+// If a source map is given, a record is created for each original
+// source file and positions which can't be mapped are dropped.
+func (fc *IstanbulFileCoverage) toSourceFiles(sourceMap *SourceMap) []*SourceFile {
+	type record struct {
+		sf    *SourceFile
+		lines map[int]int
+	}
+	records := map[string]*record{}
+	get := func(pos IstanbulPosition) (*record, int, bool) {
+		name, line := fc.Path, pos.Line
+		if sourceMap != nil {
+			var source string
+			var ok bool
+			source, line, ok = sourceMap.OriginalPosition(pos.Line, pos.Column)
+			if !ok {
+				return nil, 0, false
+			}
+			name = ResolveSource(fc.Path, source)
+		}
+		r, ok := records[name]
+		if !ok {
+			r = &record{sf: &SourceFile{Name: name}, lines: map[int]int{}}
+			records[name] = r
+		}
+		return r, line, true
+	}
+
+	// Line coverage is derived from the statements. A line is covered
+	// as often as the most executed statement starting on it, which is
+	// what Istanbul's own lcov reporter does.
+	for _, id := range sortedIDs(fc.StatementMap) {
+		r, line, ok := get(fc.StatementMap[id].Start)
+		if !ok {
+			continue
+		}
+		count := fc.S[id]
+		if prev, exists := r.lines[line]; !exists || count > prev {
+			r.lines[line] = count
+		}
+	}
+
+	for _, id := range sortedIDs(fc.FnMap) {
+		fn := fc.FnMap[id]
+		pos := fn.Decl.Start
+		if pos.Line == 0 {
+			pos = fn.Loc.Start
+		}
+		if pos.Line == 0 {
+			pos = IstanbulPosition{Line: fn.Line}
+		}
+		r, line, ok := get(pos)
+		if !ok {
+			continue
+		}
+		r.sf.FunctionInformation = append(r.sf.FunctionInformation, Function{Name: fn.Name, Line: line})
+		r.sf.FunctionExecutions = append(r.sf.FunctionExecutions, FunctionExecution{Name: fn.Name, Executions: fc.F[id]})
+	}
+
+	for block, id := range sortedIDs(fc.BranchMap) {
+		branch := fc.BranchMap[id]
+		pos := branch.Loc.Start
+		if pos.Line == 0 && len(branch.Locations) > 0 {
+			pos = branch.Locations[0].Start
+		}
+		if pos.Line == 0 {
+			pos = IstanbulPosition{Line: branch.Line}
+		}
+		r, line, ok := get(pos)
+		if !ok {
+			continue
+		}
+		for number, count := range fc.B[id] {
+			r.sf.BranchInformation = append(r.sf.BranchInformation, Branch{
+				Line:       line,
+				Block:      block,
+				Number:     number,
+				Executions: count,
+			})
+		}
+	}
+
+	var sourceFiles []*SourceFile
+	for _, r := range records {
+		for number, executions := range r.lines {
+			r.sf.LineInformation = append(r.sf.LineInformation, Line{Number: number, Executions: executions})
+		}
+		sort.Slice(r.sf.LineInformation, func(i, j int) bool {
+			return r.sf.LineInformation[i].Number < r.sf.LineInformation[j].Number
+		})
+		r.sf.Overview = overviewFromRecords(r.sf)
+		sourceFiles = append(sourceFiles, r.sf)
+	}
+	sort.Slice(sourceFiles, func(i, j int) bool {
+		return sourceFiles[i].Name < sourceFiles[j].Name
+	})
+	return sourceFiles
+}
+
+// sortedIDs returns the keys of an Istanbul map sorted numerically,
+// which is the order in which Istanbul assigned them.
+func sortedIDs[T any](m map[string]T) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+	return ids
+}
+
+// ConvertIstanbulJSONToLCOV converts an Istanbul coverage-final.json
+// report into an lcov report. The report is read into memory, because
+// multiple transpiled files can map to the same original source file,
+// which must only have a single record in the lcov report.
+func ConvertIstanbulJSONToLCOV(in io.Reader, out io.Writer) error {
+	report, err := ParseIstanbulJSONIntoLCOVReport(in)
+	if err != nil {
+		return err
+	}
+	w := NewLCOVWriter(out)
+	for _, sf := range report.SourceFiles {
+		err = w.Write(sf)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ParseIstanbulJSONIntoSummary takes an Istanbul coverage-final.json
+// report and turns it into the `Summary` struct.
+func ParseIstanbulJSONIntoSummary(in io.Reader) (*Summary, error) {
+	report, err := ParseIstanbulJSONIntoLCOVReport(in)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Total: Overview{},
+	}
+	for _, sf := range report.SourceFiles {
+		summary.Files = append(summary.Files, &FileCoverage{
+			Filename: sf.Name,
+			Coverage: sf.Overview,
+		})
+		summary.Total.add(&sf.Overview)
+	}
+	return summary, nil
+}
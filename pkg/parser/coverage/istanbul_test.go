@@ -0,0 +1,152 @@
+package coverage
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+const istanbulReport = `{
+  "/project/fuzz.js": {
+    "path": "/project/fuzz.js",
+    "statementMap": {
+      "0": {"start": {"line": 2, "column": 2}, "end": {"line": 2, "column": 20}},
+      "1": {"start": {"line": 3, "column": 4}, "end": {"line": 3, "column": 12}},
+      "2": {"start": {"line": 3, "column": 14}, "end": {"line": 3, "column": 30}},
+      "10": {"start": {"line": 5, "column": 2}, "end": {"line": 5, "column": 10}}
+    },
+    "fnMap": {
+      "0": {
+        "name": "fuzz",
+        "decl": {"start": {"line": 1, "column": 9}, "end": {"line": 1, "column": 13}},
+        "loc": {"start": {"line": 1, "column": 20}, "end": {"line": 6, "column": 1}},
+        "line": 1
+      },
+      "1": {
+        "name": "unused",
+        "decl": {"start": {"line": 7, "column": 9}, "end": {"line": 7, "column": 15}},
+        "loc": {"start": {"line": 7, "column": 18}, "end": {"line": 7, "column": 20}},
+        "line": 7
+      }
+    },
+    "branchMap": {
+      "0": {
+        "loc": {"start": {"line": 3, "column": 2}, "end": {"line": 3, "column": 30}},
+        "type": "if",
+        "locations": [
+          {"start": {"line": 3, "column": 2}, "end": {"line": 3, "column": 30}},
+          {"start": {"line": 3, "column": 2}, "end": {"line": 3, "column": 30}}
+        ],
+        "line": 3
+      }
+    },
+    "s": {"0": 5, "1": 5, "2": 0, "10": 0},
+    "f": {"0": 5, "1": 0},
+    "b": {"0": [5, 0]}
+  }
+}`
+
+func TestParseIstanbulJSONIntoLCOVReport(t *testing.T) {
+	report, err := ParseIstanbulJSONIntoLCOVReport(strings.NewReader(istanbulReport))
+	require.NoError(t, err)
+
+	require.Len(t, report.SourceFiles, 1)
+	sf := report.SourceFiles[0]
+	assert.Equal(t, "/project/fuzz.js", sf.Name)
+	assert.Equal(t, []Function{{Name: "fuzz", Line: 1}, {Name: "unused", Line: 7}}, sf.FunctionInformation)
+	assert.Equal(t, []FunctionExecution{{Name: "fuzz", Executions: 5}, {Name: "unused", Executions: 0}}, sf.FunctionExecutions)
+	// The line of statements 1 and 2 is covered by statement 1
+	assert.Equal(t, []Line{{2, 5}, {3, 5}, {5, 0}}, sf.LineInformation)
+	assert.Equal(t, []Branch{{3, 0, 0, 5}, {3, 0, 1, 0}}, sf.BranchInformation)
+	assert.Equal(t, Overview{
+		FunctionsFound: 2,
+		FunctionsHit:   1,
+		LinesFound:     3,
+		LinesHit:       2,
+		BranchesFound:  2,
+		BranchesHit:    1,
+	}, sf.Overview)
+
+	summary, err := ParseIstanbulJSONIntoSummary(strings.NewReader(istanbulReport))
+	require.NoError(t, err)
+	require.Len(t, summary.Files, 1)
+	assert.Equal(t, 2, summary.Total.LinesHit)
+	assert.Equal(t, 3, summary.Total.LinesFound)
+}
+
+func TestParseIstanbulJSONIntoLCOVReport_InputSourceMap(t *testing.T) {
+	// The transpiled lines 2, 3 and 5 map to the lines 4, 5 and 9 of
+	// the TypeScript file
+	sourceMap := `{"version": 3, "sources": ["../src/fuzz.ts"], "mappings": ";AAGA;AACA;;AAIA"}`
+	report := strings.Replace(istanbulReport, `"b": {"0": [5, 0]}`, `"b": {"0": [5, 0]}, "inputSourceMap": `+sourceMap, 1)
+	report = strings.ReplaceAll(report, "/project/fuzz.js", "/project/dist/fuzz.js")
+
+	lcovReport, err := ParseIstanbulJSONIntoLCOVReport(strings.NewReader(report))
+	require.NoError(t, err)
+
+	require.Len(t, lcovReport.SourceFiles, 1)
+	sf := lcovReport.SourceFiles[0]
+	assert.Equal(t, filepath.Join("/project", "src", "fuzz.ts"), sf.Name)
+	assert.Equal(t, []Line{{4, 5}, {5, 5}, {9, 0}}, sf.LineInformation)
+	// The functions are on lines without mappings
+	assert.Empty(t, sf.FunctionInformation)
+	assert.Equal(t, []Branch{{5, 0, 0, 5}, {5, 0, 1, 0}}, sf.BranchInformation)
+}
+
+func TestParseIstanbulJSONIntoLCOVReport_SourceMappingURL(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "istanbul-")
+	jsPath := filepath.Join(projectDir, "dist", "fuzz.js")
+	require.NoError(t, os.MkdirAll(filepath.Dir(jsPath), 0o755))
+
+	sourceMap := `{"version": 3, "sources": ["../src/fuzz.ts"], "mappings": ";AAGA;AACA;;AAIA"}`
+	js := "function fuzz(data) {\n}\n//# sourceMappingURL=data:application/json;base64," +
+		base64.StdEncoding.EncodeToString([]byte(sourceMap)) + "\n"
+	require.NoError(t, os.WriteFile(jsPath, []byte(js), 0o644))
+
+	// Paths in the JSON report use forward slashes on all platforms
+	report := strings.ReplaceAll(istanbulReport, "/project/fuzz.js", filepath.ToSlash(jsPath))
+	lcovReport, err := ParseIstanbulJSONIntoLCOVReport(strings.NewReader(report))
+	require.NoError(t, err)
+
+	require.Len(t, lcovReport.SourceFiles, 1)
+	assert.Equal(t, filepath.Join(projectDir, "src", "fuzz.ts"), lcovReport.SourceFiles[0].Name)
+	assert.Equal(t, []Line{{4, 5}, {5, 5}, {9, 0}}, lcovReport.SourceFiles[0].LineInformation)
+}
+
+func TestConvertIstanbulJSONToLCOV_SharedSource(t *testing.T) {
+	// Two transpiled files which both map to src/fuzz.ts, e.g. because
+	// it was bundled into both of them
+	sourceMap := `{"version": 3, "sources": ["../src/fuzz.ts"], "mappings": ";AAGA;AACA;;AAIA"}`
+	entry := strings.Replace(istanbulReport, `"b": {"0": [5, 0]}`, `"b": {"0": [5, 0]}, "inputSourceMap": `+sourceMap, 1)
+	entry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(entry), "{"), "}")
+	report := "{" + strings.ReplaceAll(entry, "/project/fuzz.js", "/project/dist/a.js") + "," +
+		strings.ReplaceAll(entry, "/project/fuzz.js", "/project/dist/b.js") + "}"
+
+	var out strings.Builder
+	err := ConvertIstanbulJSONToLCOV(strings.NewReader(report), &out)
+	require.NoError(t, err)
+
+	lcov := out.String()
+	assert.Equal(t, 1, strings.Count(lcov, "SF:"))
+	assert.Contains(t, lcov, "SF:"+filepath.Join("/project", "src", "fuzz.ts"))
+	// The executions of both files are added up
+	assert.Contains(t, lcov, "DA:4,10\n")
+	assert.Contains(t, lcov, "DA:9,0\n")
+}
+
+func TestParseIstanbulJSONIntoLCOVReport_Empty(t *testing.T) {
+	report, err := ParseIstanbulJSONIntoLCOVReport(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, report.SourceFiles)
+
+	report, err = ParseIstanbulJSONIntoLCOVReport(strings.NewReader("{}"))
+	require.NoError(t, err)
+	assert.Empty(t, report.SourceFiles)
+}
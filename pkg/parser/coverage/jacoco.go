@@ -10,35 +10,38 @@ import (
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
 type JacocoXMLReport struct {
-	Name     string `xml:"name,attr"`
-	Packages []struct {
-		Name  string `xml:"name,attr"`
-		Class []struct {
-			Name           string `xml:"name,attr"`
-			SourceFileName string `xml:"sourcefilename,attr"`
-			Method         []struct {
-				Name    string          `xml:"name,attr"`
-				Line    int             `xml:"line,attr"`
-				Counter []JacocoCounter `xml:"counter"`
-			} `xml:"method"`
-			Counter []JacocoCounter `xml:"counter"`
-		} `xml:"class"`
-		SourceFiles []struct {
-			Name string `xml:"name,attr"`
-			Line []struct {
-				Nr                  int `xml:"nr,attr"`
-				MissedInstructions  int `xml:"mi,attr"`
-				CoveredInstructions int `xml:"ci,attr"`
-				MissedBranches      int `xml:"mb,attr"`
-				CoveredBranches     int `xml:"cb,attr"`
-			} `xml:"line"`
+	Name     string          `xml:"name,attr"`
+	Packages []JacocoPackage `xml:"package"`
+	Counter  []JacocoCounter `xml:"counter"`
+}
+
+type JacocoPackage struct {
+	Name  string `xml:"name,attr"`
+	Class []struct {
+		Name           string `xml:"name,attr"`
+		SourceFileName string `xml:"sourcefilename,attr"`
+		Method         []struct {
+			Name    string          `xml:"name,attr"`
+			Line    int             `xml:"line,attr"`
 			Counter []JacocoCounter `xml:"counter"`
-		} `xml:"sourcefile"`
+		} `xml:"method"`
 		Counter []JacocoCounter `xml:"counter"`
-	} `xml:"package"`
+	} `xml:"class"`
+	SourceFiles []struct {
+		Name string `xml:"name,attr"`
+		Line []struct {
+			Nr                  int `xml:"nr,attr"`
+			MissedInstructions  int `xml:"mi,attr"`
+			CoveredInstructions int `xml:"ci,attr"`
+			MissedBranches      int `xml:"mb,attr"`
+			CoveredBranches     int `xml:"cb,attr"`
+		} `xml:"line"`
+		Counter []JacocoCounter `xml:"counter"`
+	} `xml:"sourcefile"`
 	Counter []JacocoCounter `xml:"counter"`
 }
 
@@ -48,33 +51,49 @@ type JacocoCounter struct {
 	Covered int    `xml:"covered,attr"`
 }
 
-func ParseJacocoXMLIntoLCOVReport(in io.Reader, sourceFilesDir string) (*LCOVReport, error) {
-	lcovReport := &LCOVReport{}
-
-	output, err := io.ReadAll(in)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unable to read jacoco.xml report")
-	}
-
-	if len(output) == 0 {
-		log.Debugf("Empty jacoco.xml, returning empty LCOV report")
-		return lcovReport, nil
-	}
+// readJacocoPackages decodes the packages of a jacoco.xml report one
+// at a time and passes them to handle, so that only a single package
+// is held in memory.
+func readJacocoPackages(in io.Reader, handle func(pkg *JacocoPackage) error) error {
+	decoder := xml.NewDecoder(in)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "Unable to parse jacoco.xml report")
+		}
 
-	jacocoReport := &JacocoXMLReport{}
-	err = xml.Unmarshal(output, jacocoReport)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unable to parse jacoco.xml report")
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+		pkg := &JacocoPackage{}
+		err = decoder.DecodeElement(pkg, &start)
+		if err != nil {
+			return errors.Wrap(err, "Unable to parse jacoco.xml report")
+		}
+		err = handle(pkg)
+		if err != nil {
+			return err
+		}
 	}
+}
 
-	for _, pkg := range jacocoReport.Packages {
+// ReadJacocoXMLIntoLCOV converts a jacoco.xml report into lcov source
+// file records and passes them to handle one at a time. The paths of
+// the source files are resolved against the first of the source file
+// directories which contains them.
+func ReadJacocoXMLIntoLCOV(in io.Reader, sourceFilesDirs []string, handle func(sf *SourceFile) error) error {
+	return readJacocoPackages(in, func(pkg *JacocoPackage) error {
 		for _, sourceFile := range pkg.SourceFiles {
 			sf := SourceFile{}
 
 			packagePath := filepath.Join(pkg.Name, sourceFile.Name)
 			// Note: Sourcefile name needs to have full path so that the files
 			// can be found when they are mapped by genhtml
-			sf.Name = filepath.Join(sourceFilesDir, packagePath)
+			sf.Name = jacocoSourceFilePath(sourceFilesDirs, packagePath)
 
 			for _, line := range sourceFile.Line {
 				// Line coverage
@@ -101,7 +120,9 @@ func ParseJacocoXMLIntoLCOVReport(in io.Reader, sourceFilesDir string) (*LCOVRep
 					sf.BranchInformation = append(sf.BranchInformation, Branch{
 						Line:       line.Nr,
 						Executions: 0,
-						Number:     i,
+						// Continue the numbering of the covered branches, so
+						// that each branch of the line is unique
+						Number: line.CoveredBranches + i,
 					})
 				}
 			}
@@ -142,10 +163,58 @@ func ParseJacocoXMLIntoLCOVReport(in io.Reader, sourceFilesDir string) (*LCOVRep
 				countJacoco(&sf.Overview, &counter)
 			}
 
-			lcovReport.SourceFiles = append(lcovReport.SourceFiles, &sf)
+			err := handle(&sf)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// jacocoSourceFilePath returns the path of the source file below the
+// first of the source file directories which contains it. If none of
+// them does, it's assumed to be below the first one.
+func jacocoSourceFilePath(sourceFilesDirs []string, packagePath string) string {
+	if len(sourceFilesDirs) == 0 {
+		return packagePath
+	}
+	if len(sourceFilesDirs) > 1 {
+		for _, dir := range sourceFilesDirs {
+			path := filepath.Join(dir, packagePath)
+			exists, err := fileutil.Exists(path)
+			if err == nil && exists {
+				return path
+			}
 		}
 	}
+	return filepath.Join(sourceFilesDirs[0], packagePath)
+}
+
+// ConvertJacocoXMLToLCOV converts a jacoco.xml report into an lcov
+// report without holding more than one package of the report in
+// memory.
+func ConvertJacocoXMLToLCOV(in io.Reader, sourceFilesDirs []string, out io.Writer) error {
+	w := NewLCOVWriter(out)
+	err := ReadJacocoXMLIntoLCOV(in, sourceFilesDirs, w.Write)
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
 
+func ParseJacocoXMLIntoLCOVReport(in io.Reader, sourceFilesDir string) (*LCOVReport, error) {
+	lcovReport := &LCOVReport{}
+	err := ReadJacocoXMLIntoLCOV(in, []string{sourceFilesDir}, func(sf *SourceFile) error {
+		lcovReport.SourceFiles = append(lcovReport.SourceFiles, sf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(lcovReport.SourceFiles) == 0 {
+		log.Debugf("Empty jacoco.xml, returning empty LCOV report")
+	}
 	return lcovReport, nil
 }
 
@@ -159,28 +228,9 @@ func ParseJacocoXMLIntoSummary(in io.Reader) *Summary {
 		Total: Overview{},
 	}
 
-	output, err := io.ReadAll(in)
-	if err != nil {
-		log.Errorf(err, "Unable to read jacoco.xml report: %v", err)
-		return coverageSummary
-	}
-
-	if len(output) == 0 {
-		log.Debugf("Empty jacoco.xml, returning empty coverage summary")
-		return coverageSummary
-	}
-
-	report := &JacocoXMLReport{}
-	err = xml.Unmarshal(output, report)
-	if err != nil {
-		log.Errorf(err, "Unable to parse jacoco.xml report: %v", err)
-		return coverageSummary
-	}
-
-	var currentFile *FileCoverage
-	for _, xmlPackage := range report.Packages {
+	err := readJacocoPackages(in, func(xmlPackage *JacocoPackage) error {
 		for _, sourcefile := range xmlPackage.SourceFiles {
-			currentFile = &FileCoverage{
+			currentFile := &FileCoverage{
 				Filename: fmt.Sprintf("%s/%s", xmlPackage.Name, sourcefile.Name),
 				Coverage: Overview{},
 			}
@@ -190,6 +240,12 @@ func ParseJacocoXMLIntoSummary(in io.Reader) *Summary {
 			}
 			coverageSummary.Files = append(coverageSummary.Files, currentFile)
 		}
+		return nil
+	})
+	if err != nil {
+		// Keep the information gathered from the packages which could
+		// be parsed
+		log.Errorf(err, "Unable to parse jacoco.xml report: %v", err)
 	}
 
 	return coverageSummary
@@ -1,12 +1,15 @@
 package coverage
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
 )
 
 func TestParseJacocoXMLIntoLCOVReport(t *testing.T) {
@@ -154,3 +157,79 @@ func TestParseJacocoIntoSummary_Empty(t *testing.T) {
 	assert.Empty(t, summary.Total.LinesFound, "incorrect number of total functions found")
 	assert.Empty(t, summary.Total.FunctionsFound, "incorrect number of total lines found")
 }
+
+func TestConvertJacocoXMLToLCOV(t *testing.T) {
+	reportData := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">
+<report name="maven-example">
+    <package name="com/example">
+        <sourcefile name="ExploreMe.java">
+            <line cb="1" ci="2" mb="1" mi="0" nr="5"/>
+            <counter type="LINE" missed="0" covered="1"/>
+            <counter type="BRANCH" missed="1" covered="1"/>
+        </sourcefile>
+    </package>
+    <package name="com/other">
+        <sourcefile name="Other.java">
+            <line cb="0" ci="0" mb="0" mi="1" nr="3"/>
+            <counter type="LINE" missed="1" covered="0"/>
+        </sourcefile>
+    </package>
+</report>
+`
+	out := &strings.Builder{}
+	err := ConvertJacocoXMLToLCOV(strings.NewReader(reportData), []string{"src"}, out)
+	require.NoError(t, err)
+
+	expectedLCOV := `SF:` + filepath.Join("src", "com", "example", "ExploreMe.java") + `
+FNF:0
+FNH:0
+DA:5,1
+LF:1
+LH:1
+BRDA:5,0,0,1
+BRDA:5,0,1,-
+BRF:2
+BRH:1
+end_of_record
+SF:` + filepath.Join("src", "com", "other", "Other.java") + `
+FNF:0
+FNH:0
+DA:3,0
+LF:1
+LH:0
+BRF:0
+BRH:0
+end_of_record
+`
+	assert.Equal(t, expectedLCOV, out.String())
+}
+
+func TestConvertJacocoXMLToLCOV_MultipleSourceRoots(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "jacoco-")
+	mainDir := filepath.Join(dir, "src", "main", "java")
+	testDir := filepath.Join(dir, "src", "test", "java")
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "com", "example"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "com", "example", "FuzzTest.java"), nil, 0o644))
+
+	reportData := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<report name="maven-example">
+    <package name="com/example">
+        <sourcefile name="FuzzTest.java">
+            <line cb="0" ci="1" mb="0" mi="0" nr="5"/>
+        </sourcefile>
+        <sourcefile name="ExploreMe.java">
+            <line cb="0" ci="1" mb="0" mi="0" nr="7"/>
+        </sourcefile>
+    </package>
+</report>
+`
+	out := &strings.Builder{}
+	err := ConvertJacocoXMLToLCOV(strings.NewReader(reportData), []string{mainDir, testDir}, out)
+	require.NoError(t, err)
+
+	// The fuzz test is found in the second source root, the file which
+	// doesn't exist in any of them is assumed to be in the first one
+	assert.Contains(t, out.String(), "SF:"+filepath.Join(testDir, "com", "example", "FuzzTest.java")+"\n")
+	assert.Contains(t, out.String(), "SF:"+filepath.Join(mainDir, "com", "example", "ExploreMe.java")+"\n")
+}
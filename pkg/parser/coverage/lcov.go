@@ -60,99 +60,39 @@ type Overview struct {
 	BranchesHit    int
 }
 
-func (r *LCOVReport) WriteLCOVReportToFile(file string) error {
-	if r.SourceFiles == nil || len(r.SourceFiles) == 0 {
-		log.Debug("LCOV report is empty, no file created")
-		return nil
-	}
-
-	if !strings.HasSuffix(file, ".lcov") {
-		file += ".lcov"
-		log.Debug("Missing extension '.lcov' was appended to path")
-	}
-
-	// Note: file needs read/write access to be used with genhtml later on
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	defer f.Close()
-
-	for _, sf := range r.SourceFiles {
-		// SF:<absolute path to the source file>
-		s := fmt.Sprintf("SF:%s\n", sf.Name)
-
-		// Function Coverage
-		for _, f := range sf.FunctionInformation {
-			// FN:<line number of function start>,<function name>
-			s += fmt.Sprintf("FN:%d,%s\n", f.Line, f.Name)
-		}
-		for _, f := range sf.FunctionExecutions {
-			// FNDA:<execution count>,<function name>
-			s += fmt.Sprintf("FNDA:%d,%s\n", f.Executions, f.Name)
-		}
-		// FNF:<number of functions found>
-		s += fmt.Sprintf("FNF:%d\n", sf.FunctionsFound)
-		// FNH:<number of function hit>
-		s += fmt.Sprintf("FNH:%d\n", sf.FunctionsHit)
-
-		// Line Coverage
-		for _, l := range sf.LineInformation {
-			// DA:<line number>,<execution count>[,<checksum>]
-			s += fmt.Sprintf("DA:%d,%d\n", l.Number, l.Executions)
-		}
-		// LF:<number of instrumented lines>
-		s += fmt.Sprintf("LF:%d\n", sf.LinesFound)
-		// LH:<number of lines with a non-zero execution count>
-		s += fmt.Sprintf("LH:%d\n", sf.LinesHit)
-
-		// Branch coverage
-		for _, b := range sf.BranchInformation {
-			if b.Executions == 0 {
-				// BRDA:<line number>,<block number>,<branch number>,<taken>
-				s += fmt.Sprintf("BRDA:%d,0,%d,-\n", b.Line, b.Number)
-			} else {
-				s += fmt.Sprintf("BRDA:%d,0,%d,%d\n", b.Line, b.Number, b.Executions)
-			}
-		}
-		// BRF:<number of branches found>
-		s += fmt.Sprintf("BRF:%d\n", sf.BranchesFound)
-		// BRH:<number of branches hit>
-		s += fmt.Sprintf("BRH:%d\n", sf.BranchesHit)
+// LCOVReader reads an LCOV report one source file record at a time,
+// so that reports of any size can be processed in bounded memory.
+type LCOVReader struct {
+	scanner *bufio.Scanner
+}
 
-		// Necessary to signal end of sourcefile section
-		s += fmt.Sprintf("end_of_record\n")
+// Function names of C++ templates can get very long, so we allow lines
+// which are much larger than the default token size of bufio.Scanner.
+const maxLCOVLineSize = 16 * 1024 * 1024
 
-		_, err = f.WriteString(s)
-		if err != nil {
-			return errors.Wrapf(err, "Failed to write to file '%s'", file)
-		}
-	}
-
-	log.Debugf("Successfully wrote lcov report to %s", file)
-	return nil
+func NewLCOVReader(in io.Reader) *LCOVReader {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxLCOVLineSize)
+	return &LCOVReader{scanner: scanner}
 }
 
-func ParseLCOVFileIntoLCOVReport(in io.Reader) (*LCOVReport, error) {
+// Next returns the next source file record of the report. It returns
+// io.EOF once all records were read. A trailing record which is not
+// terminated by "end_of_record" is ignored.
+func (r *LCOVReader) Next() (*SourceFile, error) {
 	var err error
-	report := &LCOVReport{}
 	currentSourceFile := &SourceFile{}
 
-	scanner := bufio.NewScanner(in)
-	for scanner.Scan() {
-		line := scanner.Text()
-		invalidFormatErr := fmt.Errorf("'%s' is not a valid lcov format", line)
-		failedParsing := fmt.Sprintf("Failed to parse line in lcov report: %s", line)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
 
 		if line == "end_of_record" {
-			report.SourceFiles = append(report.SourceFiles, currentSourceFile)
-			currentSourceFile = &SourceFile{}
-			continue
+			return currentSourceFile, nil
 		}
 
 		prefix, v, found := strings.Cut(line, ":")
 		if !found {
-			return nil, invalidFormatErr
+			return nil, fmt.Errorf("'%s' is not a valid lcov format", line)
 		}
 
 		switch prefix {
@@ -160,160 +100,232 @@ func ParseLCOVFileIntoLCOVReport(in io.Reader) (*LCOVReport, error) {
 			currentSourceFile.Name = v
 
 		case "LF":
-			currentSourceFile.LinesFound, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.LinesFound, err = parseLCOVInt(line, v)
 
 		case "LH":
-			currentSourceFile.LinesHit, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.LinesHit, err = parseLCOVInt(line, v)
 
 		case "FNF":
-			currentSourceFile.FunctionsFound, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.FunctionsFound, err = parseLCOVInt(line, v)
 
 		case "FNH":
-			currentSourceFile.FunctionsHit, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.FunctionsHit, err = parseLCOVInt(line, v)
 
 		case "BRF":
-			currentSourceFile.BranchesFound, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.BranchesFound, err = parseLCOVInt(line, v)
 
 		case "BRH":
-			currentSourceFile.BranchesHit, err = strconv.Atoi(v)
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
+			currentSourceFile.BranchesHit, err = parseLCOVInt(line, v)
 
 		case "FN":
-			split := strings.Split(v, ",")
-			if len(split) != 2 {
-				return nil, invalidFormatErr
+			lineNumber, name, found := strings.Cut(v, ",")
+			if !found {
+				return nil, fmt.Errorf("'%s' is not a valid lcov format", line)
 			}
-
-			l, err := strconv.Atoi(split[0])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
-			}
-			currentSourceFile.FunctionInformation = append(currentSourceFile.FunctionInformation, Function{
-				Name: split[1],
-				Line: l,
-			})
+			f := Function{Name: name}
+			f.Line, err = parseLCOVInt(line, lineNumber)
+			currentSourceFile.FunctionInformation = append(currentSourceFile.FunctionInformation, f)
 
 		case "FNDA":
-			split := strings.Split(v, ",")
-			if len(split) != 2 {
-				return nil, invalidFormatErr
-			}
-
-			e, err := strconv.Atoi(split[0])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+			executions, name, found := strings.Cut(v, ",")
+			if !found {
+				return nil, fmt.Errorf("'%s' is not a valid lcov format", line)
 			}
-			currentSourceFile.FunctionExecutions = append(currentSourceFile.FunctionExecutions, FunctionExecution{
-				Name:       split[1],
-				Executions: e,
-			})
+			e := FunctionExecution{Name: name}
+			e.Executions, err = parseLCOVInt(line, executions)
+			currentSourceFile.FunctionExecutions = append(currentSourceFile.FunctionExecutions, e)
 
 		case "DA":
 			split := strings.Split(v, ",")
 			// Note: DA can have checksum as third value which we are ignoring
 			if len(split) < 2 || len(split) > 3 {
-				return nil, invalidFormatErr
-			}
-
-			l, err := strconv.Atoi(split[0])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+				return nil, fmt.Errorf("'%s' is not a valid lcov format", line)
 			}
-			e, err := strconv.Atoi(split[1])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+			l := Line{}
+			l.Number, err = parseLCOVInt(line, split[0])
+			if err == nil {
+				l.Executions, err = parseLCOVInt(line, split[1])
 			}
-			currentSourceFile.LineInformation = append(currentSourceFile.LineInformation, Line{
-				Number:     l,
-				Executions: e,
-			})
+			currentSourceFile.LineInformation = append(currentSourceFile.LineInformation, l)
 
 		case "BRDA":
 			split := strings.Split(v, ",")
 			if len(split) != 4 {
-				return nil, invalidFormatErr
-			}
-
-			l, err := strconv.Atoi(split[0])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+				return nil, fmt.Errorf("'%s' is not a valid lcov format", line)
 			}
-			bl, err := strconv.Atoi(split[1])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+			b := Branch{}
+			b.Line, err = parseLCOVInt(line, split[0])
+			if err == nil {
+				b.Block, err = parseLCOVInt(line, split[1])
 			}
-			br, err := strconv.Atoi(split[2])
-			if err != nil {
-				return nil, errors.Wrap(err, failedParsing)
+			if err == nil {
+				b.Number, err = parseLCOVInt(line, split[2])
 			}
-			e := 0
-			if split[3] != "-" {
-				e, err = strconv.Atoi(split[3])
-				if err != nil {
-					return nil, errors.Wrap(err, failedParsing)
-				}
+			if err == nil && split[3] != "-" {
+				b.Executions, err = parseLCOVInt(line, split[3])
 			}
-			currentSourceFile.BranchInformation = append(currentSourceFile.BranchInformation, Branch{
-				Line:       l,
-				Block:      bl,
-				Number:     br,
-				Executions: e,
-			})
+			currentSourceFile.BranchInformation = append(currentSourceFile.BranchInformation, b)
+		}
+
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
+	if err := r.scanner.Err(); err != nil {
 		return nil, errors.WithStack(err)
 	}
+	return nil, io.EOF
+}
+
+func parseLCOVInt(line, v string) (int, error) {
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to parse line in lcov report: %s", line)
+	}
+	return i, nil
+}
+
+// LCOVWriter writes an LCOV report one source file record at a time.
+// Flush must be called after the last record was written.
+type LCOVWriter struct {
+	w *bufio.Writer
+}
+
+func NewLCOVWriter(out io.Writer) *LCOVWriter {
+	return &LCOVWriter{w: bufio.NewWriter(out)}
+}
+
+func (w *LCOVWriter) Write(sf *SourceFile) error {
+	// SF:<absolute path to the source file>
+	fmt.Fprintf(w.w, "SF:%s\n", sf.Name)
+
+	// Function Coverage
+	for _, f := range sf.FunctionInformation {
+		// FN:<line number of function start>,<function name>
+		fmt.Fprintf(w.w, "FN:%d,%s\n", f.Line, f.Name)
+	}
+	for _, f := range sf.FunctionExecutions {
+		// FNDA:<execution count>,<function name>
+		fmt.Fprintf(w.w, "FNDA:%d,%s\n", f.Executions, f.Name)
+	}
+	// FNF:<number of functions found>
+	fmt.Fprintf(w.w, "FNF:%d\n", sf.FunctionsFound)
+	// FNH:<number of function hit>
+	fmt.Fprintf(w.w, "FNH:%d\n", sf.FunctionsHit)
+
+	// Line Coverage
+	for _, l := range sf.LineInformation {
+		// DA:<line number>,<execution count>[,<checksum>]
+		fmt.Fprintf(w.w, "DA:%d,%d\n", l.Number, l.Executions)
+	}
+	// LF:<number of instrumented lines>
+	fmt.Fprintf(w.w, "LF:%d\n", sf.LinesFound)
+	// LH:<number of lines with a non-zero execution count>
+	fmt.Fprintf(w.w, "LH:%d\n", sf.LinesHit)
+
+	// Branch coverage
+	for _, b := range sf.BranchInformation {
+		if b.Executions == 0 {
+			// BRDA:<line number>,<block number>,<branch number>,<taken>
+			fmt.Fprintf(w.w, "BRDA:%d,%d,%d,-\n", b.Line, b.Block, b.Number)
+		} else {
+			fmt.Fprintf(w.w, "BRDA:%d,%d,%d,%d\n", b.Line, b.Block, b.Number, b.Executions)
+		}
+	}
+	// BRF:<number of branches found>
+	fmt.Fprintf(w.w, "BRF:%d\n", sf.BranchesFound)
+	// BRH:<number of branches hit>
+	fmt.Fprintf(w.w, "BRH:%d\n", sf.BranchesHit)
+
+	// Necessary to signal end of sourcefile section
+	_, err := w.w.WriteString("end_of_record\n")
+	// bufio.Writer keeps the first error, so checking the last write
+	// is sufficient
+	return errors.WithStack(err)
+}
+
+func (w *LCOVWriter) Flush() error {
+	return errors.WithStack(w.w.Flush())
+}
+
+func (r *LCOVReport) WriteLCOVReportToFile(file string) error {
+	if r.SourceFiles == nil || len(r.SourceFiles) == 0 {
+		log.Debug("LCOV report is empty, no file created")
+		return nil
+	}
+
+	if !strings.HasSuffix(file, ".lcov") {
+		file += ".lcov"
+		log.Debug("Missing extension '.lcov' was appended to path")
+	}
+
+	// Note: file needs read/write access to be used with genhtml later on
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	w := NewLCOVWriter(f)
+	for _, sf := range r.SourceFiles {
+		err = w.Write(sf)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to write to file '%s'", file)
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to write to file '%s'", file)
+	}
+
+	log.Debugf("Successfully wrote lcov report to %s", file)
+	return nil
+}
+
+// ParseLCOVFileIntoLCOVReport reads a complete lcov report into memory.
+// Prefer LCOVReader for reports which only have to be processed once.
+func ParseLCOVFileIntoLCOVReport(in io.Reader) (*LCOVReport, error) {
+	report := &LCOVReport{}
+
+	r := NewLCOVReader(in)
+	for {
+		sf, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.SourceFiles = append(report.SourceFiles, sf)
+	}
 
 	return report, nil
 }
 
 // ParseLCOVReportIntoSummary takes a lcov report and turns it
 // into the `Summary` struct. It will print the summary in verbose mode
-// in JSON format if possible.
+// in JSON format if possible. The report is read record by record, so
+// only the per-file overviews are held in memory.
 func ParseLCOVReportIntoSummary(in io.Reader) (*Summary, error) {
 	summary := &Summary{
 		Total: Overview{},
 	}
 
-	report, err := ParseLCOVFileIntoLCOVReport(in)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, sf := range report.SourceFiles {
-		currentFile := &FileCoverage{
+	r := NewLCOVReader(in)
+	for {
+		sf, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		summary.Files = append(summary.Files, &FileCoverage{
 			Filename: sf.Name,
 			Coverage: sf.Overview,
-		}
-		summary.Files = append(summary.Files, currentFile)
-	}
-
-	for _, f := range summary.Files {
-		summary.Total.LinesHit += f.Coverage.LinesHit
-		summary.Total.LinesFound += f.Coverage.LinesFound
-		summary.Total.BranchesFound += f.Coverage.BranchesFound
-		summary.Total.BranchesHit += f.Coverage.BranchesHit
-		summary.Total.FunctionsFound += f.Coverage.FunctionsFound
-		summary.Total.FunctionsHit += f.Coverage.FunctionsHit
+		})
+		summary.Total.add(&sf.Overview)
 	}
 
 	// This is not an essential step, so we don't fail on error
@@ -326,3 +338,44 @@ func ParseLCOVReportIntoSummary(in io.Reader) (*Summary, error) {
 
 	return summary, nil
 }
+
+func (o *Overview) add(other *Overview) {
+	o.LinesHit += other.LinesHit
+	o.LinesFound += other.LinesFound
+	o.BranchesFound += other.BranchesFound
+	o.BranchesHit += other.BranchesHit
+	o.FunctionsFound += other.FunctionsFound
+	o.FunctionsHit += other.FunctionsHit
+}
+
+// overviewFromRecords computes the overview of a source file from its
+// function, line and branch records.
+func overviewFromRecords(sf *SourceFile) Overview {
+	o := Overview{}
+
+	o.FunctionsFound = len(sf.FunctionInformation)
+	if o.FunctionsFound == 0 {
+		o.FunctionsFound = len(sf.FunctionExecutions)
+	}
+	for _, f := range sf.FunctionExecutions {
+		if f.Executions > 0 {
+			o.FunctionsHit++
+		}
+	}
+
+	o.LinesFound = len(sf.LineInformation)
+	for _, l := range sf.LineInformation {
+		if l.Executions > 0 {
+			o.LinesHit++
+		}
+	}
+
+	o.BranchesFound = len(sf.BranchInformation)
+	for _, b := range sf.BranchInformation {
+		if b.Executions > 0 {
+			o.BranchesHit++
+		}
+	}
+
+	return o
+}
@@ -1,6 +1,7 @@
 package coverage
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -233,3 +234,63 @@ func TestParseLCOVIntoSummary_EmptyReport(t *testing.T) {
 	assert.Empty(t, summary.Total.LinesFound, "summary shouldn't have any found lines")
 	assert.Empty(t, summary.Total.FunctionsFound, "summary shouldn't have any found functions")
 }
+
+func TestLCOVReader_RoundTrip(t *testing.T) {
+	lcovFile := `TN:
+SF:src/explore_me.cpp
+FN:3,_Z10exploreMeiiNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEE
+FNDA:12,_Z10exploreMeiiNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEE
+FNF:1
+FNH:1
+DA:3,12
+DA:4,0
+LF:2
+LH:1
+BRDA:4,1,0,12
+BRDA:4,1,1,-
+BRF:2
+BRH:1
+end_of_record
+SF:src/other.cpp
+FNF:0
+FNH:0
+LF:0
+LH:0
+BRF:0
+BRH:0
+end_of_record
+`
+	r := NewLCOVReader(strings.NewReader(lcovFile))
+	out := &strings.Builder{}
+	w := NewLCOVWriter(out)
+
+	var names []string
+	for {
+		sf, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, sf.Name)
+		require.NoError(t, w.Write(sf))
+	}
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, []string{"src/explore_me.cpp", "src/other.cpp"}, names)
+	// The writer preserves everything but the test name
+	assert.Equal(t, strings.TrimPrefix(lcovFile, "TN:\n"), out.String())
+}
+
+func TestLCOVReader_LongLine(t *testing.T) {
+	name := strings.Repeat("a", 1024*1024)
+	lcovFile := "SF:foo.cpp\nFN:1," + name + "\nend_of_record\n"
+
+	r := NewLCOVReader(strings.NewReader(lcovFile))
+	sf, err := r.Next()
+	require.NoError(t, err)
+	require.Len(t, sf.FunctionInformation, 1)
+	assert.Equal(t, name, sf.FunctionInformation[0].Name)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
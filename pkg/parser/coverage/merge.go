@@ -0,0 +1,191 @@
+package coverage
+
+import (
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// MergeLCOV merges the lcov reports read from inputs and writes the
+// result to out. Records of the same source file are combined by
+// summing up the execution counts of their lines, functions and
+// branches.
+//
+// All inputs must list their source files sorted by name, which is
+// what llvm-cov produces. This allows merging the inputs in a single
+// pass while only holding one record per input in memory.
+func MergeLCOV(out io.Writer, inputs ...io.Reader) error {
+	readers := make([]*LCOVReader, len(inputs))
+	heads := make([]*SourceFile, len(inputs))
+	for i, in := range inputs {
+		readers[i] = NewLCOVReader(in)
+		err := advance(readers, heads, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	w := NewLCOVWriter(out)
+	for {
+		// Find the smallest source file name among the current records
+		var name string
+		found := false
+		for _, sf := range heads {
+			if sf != nil && (!found || sf.Name < name) {
+				name = sf.Name
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+
+		var merged *SourceFile
+		for i, sf := range heads {
+			// An input can contain multiple records of the same file, so
+			// we consume records until the name changes
+			for sf != nil && sf.Name == name {
+				if merged == nil {
+					merged = sf
+				} else {
+					merged = MergeSourceFiles(merged, sf)
+				}
+				err := advance(readers, heads, i)
+				if err != nil {
+					return err
+				}
+				sf = heads[i]
+			}
+		}
+
+		err := w.Write(merged)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// advance reads the next record of input i into heads[i], which is set
+// to nil once the input is exhausted.
+func advance(readers []*LCOVReader, heads []*SourceFile, i int) error {
+	prev := heads[i]
+	sf, err := readers[i].Next()
+	if err == io.EOF {
+		heads[i] = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if prev != nil && sf.Name < prev.Name {
+		return errors.Errorf("Input %d of the lcov merge is not sorted by source file name: %q comes after %q",
+			i, sf.Name, prev.Name)
+	}
+	heads[i] = sf
+	return nil
+}
+
+// MergeSourceFiles combines two records of the same source file into a
+// new record.
+func MergeSourceFiles(a, b *SourceFile) *SourceFile {
+	merged := &SourceFile{Name: a.Name}
+
+	functionLines := map[string]int{}
+	for _, f := range concat(a.FunctionInformation, b.FunctionInformation) {
+		if _, ok := functionLines[f.Name]; !ok {
+			functionLines[f.Name] = f.Line
+			merged.FunctionInformation = append(merged.FunctionInformation, f)
+		}
+	}
+
+	functionExecutions := map[string]int{}
+	for _, f := range concat(a.FunctionExecutions, b.FunctionExecutions) {
+		functionExecutions[f.Name] += f.Executions
+	}
+	for name, executions := range functionExecutions {
+		merged.FunctionExecutions = append(merged.FunctionExecutions, FunctionExecution{
+			Name:       name,
+			Executions: executions,
+		})
+	}
+	sort.Slice(merged.FunctionExecutions, func(i, j int) bool {
+		fi, fj := merged.FunctionExecutions[i], merged.FunctionExecutions[j]
+		if functionLines[fi.Name] != functionLines[fj.Name] {
+			return functionLines[fi.Name] < functionLines[fj.Name]
+		}
+		return fi.Name < fj.Name
+	})
+
+	lineExecutions := map[int]int{}
+	for _, l := range concat(a.LineInformation, b.LineInformation) {
+		lineExecutions[l.Number] += l.Executions
+	}
+	for number, executions := range lineExecutions {
+		merged.LineInformation = append(merged.LineInformation, Line{
+			Number:     number,
+			Executions: executions,
+		})
+	}
+	sort.Slice(merged.LineInformation, func(i, j int) bool {
+		return merged.LineInformation[i].Number < merged.LineInformation[j].Number
+	})
+
+	type branchKey struct{ line, block, number int }
+	branchExecutions := map[branchKey]int{}
+	for _, br := range concat(a.BranchInformation, b.BranchInformation) {
+		branchExecutions[branchKey{br.Line, br.Block, br.Number}] += br.Executions
+	}
+	for key, executions := range branchExecutions {
+		merged.BranchInformation = append(merged.BranchInformation, Branch{
+			Line:       key.line,
+			Block:      key.block,
+			Number:     key.number,
+			Executions: executions,
+		})
+	}
+	sort.Slice(merged.BranchInformation, func(i, j int) bool {
+		bi, bj := merged.BranchInformation[i], merged.BranchInformation[j]
+		if bi.Line != bj.Line {
+			return bi.Line < bj.Line
+		}
+		if bi.Block != bj.Block {
+			return bi.Block < bj.Block
+		}
+		return bi.Number < bj.Number
+	})
+
+	merged.Overview = mergeOverviews(merged, &a.Overview, &b.Overview)
+	return merged
+}
+
+// mergeOverviews computes the overview of a merged record from its
+// detailed records. For records which only contain the summary lines
+// (e.g. LF/LH without any DA lines) the larger of both values is used,
+// because they most likely describe the same instrumented code.
+func mergeOverviews(sf *SourceFile, a, b *Overview) Overview {
+	o := overviewFromRecords(sf)
+	if len(sf.FunctionInformation) == 0 && len(sf.FunctionExecutions) == 0 {
+		o.FunctionsFound = max(a.FunctionsFound, b.FunctionsFound)
+		o.FunctionsHit = max(a.FunctionsHit, b.FunctionsHit)
+	}
+	if len(sf.LineInformation) == 0 {
+		o.LinesFound = max(a.LinesFound, b.LinesFound)
+		o.LinesHit = max(a.LinesHit, b.LinesHit)
+	}
+	if len(sf.BranchInformation) == 0 {
+		o.BranchesFound = max(a.BranchesFound, b.BranchesFound)
+		o.BranchesHit = max(a.BranchesHit, b.BranchesHit)
+	}
+	return o
+}
+
+// concat returns a new slice with the elements of a and b, without
+// modifying the backing array of a.
+func concat[T any](a, b []T) []T {
+	result := make([]T, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
@@ -0,0 +1,72 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeLCOV(t *testing.T) {
+	a := `SF:a.cpp
+FN:1,foo
+FNDA:0,foo
+DA:1,0
+DA:2,0
+BRDA:2,0,0,-
+BRDA:2,0,1,-
+end_of_record
+SF:c.cpp
+DA:1,1
+end_of_record
+`
+	b := `SF:a.cpp
+FN:1,foo
+FNDA:3,foo
+DA:1,3
+DA:2,0
+DA:3,1
+BRDA:2,0,0,3
+BRDA:2,0,1,-
+end_of_record
+SF:b.cpp
+DA:1,0
+end_of_record
+`
+	out := &strings.Builder{}
+	err := MergeLCOV(out, strings.NewReader(a), strings.NewReader(b))
+	require.NoError(t, err)
+
+	report, err := ParseLCOVFileIntoLCOVReport(strings.NewReader(out.String()))
+	require.NoError(t, err)
+	require.Len(t, report.SourceFiles, 3)
+	assert.Equal(t, "a.cpp", report.SourceFiles[0].Name)
+	assert.Equal(t, "b.cpp", report.SourceFiles[1].Name)
+	assert.Equal(t, "c.cpp", report.SourceFiles[2].Name)
+
+	merged := report.SourceFiles[0]
+	assert.Equal(t, []Function{{Name: "foo", Line: 1}}, merged.FunctionInformation)
+	assert.Equal(t, []FunctionExecution{{Name: "foo", Executions: 3}}, merged.FunctionExecutions)
+	assert.Equal(t, []Line{{1, 3}, {2, 0}, {3, 1}}, merged.LineInformation)
+	assert.Equal(t, []Branch{{2, 0, 0, 3}, {2, 0, 1, 0}}, merged.BranchInformation)
+	assert.Equal(t, Overview{
+		FunctionsFound: 1,
+		FunctionsHit:   1,
+		LinesFound:     3,
+		LinesHit:       2,
+		BranchesFound:  2,
+		BranchesHit:    1,
+	}, merged.Overview)
+}
+
+func TestMergeLCOV_Unsorted(t *testing.T) {
+	unsorted := `SF:b.cpp
+end_of_record
+SF:a.cpp
+end_of_record
+`
+	err := MergeLCOV(&strings.Builder{}, strings.NewReader(unsorted))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not sorted")
+}
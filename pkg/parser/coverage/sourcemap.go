@@ -0,0 +1,167 @@
+package coverage
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SourceMap is a source map (revision 3) which maps positions in a
+// transpiled file (e.g. JavaScript generated by the TypeScript
+// compiler) to positions in the original source files.
+type SourceMap struct {
+	Version    int      `json:"version"`
+	File       string   `json:"file"`
+	SourceRoot string   `json:"sourceRoot"`
+	Sources    []string `json:"sources"`
+	Mappings   string   `json:"mappings"`
+
+	// The decoded mappings, indexed by the zero-based line of the
+	// generated file and sorted by the generated column
+	lines [][]sourceMapping
+}
+
+type sourceMapping struct {
+	generatedColumn int
+	source          int
+	line            int
+	column          int
+}
+
+// ParseSourceMap parses and decodes a JSON source map.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	m := &SourceMap{}
+	err := json.Unmarshal(data, m)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse source map")
+	}
+	if m.Version != 3 {
+		return nil, errors.Errorf("Unsupported source map version %d", m.Version)
+	}
+
+	err = m.decodeMappings()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OriginalPosition returns the source file and the one-based line in
+// that file which the given one-based line and zero-based column of
+// the generated file map to.
+func (m *SourceMap) OriginalPosition(line, column int) (source string, originalLine int, ok bool) {
+	if line < 1 || line > len(m.lines) {
+		return "", 0, false
+	}
+	segments := m.lines[line-1]
+	if len(segments) == 0 {
+		return "", 0, false
+	}
+
+	// Find the last segment which starts at or before the column. If
+	// the column is before the first segment, we use the first one,
+	// because the position is most likely in leading whitespace.
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].generatedColumn > column
+	})
+	if i > 0 {
+		i--
+	}
+	s := segments[i]
+	return m.sourcePath(s.source), s.line + 1, true
+}
+
+func (m *SourceMap) sourcePath(i int) string {
+	source := m.Sources[i]
+	if m.SourceRoot != "" && !path.IsAbs(source) {
+		source = path.Join(m.SourceRoot, source)
+	}
+	return source
+}
+
+// ResolveSource returns the path of a source file of the map, resolved
+// relative to the directory of the generated file.
+func ResolveSource(generatedFile, source string) string {
+	source = strings.TrimPrefix(source, "file://")
+	if filepath.IsAbs(source) {
+		return filepath.Clean(source)
+	}
+	return filepath.Join(filepath.Dir(generatedFile), filepath.FromSlash(source))
+}
+
+func (m *SourceMap) decodeMappings() error {
+	var source, line, column int
+	for _, generatedLine := range strings.Split(m.Mappings, ";") {
+		var segments []sourceMapping
+		// The generated column is relative to the previous segment of
+		// the same line, all other fields are relative to the previous
+		// segment of the whole map
+		generatedColumn := 0
+		for _, segment := range strings.Split(generatedLine, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return err
+			}
+			generatedColumn += fields[0]
+			if len(fields) < 4 {
+				// The segment doesn't map to an original source
+				continue
+			}
+			source += fields[1]
+			line += fields[2]
+			column += fields[3]
+			if source < 0 || source >= len(m.Sources) {
+				return errors.Errorf("Invalid source index %d in source map", source)
+			}
+			segments = append(segments, sourceMapping{
+				generatedColumn: generatedColumn,
+				source:          source,
+				line:            line,
+				column:          column,
+			})
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].generatedColumn < segments[j].generatedColumn
+		})
+		m.lines = append(m.lines, segments)
+	}
+	return nil
+}
+
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes the base64 VLQ encoded fields of a mapping segment.
+func decodeVLQ(segment string) ([]int, error) {
+	var fields []int
+	value, shift := 0, 0
+	for _, c := range segment {
+		digit := strings.IndexRune(base64Alphabet, c)
+		if digit < 0 {
+			return nil, errors.Errorf("Invalid character %q in source map mappings", c)
+		}
+		value += (digit & 0x1f) << shift
+		if digit&0x20 != 0 {
+			// Continuation bit is set
+			shift += 5
+			continue
+		}
+		// The lowest bit is the sign
+		if value&1 != 0 {
+			fields = append(fields, -(value >> 1))
+		} else {
+			fields = append(fields, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, errors.Errorf("Incomplete VLQ value in source map segment %q", segment)
+	}
+	return fields, nil
+}
@@ -0,0 +1,53 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceMap_OriginalPosition(t *testing.T) {
+	// Maps generated line 1 to line 1 of a.ts, generated line 2 to
+	// line 4 of a.ts (column 4 onwards to line 2 of b.ts), and
+	// generated line 4 to line 3 of b.ts
+	data := `{
+  "version": 3,
+  "sourceRoot": "../src",
+  "sources": ["a.ts", "b.ts"],
+  "mappings": "AAAA;AAGA,ICFA;;AAEA"
+}`
+	m, err := ParseSourceMap([]byte(data))
+	require.NoError(t, err)
+
+	source, line, ok := m.OriginalPosition(1, 0)
+	require.True(t, ok)
+	assert.Equal(t, "../src/a.ts", source)
+	assert.Equal(t, 1, line)
+
+	source, line, ok = m.OriginalPosition(2, 2)
+	require.True(t, ok)
+	assert.Equal(t, "../src/a.ts", source)
+	assert.Equal(t, 4, line)
+
+	source, line, ok = m.OriginalPosition(2, 10)
+	require.True(t, ok)
+	assert.Equal(t, "../src/b.ts", source)
+	assert.Equal(t, 2, line)
+
+	_, _, ok = m.OriginalPosition(3, 0)
+	assert.False(t, ok, "line without mappings")
+
+	source, line, ok = m.OriginalPosition(4, 0)
+	require.True(t, ok)
+	assert.Equal(t, "../src/b.ts", source)
+	assert.Equal(t, 4, line)
+}
+
+func TestParseSourceMap_Invalid(t *testing.T) {
+	_, err := ParseSourceMap([]byte(`{"version": 2, "sources": [], "mappings": ""}`))
+	assert.Error(t, err)
+
+	_, err = ParseSourceMap([]byte(`{"version": 3, "sources": ["a.ts"], "mappings": "A!AA"}`))
+	assert.Error(t, err)
+}
@@ -114,7 +114,12 @@ type Options struct {
 	StartupOutputWriter io.Writer
 	// The directory to which paths in the stack trace are made relative to
 	ProjectDir string
-	SourceMap  *sourcemap.SourceMap
+	// A directory which contains a copy of the source files and the
+	// function which maps source paths to it, see
+	// stacktrace.ParserOptions
+	SourceDir       string
+	LocalSourcePath func(path string) (string, bool)
+	SourceMap       *sourcemap.SourceMap
 }
 
 func NewLibfuzzerOutputParser(options *Options) *parser {
@@ -628,6 +633,8 @@ func (p *parser) finalizeAndSendPendingFinding(ctx context.Context) error {
 	// Parse the stack trace
 	parserOpts := &stacktrace.ParserOptions{
 		ProjectDir:      p.ProjectDir,
+		SourceDir:       p.SourceDir,
+		LocalSourcePath: p.LocalSourcePath,
 		SourceMap:       p.SourceMap,
 		SupportJazzer:   p.SupportJazzer,
 		SupportJazzerJS: p.SupportJazzerJS,
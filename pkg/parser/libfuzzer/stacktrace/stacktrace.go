@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
+	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/regexutil"
 )
 
@@ -47,7 +48,16 @@ func EncodeStackTrace(stacktrace []*StackFrame) []byte {
 }
 
 type ParserOptions struct {
-	ProjectDir      string
+	ProjectDir string
+	// A directory which contains a copy of the source files of the
+	// project, e.g. the sources included in a bundle. Paths which are
+	// mapped to a file in it by LocalSourcePath are made relative to it
+	// instead of ProjectDir.
+	SourceDir string
+	// LocalSourcePath maps a source path from the debug info, which can
+	// be a path on another machine, to the copy of the source file in
+	// SourceDir (see archive.Sources.LocalPath).
+	LocalSourcePath func(path string) (string, bool)
 	SourceMap       *sourcemap.SourceMap
 	SupportJazzer   bool
 	SupportJazzerJS bool
@@ -202,7 +212,9 @@ func (p *parser) validateSourceFile(sourceFile string, function string) string {
 	// because that only produces relative paths if the compiler
 	// command-line also contained relative paths to the source files.
 	path := sourceFile
-	if filepath.IsAbs(sourceFile) {
+	if localPath, ok := p.localSourceFile(sourceFile); ok {
+		path, err = filepath.Rel(p.SourceDir, localPath)
+	} else if filepath.IsAbs(sourceFile) {
 		path, err = filepath.Rel(p.ProjectDir, path)
 		// We don't return the error here, because on Windows an error
 		// is returned when the paths are on different drives (e.g. C:
@@ -306,6 +318,20 @@ func (p *parser) getJavaSourceFilePath(sourceFile string, function string) strin
 	return sourceFile
 }
 
+// localSourceFile returns the path of the copy of the source file in
+// SourceDir. The second return value is false if there is no copy.
+func (p *parser) localSourceFile(sourceFile string) (string, bool) {
+	if p.SourceDir == "" || p.LocalSourcePath == nil || !filepath.IsAbs(sourceFile) {
+		return "", false
+	}
+	localPath, ok := p.LocalSourcePath(sourceFile)
+	if !ok {
+		return "", false
+	}
+	exists, err := fileutil.Exists(localPath)
+	return localPath, err == nil && exists
+}
+
 func removeLastPart(packageName string) string {
 	sepIndex := strings.LastIndex(packageName, ".")
 	if sepIndex > 0 {
@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
 )
 
@@ -179,6 +181,38 @@ func TestStackTrace(t *testing.T) {
 	}
 }
 
+func TestStackTrace_SourceDir(t *testing.T) {
+	// The fuzzer was built in buildDir on another machine, the bundle
+	// which contains it includes a copy of api.cpp in sourceDir
+	buildDir := filepath.Join(os.TempDir(), "build-machine", "project")
+	sourceDir := testutil.MkdirTemp(t, "", "bundle-src-")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "lib", "api.cpp"), nil, 0o644))
+	sources := &archive.Sources{Dir: sourceDir, BuildDir: buildDir}
+
+	projectDir := filepath.Join(os.TempDir(), "project")
+	parser, err := NewParser(&ParserOptions{
+		ProjectDir:      projectDir,
+		SourceDir:       sourceDir,
+		LocalSourcePath: sources.LocalPath,
+	})
+	require.NoError(t, err)
+
+	logs := []string{
+		fmt.Sprintf("    #0 0x50a3a1 in DoStuff %s:24:10", filepath.Join(buildDir, "lib", "api.cpp")),
+		// Not included in the bundle
+		fmt.Sprintf("    #1 0x50a3a2 in Other %s:5:1", filepath.Join(buildDir, "other.cpp")),
+		fmt.Sprintf("    #2 0x50a3a3 in LLVMFuzzerTestOneInput %s:11:3", filepath.Join(projectDir, "fuzz_targets", "do_stuff_fuzzer.cpp")),
+	}
+	trace, err := parser.Parse(logs)
+	require.NoError(t, err)
+	require.Len(t, trace, 2)
+	// Paths which are mapped to the source dir and paths below the
+	// project dir are both made relative
+	assert.Equal(t, "lib/api.cpp", trace[0].SourceFile)
+	assert.Equal(t, "fuzz_targets/do_stuff_fuzzer.cpp", trace[1].SourceFile)
+}
+
 func TestGetJavaSourceFilePath(t *testing.T) {
 	sourceFilePath := filepath.Join("src", "main", "java", "com", "example", "ExploreMe.java")
 	sourceMap := sourcemap.SourceMap{
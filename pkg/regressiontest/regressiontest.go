@@ -0,0 +1,235 @@
+// Package regressiontest generates regression tests from findings. A
+// regression test calls the fuzz test of a finding with the crashing
+// input of the finding, so that it fails if the bug comes back. JVM fuzz
+// tests don't need generated code, Jazzer replays the inputs in the
+// inputs directory of a @FuzzTest method when it's run as a JUnit test.
+package regressiontest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+//go:embed regression-test.cpp.tmpl
+var cppTemplate string
+
+//go:embed regression.test.js.tmpl
+var javaScriptTemplate string
+
+//go:embed regression.test.ts.tmpl
+var typeScriptTemplate string
+
+// The number of input bytes per line of C arrays and the number of
+// characters per line of base64 encoded inputs in the generated code
+const (
+	cArrayBytesPerLine = 12
+	base64CharsPerLine = 76
+)
+
+type Options struct {
+	FindingName string
+	// The identifier of the fuzz test, e.g. "my_fuzz_test" or
+	// "com.example.FuzzTestCase::myFuzzTest"
+	FuzzTest string
+	// The path of the source file of the fuzz test. Only required for
+	// JavaScript and TypeScript, where the regression test imports the
+	// fuzz test file.
+	FuzzTestFile string
+	// The crashing input of the finding
+	Input []byte
+}
+
+// The data which is passed to the templates
+type templateData struct {
+	FindingName string
+	FuzzTest    string
+	InputSize   int
+
+	// C/C++
+	CArray string
+
+	// JavaScript/TypeScript
+	FuzzTestModule string
+	JSBase64       string
+}
+
+// TestType returns the type of the test based on the file name
+// extension of the source file. Regression tests of JVM fuzz tests are
+// not generated, see JVMInputPath.
+func TestType(path string) (config.FuzzTestType, error) {
+	switch filepath.Ext(path) {
+	case ".c", ".cc", ".cpp", ".cxx":
+		return config.CPP, nil
+	case ".js":
+		return config.JavaScript, nil
+	case ".ts":
+		return config.TypeScript, nil
+	default:
+		return "", errors.Errorf("Unsupported file type of %s", path)
+	}
+}
+
+// Filename returns the path of the regression test for the finding,
+// which is placed next to the source file of the fuzz test and follows
+// the naming conventions of the language.
+func Filename(fuzzTestFile, findingName string) (string, error) {
+	testType, err := TestType(fuzzTestFile)
+	if err != nil {
+		return "", err
+	}
+
+	var filename string
+	switch testType {
+	case config.CPP:
+		filename = findingName + "_regression_test" + filepath.Ext(fuzzTestFile)
+	case config.JavaScript, config.TypeScript:
+		filename = findingName + ".regression.test" + filepath.Ext(fuzzTestFile)
+	}
+	return filepath.Join(filepath.Dir(fuzzTestFile), filename), nil
+}
+
+// Generate returns the source code of the regression test which is
+// written to path. The language of the test is determined by the file
+// name extension of path.
+func Generate(path string, opts *Options) ([]byte, error) {
+	testType, err := TestType(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &templateData{
+		FindingName: opts.FindingName,
+		FuzzTest:    opts.FuzzTest,
+		InputSize:   len(opts.Input),
+	}
+
+	var tmpl string
+	switch testType {
+	case config.CPP:
+		tmpl = cppTemplate
+		data.CArray = cArray(opts.Input)
+
+	case config.JavaScript, config.TypeScript:
+		tmpl = javaScriptTemplate
+		if testType == config.TypeScript {
+			tmpl = typeScriptTemplate
+		}
+		if opts.FuzzTestFile == "" {
+			return nil, errors.New("The fuzz test file is required to generate JavaScript and TypeScript regression tests")
+		}
+		data.FuzzTestModule, err = relativeModule(filepath.Dir(path), opts.FuzzTestFile)
+		if err != nil {
+			return nil, err
+		}
+		data.JSBase64 = base64Lines(opts.Input, "\t", " +\n")
+	}
+
+	t, err := template.New(filepath.Base(path)).Parse(tmpl)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// JVMInputPath returns the path to which the crashing input of the
+// finding is written to turn it into a regression test of the JVM fuzz
+// test. When a @FuzzTest method is run as a JUnit test, Jazzer replays
+// the inputs in the <Class>Inputs/<method> resource directory of the
+// test class, so no code needs to be generated. The resource directory
+// is derived from the source file of the fuzz test, e.g. src/test/java
+// is replaced by src/test/resources. If the source file is unknown, the
+// resource directory of the project is used.
+func JVMInputPath(projectDir, fuzzTestFile, fuzzTest, findingName string) (string, error) {
+	className, method, found := strings.Cut(fuzzTest, "::")
+	if !found {
+		return "", errors.Errorf("Fuzz test %s is not a @FuzzTest method, Jazzer only replays the inputs of @FuzzTest methods", fuzzTest)
+	}
+	parts := strings.Split(className, ".")
+	packageDir := filepath.Join(parts[:len(parts)-1]...)
+
+	resourcesDir := filepath.Join(projectDir, "src", "test", "resources")
+	if fuzzTestFile != "" {
+		// The source file is in the package directory below the test
+		// source root, which is next to the resources directory
+		sourceRoot, found := strings.CutSuffix(filepath.Dir(fuzzTestFile), string(filepath.Separator)+packageDir)
+		if packageDir == "" {
+			sourceRoot, found = filepath.Dir(fuzzTestFile), true
+		}
+		if found {
+			resourcesDir = filepath.Join(filepath.Dir(sourceRoot), "resources")
+		}
+	}
+
+	return filepath.Join(resourcesDir, packageDir, parts[len(parts)-1]+"Inputs", method, findingName), nil
+}
+
+// cArray returns the elements of a C array initializer which contains
+// the input. Empty arrays are not allowed in C, so an empty input is
+// represented by a single zero byte, which is not passed to the fuzz
+// test because the size is zero.
+func cArray(input []byte) string {
+	if len(input) == 0 {
+		return "  0x00"
+	}
+	var lines []string
+	for start := 0; start < len(input); start += cArrayBytesPerLine {
+		end := min(start+cArrayBytesPerLine, len(input))
+		var elems []string
+		for _, b := range input[start:end] {
+			elems = append(elems, fmt.Sprintf("0x%02x", b))
+		}
+		lines = append(lines, "  "+strings.Join(elems, ", ")+",")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// base64Lines returns the base64 encoded input as string literals, one
+// per line, which are joined by the separator.
+func base64Lines(input []byte, indent, sep string) string {
+	encoded := base64.StdEncoding.EncodeToString(input)
+	if encoded == "" {
+		return indent + `""`
+	}
+	var lines []string
+	for start := 0; start < len(encoded); start += base64CharsPerLine {
+		end := min(start+base64CharsPerLine, len(encoded))
+		lines = append(lines, fmt.Sprintf("%s%q", indent, encoded[start:end]))
+	}
+	return strings.Join(lines, sep)
+}
+
+// relativeModule returns the module path which imports the file from
+// the directory, without the file name extension.
+func relativeModule(dir, file string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	rel, err := filepath.Rel(absDir, absFile)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
@@ -0,0 +1,91 @@
+package regressiontest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+func TestFilename(t *testing.T) {
+	tests := []struct {
+		fuzzTestFile string
+		expected     string
+	}{
+		{filepath.Join("src", "fuzz_test.cpp"), filepath.Join("src", "focused_turing_regression_test.cpp")},
+		{filepath.Join("src", "fuzz_test.c"), filepath.Join("src", "focused_turing_regression_test.c")},
+		{filepath.Join("test", "myTest.fuzz.js"), filepath.Join("test", "focused_turing.regression.test.js")},
+		{filepath.Join("test", "myTest.fuzz.ts"), filepath.Join("test", "focused_turing.regression.test.ts")},
+	}
+	for _, tt := range tests {
+		filename, err := Filename(tt.fuzzTestFile, "focused_turing")
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, filename)
+	}
+
+	_, err := Filename("fuzz_test.py", "focused_turing")
+	assert.Error(t, err)
+	// The regression tests of JVM fuzz tests are inputs, see JVMInputPath
+	_, err = Filename("FuzzTestCase.java", "focused_turing")
+	assert.Error(t, err)
+}
+
+func TestGenerate_CPP(t *testing.T) {
+	content, err := Generate("focused_turing_regression_test.cpp", &Options{
+		FindingName: "focused_turing",
+		FuzzTest:    "my_fuzz_test",
+		Input:       []byte("FUZZING\x00"),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "  0x46, 0x55, 0x5a, 0x5a, 0x49, 0x4e, 0x47, 0x00,\n};")
+	assert.Contains(t, string(content), "LLVMFuzzerTestOneInput(crashing_input, 8);")
+	assert.Contains(t, string(content), "finding focused_turing of the fuzz test my_fuzz_test")
+
+	content, err = Generate("focused_turing_regression_test.cpp", &Options{FindingName: "focused_turing"})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "LLVMFuzzerTestOneInput(crashing_input, 0);")
+}
+
+func TestJVMInputPath(t *testing.T) {
+	moduleDir := filepath.Join("project", "module")
+	fuzzTestFile := filepath.Join(moduleDir, "src", "test", "kotlin", "com", "example", "FuzzTestCase.kt")
+	path, err := JVMInputPath("project", fuzzTestFile, "com.example.FuzzTestCase::myFuzzTest", "focused_turing")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(moduleDir, "src", "test", "resources", "com", "example", "FuzzTestCaseInputs", "myFuzzTest", "focused_turing"), path)
+
+	// Without the source file, the resources of the project are used
+	path, err = JVMInputPath("project", "", "FuzzTestCase::myFuzzTest", "focused_turing")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("project", "src", "test", "resources", "FuzzTestCaseInputs", "myFuzzTest", "focused_turing"), path)
+
+	// Jazzer only replays the inputs of @FuzzTest methods
+	_, err = JVMInputPath("project", "", "com.example.FuzzTestCase", "focused_turing")
+	assert.Error(t, err)
+}
+
+func TestGenerate_JavaScript(t *testing.T) {
+	content, err := Generate(filepath.Join("test", "focused_turing.regression.test.js"), &Options{
+		FindingName:  "focused_turing",
+		FuzzTest:     "myTest",
+		FuzzTestFile: filepath.Join("test", "myTest.fuzz.js"),
+		Input:        []byte("FUZZING"),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `require("./myTest.fuzz")`)
+	assert.Contains(t, string(content), "Buffer.from(\n\t\"RlVaWklORw==\",\n\t\"base64\",\n);")
+
+	_, err = Generate("focused_turing.regression.test.ts", &Options{FindingName: "focused_turing"})
+	assert.Error(t, err)
+}
+
+func TestTestType(t *testing.T) {
+	testType, err := TestType("fuzz_test.cc")
+	require.NoError(t, err)
+	assert.Equal(t, config.CPP, testType)
+
+	_, err = TestType("FuzzTestCase.kt")
+	assert.Error(t, err)
+}
@@ -67,49 +67,11 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
-	classPath := strings.Join(r.ClassPaths, string(os.PathListSeparator))
-
-	javaBin, err := runfiles.Finder.JavaPath()
+	args, err := r.Command()
 	if err != nil {
 		return err
 	}
 
-	// Print version information for debugging purposes
-	r.printDebugVersionInfos(classPath, javaBin)
-
-	args := []string{javaBin}
-
-	// class path
-	args = append(args, "-cp", classPath)
-
-	// JVM tuning args
-	// See https://github.com/CodeIntelligenceTesting/jazzer/blob/main/docs/common.md#recommended-jvm-options
-	args = append(args,
-		// Preserve and emit stack trace information even on hot paths.
-		// This may hurt performance, but also helps find flaky bugs.
-		"-XX:-OmitStackTraceInFastThrow",
-		// Optimize GC for high throughput rather than low latency.
-		"-XX:+UseParallelGC",
-		// CriticalJNINatives has been removed in JDK 18.
-		"-XX:+IgnoreUnrecognizedVMOptions",
-		// Improves the performance of Jazzer's tracing hooks.
-		"-XX:+CriticalJNINatives",
-		// Disable warnings caused by the use of Jazzer's Java agent on JDK 21+.
-		"-XX:+EnableDynamicAgentLoading",
-	)
-
-	// Jazzer main class
-	args = append(args, options.JazzerMainClass)
-
-	// ----------------------
-	// --- Jazzer options ---
-	// ----------------------
-	if r.AutofuzzTarget != "" {
-		args = append(args, options.JazzerAutoFuzzFlag(r.AutofuzzTarget))
-	} else {
-		args = append(args, options.JazzerTargetClassFlag(r.TargetClass))
-		args = append(args, options.JazzerTargetMethodFlag(r.TargetMethod))
-	}
 	// -------------------------
 	// --- libfuzzer options ---
 	// -------------------------
@@ -121,13 +83,8 @@ func (r *Runner) Run(ctx context.Context) error {
 		args = append(args, options.LibFuzzerMaxTotalTimeFlag(timeoutStr))
 	}
 
-	// Tell libfuzzer which dictionary it should use
-	if r.Dictionary != "" {
-		args = append(args, options.LibFuzzerDictionaryFlag(r.Dictionary))
-	}
-
-	// Add user-specified Jazzer/libfuzzer options
-	args = append(args, r.EngineArgs...)
+	// Add the user-specified dictionary and Jazzer/libfuzzer options
+	args = append(args, r.UserArgs()...)
 
 	// Tell Jazzer which corpus directory it should use, if specified.
 	// By default, Jazzer stores the generated corpus in
@@ -159,6 +116,59 @@ func (r *Runner) Run(ctx context.Context) error {
 	return r.RunLibfuzzerAndReport(ctx, args, env)
 }
 
+// Command returns the command which runs Jazzer with the fuzz test: the
+// java binary, the class path, the JVM options and the Jazzer options.
+// The libFuzzer arguments must be appended.
+func (r *Runner) Command() ([]string, error) {
+	classPath := strings.Join(r.ClassPaths, string(os.PathListSeparator))
+
+	javaBin, err := runfiles.Finder.JavaPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Print version information for debugging purposes
+	r.printDebugVersionInfos(classPath, javaBin)
+
+	args := []string{javaBin}
+
+	// class path
+	args = append(args, "-cp", classPath)
+
+	// JVM tuning args
+	// See https://github.com/CodeIntelligenceTesting/jazzer/blob/main/docs/common.md#recommended-jvm-options
+	args = append(args,
+		// Preserve and emit stack trace information even on hot paths.
+		// This may hurt performance, but also helps find flaky bugs.
+		"-XX:-OmitStackTraceInFastThrow",
+		// Optimize GC for high throughput rather than low latency.
+		"-XX:+UseParallelGC",
+		// CriticalJNINatives has been removed in JDK 18.
+		"-XX:+IgnoreUnrecognizedVMOptions",
+		// Improves the performance of Jazzer's tracing hooks.
+		"-XX:+CriticalJNINatives",
+		// Disable warnings caused by the use of Jazzer's Java agent on JDK 21+.
+		"-XX:+EnableDynamicAgentLoading",
+	)
+
+	// Jazzer main class
+	args = append(args, options.JazzerMainClass)
+
+	// ----------------------
+	// --- Jazzer options ---
+	// ----------------------
+	if r.AutofuzzTarget != "" {
+		args = append(args, options.JazzerAutoFuzzFlag(r.AutofuzzTarget))
+	} else {
+		args = append(args, options.JazzerTargetClassFlag(r.TargetClass))
+		if r.TargetMethod != "" {
+			args = append(args, options.JazzerTargetMethodFlag(r.TargetMethod))
+		}
+	}
+
+	return args, nil
+}
+
 func (r *Runner) ProduceJacocoReport(ctx context.Context, outputFile string) (string, error) {
 	err := r.ValidateOptions()
 	if err != nil {
@@ -62,6 +62,18 @@ func (r *Runner) Run(ctx context.Context) error {
 	// Print version information for debugging purposes
 	r.printDebugVersionInfos()
 
+	env, err := r.FuzzerEnvironment()
+	if err != nil {
+		return err
+	}
+
+	return r.RunLibfuzzerAndReport(ctx, r.Command(), env)
+}
+
+// Command returns the command which runs the fuzz tests via jest. The
+// libFuzzer arguments are passed via the environment, see
+// FuzzerEnvironment.
+func (r *Runner) Command() []string {
 	args := []string{"npx", "jest"}
 
 	// ---------------------------
@@ -71,12 +83,7 @@ func (r *Runner) Run(ctx context.Context) error {
 	args = append(args, options.JazzerJSTestNamePatternFlag(r.TestNamePattern))
 	args = append(args, options.JestTestFailureExitCodeFlag(fuzzer_runner.LibFuzzerErrorExitCode))
 
-	env, err := r.FuzzerEnvironment()
-	if err != nil {
-		return err
-	}
-
-	return r.RunLibfuzzerAndReport(ctx, args, env)
+	return args
 }
 
 func (r *Runner) FuzzerEnvironment() ([]string, error) {
@@ -91,8 +98,12 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 		return nil, err
 	}
 
-	if len(r.LibfuzzerOptions.EngineArgs) > 0 {
-		env, err = r.setEngineArgsAsJazzerFlags(env)
+	args, err := r.fuzzerArgs()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		env, err = r.setEngineArgsAsJazzerFlags(env, args)
 		if err != nil {
 			return nil, err
 		}
@@ -101,6 +112,30 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 	return env, nil
 }
 
+// fuzzerArgs returns the libFuzzer arguments which are passed to
+// Jazzer.js: the dictionary, the user-specified engine args and the seed
+// corpus directories. Jest is run in the project directory, so the paths
+// are made absolute.
+func (r *Runner) fuzzerArgs() ([]string, error) {
+	var args []string
+	if r.Dictionary != "" {
+		dict, err := filepath.Abs(r.Dictionary)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		args = append(args, options.LibFuzzerDictionaryFlag(dict))
+	}
+	args = append(args, r.LibfuzzerOptions.EngineArgs...)
+	for _, dir := range r.SeedCorpusDirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		args = append(args, dir)
+	}
+	return args, nil
+}
+
 func (r *Runner) Cleanup(ctx context.Context) {
 	r.Runner.Cleanup(ctx)
 }
@@ -130,13 +165,13 @@ type jazzerJSRC struct {
 	Timeout       int32    `json:"timeout"`
 }
 
-// setEngineArgsAsJazzerFlags sets the engine args for libfuzzer with the
+// setEngineArgsAsJazzerFlags sets the args for libfuzzer with the
 // environment variables JAZZER_FUZZER_OPTIONS and JAZZER_TIMEOUT.
 // It checks if a .jazzerjsrc file exists in the project and prioritizes
 // those values over the engine args. Setting the JAZZER_FUZZER_OPTIONS or
 // JAZZER_TIMEOUT environment variable will make Jazzer.js ignore the values
 // from the .jazzerjsrc so those values have to be added in the env too.
-func (r *Runner) setEngineArgsAsJazzerFlags(env []string) ([]string, error) {
+func (r *Runner) setEngineArgsAsJazzerFlags(env []string, args []string) ([]string, error) {
 	// Check if .jazzerjsrc exists and store values
 	var rc jazzerJSRC
 	jazzerJSRCPath := filepath.Join(r.ProjectDir, ".jazzerjsrc")
@@ -156,7 +191,13 @@ func (r *Runner) setEngineArgsAsJazzerFlags(env []string) ([]string, error) {
 	}
 
 	fuzzerOptions := rc.FuzzerOptions
-	for _, arg := range r.LibfuzzerOptions.EngineArgs {
+	for _, arg := range args {
+		// Positional arguments are additional corpus directories
+		if !strings.HasPrefix(arg, "-") {
+			fuzzerOptions = append(fuzzerOptions, arg)
+			continue
+		}
+
 		flag, value, found := strings.Cut(arg, "=")
 		if !found {
 			continue
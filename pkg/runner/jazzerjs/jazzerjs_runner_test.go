@@ -1,6 +1,7 @@
 package jazzerjs
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -79,3 +80,25 @@ func TestRunner_FuzzerEnvironmentWithJazzerJSRC_OnlyDuplicates(t *testing.T) {
 	assert.Len(t, env, 1)
 	assert.Contains(t, env, "JAZZER_FUZZ=1")
 }
+
+// TestRunner_FuzzerEnvironmentWithDictionaryAndSeeds checks that the
+// dictionary and seed corpus directories are passed to Jazzer.js as
+// absolute paths.
+func TestRunner_FuzzerEnvironmentWithDictionaryAndSeeds(t *testing.T) {
+	r := NewRunner(&RunnerOptions{
+		LibfuzzerOptions: &libfuzzer.RunnerOptions{
+			Dictionary:     "dict",
+			EngineArgs:     []string{"-seed=1"},
+			SeedCorpusDirs: []string{"seeds/a"},
+		},
+	})
+
+	env, err := r.FuzzerEnvironment()
+	require.NoError(t, err)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	expected := fmt.Sprintf("JAZZER_FUZZER_OPTIONS=[%q,\"-seed=1\",%q]",
+		"-dict="+filepath.Join(cwd, "dict"), filepath.Join(cwd, "seeds", "a"))
+	assert.Contains(t, env, expected)
+}
@@ -0,0 +1,378 @@
+package libfuzzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/binary"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// CoverageSnapshot is an entry of the line coverage history which is
+// recorded while fuzzing.
+type CoverageSnapshot struct {
+	Timestamp    time.Time `json:"timestamp"`
+	NewInputs    int       `json:"new_inputs"`
+	LinesHit     int       `json:"lines_hit"`
+	LinesFound   int       `json:"lines_found"`
+	LineCoverage float64   `json:"line_coverage"`
+}
+
+// coverageSnapshotter incrementally produces coverage reports while
+// a fuzzing run is in progress. Each snapshot only replays the corpus
+// entries which were added since the last snapshot and merges the
+// resulting profile into the accumulated profile of all previous
+// snapshots.
+type coverageSnapshotter struct {
+	binary      string
+	libraryDirs []string
+	objects     []string
+	corpusDirs  []string
+	outputPath  string
+	historyPath string
+	finder      runfiles.RunfilesFinder
+
+	workDir string
+	seen    map[string]bool
+	history []*CoverageSnapshot
+	count   int
+	// Takes a snapshot, replaced in tests
+	takeSnapshot func(ctx context.Context) error
+	// Snapshots are taken periodically and at the end of the run,
+	// which must not overlap
+	mutex sync.Mutex
+}
+
+func newCoverageSnapshotter(r *Runner) (*coverageSnapshotter, error) {
+	workDir, err := os.MkdirTemp("", "coverage-snapshots-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	corpusDirs := append([]string{r.GeneratedCorpusDir}, r.SeedCorpusDirs...)
+	corpusDirs = append(corpusDirs, r.CoverageCorpusDirs...)
+	s := &coverageSnapshotter{
+		binary:      r.CoverageBinary,
+		libraryDirs: r.CoverageLibraryDirs,
+		objects:     r.CoverageObjects,
+		corpusDirs:  corpusDirs,
+		outputPath:  r.CoverageOutputPath,
+		historyPath: r.CoverageHistoryPath,
+		finder:      runfiles.Finder,
+		workDir:     workDir,
+		seen:        map[string]bool{},
+	}
+	s.takeSnapshot = s.snapshot
+	return s, nil
+}
+
+// runWhile runs the fuzzer and takes a snapshot every interval while it
+// is running, if the interval is positive. A final snapshot which
+// includes all inputs of the run is always taken after the fuzzer
+// returned, also if it failed, because a crash is when the last point
+// of the coverage history matters most.
+func (s *coverageSnapshotter) runWhile(ctx context.Context, interval time.Duration, fuzz func() error) error {
+	snapshotCtx, cancelSnapshots := context.WithCancel(ctx)
+	snapshotsDone := make(chan struct{})
+	if interval > 0 {
+		go func() {
+			s.run(snapshotCtx, interval)
+			close(snapshotsDone)
+		}()
+	} else {
+		close(snapshotsDone)
+	}
+
+	fuzzErr := fuzz()
+	cancelSnapshots()
+	<-snapshotsDone
+
+	// The context of the run might be canceled already
+	err := s.takeSnapshot(context.Background())
+	if fuzzErr != nil {
+		if err != nil {
+			log.Errorf(err, "Failed to create coverage snapshot: %v", err.Error())
+		}
+		return fuzzErr
+	}
+	return err
+}
+
+// run takes a snapshot every interval until the context is done.
+func (s *coverageSnapshotter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.takeSnapshot(ctx)
+			if err != nil {
+				// A failed snapshot should not abort the fuzzing run
+				log.Errorf(err, "Failed to create coverage snapshot: %v", err.Error())
+			}
+		}
+	}
+}
+
+func (s *coverageSnapshotter) snapshot(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	inputsDir := filepath.Join(s.workDir, fmt.Sprintf("inputs-%d", s.count))
+	newInputs, err := s.collectNewInputs(inputsDir)
+	defer fileutil.Cleanup(inputsDir)
+	if err != nil {
+		return err
+	}
+
+	if len(newInputs) > 0 || s.count == 1 {
+		err = s.replay(ctx, inputsDir)
+		if err == nil {
+			err = s.exportLCOV(ctx)
+		}
+		if err != nil {
+			// Replay the inputs again in the next snapshot
+			for _, path := range newInputs {
+				delete(s.seen, path)
+			}
+			return err
+		}
+	}
+
+	snapshot, err := s.summarize(len(newInputs))
+	if err != nil {
+		return err
+	}
+	s.history = append(s.history, snapshot)
+	log.Infof("Coverage snapshot: %.1f%% lines covered (%d / %d), %d new inputs",
+		snapshot.LineCoverage, snapshot.LinesHit, snapshot.LinesFound, snapshot.NewInputs)
+
+	return s.writeHistory()
+}
+
+// collectNewInputs links all corpus entries which weren't replayed by
+// a previous snapshot into inputsDir and returns their paths.
+func (s *coverageSnapshotter) collectNewInputs(inputsDir string) ([]string, error) {
+	err := os.MkdirAll(inputsDir, 0o755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var paths []string
+	for _, dir := range s.corpusDirs {
+		err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return errors.WithStack(err)
+			}
+			if d.IsDir() || s.seen[path] {
+				return nil
+			}
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			err = os.Symlink(absPath, filepath.Join(inputsDir, fmt.Sprintf("%d-%s", len(paths), d.Name())))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			s.seen[path] = true
+			paths = append(paths, path)
+			return nil
+		})
+		// nolint: wrapcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+func (s *coverageSnapshotter) replay(ctx context.Context, inputsDir string) error {
+	rawProfileDir := filepath.Join(s.workDir, fmt.Sprintf("profraw-%d", s.count))
+	err := os.MkdirAll(rawProfileDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(rawProfileDir)
+	mergeDir := filepath.Join(s.workDir, fmt.Sprintf("merge-%d", s.count))
+	err = os.MkdirAll(mergeDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(mergeDir)
+
+	// See llvm.CoverageGenerator.rawProfilePattern for why we use "%m"
+	// and "%c" here.
+	pattern := "%m.profraw"
+	if binary.SupportsLlvmProfileContinuousMode(s.binary) {
+		pattern = "%c" + pattern
+	}
+	var env []string
+	env, err = envutil.Setenv(env, "LLVM_PROFILE_FILE", filepath.Join(rawProfileDir, pattern))
+	if err != nil {
+		return err
+	}
+	env, err = envutil.Setenv(env, "NO_CIFUZZ", "1")
+	if err != nil {
+		return err
+	}
+	if len(s.libraryDirs) > 0 {
+		env, err = fuzzer_runner.SetLDLibraryPath(env, s.libraryDirs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Use libFuzzer's crash-resistant merge mode, which runs all inputs
+	// even if some of them crash the target.
+	args := []string{
+		"-artifact_prefix=" + mergeDir + "/",
+		"-merge=1",
+		mergeDir,
+		inputsDir,
+	}
+	cmd := executil.CommandContext(ctx, s.binary, args...)
+	cmd.Env, err = envutil.Copy(os.Environ(), env)
+	if err != nil {
+		return err
+	}
+	errStream := &bytes.Buffer{}
+	cmd.Stderr = errStream
+	log.Debugf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, env))
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to replay corpus with coverage binary:\n%s", errStream.String())
+	}
+
+	rawProfiles, err := filepath.Glob(filepath.Join(rawProfileDir, "*.profraw"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(rawProfiles) == 0 {
+		return errors.Errorf("%s did not generate .profraw files in %s", s.binary, rawProfileDir)
+	}
+
+	// Merge the new raw profiles into the accumulated profile
+	profile := s.indexedProfilePath()
+	exists, err := fileutil.Exists(profile)
+	if err != nil {
+		return err
+	}
+	inputs := rawProfiles
+	if exists {
+		inputs = append(inputs, profile)
+	}
+	llvmProfData, err := s.finder.LLVMProfDataPath()
+	if err != nil {
+		return err
+	}
+	mergedProfile := profile + ".new"
+	mergeArgs := append([]string{"merge", "-sparse", "-o", mergedProfile}, inputs...)
+	mergeCmd := exec.CommandContext(ctx, llvmProfData, mergeArgs...)
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(mergeCmd.Args), " "))
+	out, err := mergeCmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to merge coverage profiles:\n%s", string(out))
+	}
+	return errors.WithStack(os.Rename(mergedProfile, profile))
+}
+
+// exportLCOV writes the accumulated coverage as LCOV report to the
+// output path, replacing the report of the previous snapshot.
+func (s *coverageSnapshotter) exportLCOV(ctx context.Context) error {
+	llvmCov, err := s.finder.LLVMCovPath()
+	if err != nil {
+		return err
+	}
+	args := []string{"export", "-format=lcov", "-instr-profile=" + s.indexedProfilePath(), s.binary}
+	for _, object := range s.objects {
+		args = append(args, "-object="+object)
+	}
+	cmd := exec.CommandContext(ctx, llvmCov, args...)
+	errStream := &bytes.Buffer{}
+	cmd.Stderr = errStream
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+	report, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to export coverage report:\n%s", errStream.String())
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.outputPath), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Write to a temporary file first, so that the rolling report is
+	// never observed in a partially written state
+	tmpPath := s.outputPath + ".tmp"
+	err = os.WriteFile(tmpPath, report, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpPath, s.outputPath))
+}
+
+func (s *coverageSnapshotter) summarize(newInputs int) (*CoverageSnapshot, error) {
+	snapshot := &CoverageSnapshot{
+		Timestamp: time.Now(),
+		NewInputs: newInputs,
+	}
+
+	f, err := os.Open(s.outputPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	summary, err := coverage.ParseLCOVReportIntoSummary(f)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.LinesHit = summary.Total.LinesHit
+	snapshot.LinesFound = summary.Total.LinesFound
+	if snapshot.LinesFound > 0 {
+		snapshot.LineCoverage = float64(snapshot.LinesHit) * 100 / float64(snapshot.LinesFound)
+	}
+	return snapshot, nil
+}
+
+func (s *coverageSnapshotter) writeHistory() error {
+	if s.historyPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.history, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(s.historyPath, data, 0o644))
+}
+
+func (s *coverageSnapshotter) indexedProfilePath() string {
+	return filepath.Join(s.workDir, filepath.Base(s.binary)+".profdata")
+}
+
+func (s *coverageSnapshotter) cleanup() {
+	fileutil.Cleanup(s.workDir)
+}
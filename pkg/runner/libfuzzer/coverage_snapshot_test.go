@@ -0,0 +1,112 @@
+package libfuzzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestCoverageSnapshotter_CollectNewInputs(t *testing.T) {
+	corpusDir := testutil.MkdirTemp(t, "", "corpus-")
+	seedsDir := testutil.MkdirTemp(t, "", "seeds-")
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "a"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(seedsDir, "b"), []byte("b"), 0o644))
+
+	s, err := newCoverageSnapshotter(NewRunner(&RunnerOptions{
+		GeneratedCorpusDir: corpusDir,
+		SeedCorpusDirs:     []string{seedsDir},
+		// Corpus directories which don't exist are ignored
+		CoverageCorpusDirs: []string{filepath.Join(seedsDir, "does-not-exist")},
+	}))
+	require.NoError(t, err)
+	defer s.cleanup()
+
+	inputs, err := s.collectNewInputs(filepath.Join(s.workDir, "inputs-1"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(corpusDir, "a"), filepath.Join(seedsDir, "b")}, inputs)
+	entries, err := os.ReadDir(filepath.Join(s.workDir, "inputs-1"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Only inputs added after the last call are collected
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "c"), []byte("c"), 0o644))
+	inputs, err = s.collectNewInputs(filepath.Join(s.workDir, "inputs-2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(corpusDir, "c")}, inputs)
+	content, err := os.ReadFile(filepath.Join(s.workDir, "inputs-2", "0-c"))
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(content))
+}
+
+func TestCoverageSnapshotter_RunWhile(t *testing.T) {
+	s, err := newCoverageSnapshotter(NewRunner(&RunnerOptions{}))
+	require.NoError(t, err)
+	defer s.cleanup()
+
+	var mutex sync.Mutex
+	var snapshots []bool // whether the fuzzer was still running
+	running := false
+	s.takeSnapshot = func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		snapshots = append(snapshots, running)
+		return nil
+	}
+	setRunning := func(r bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		running = r
+	}
+
+	// Snapshots are taken periodically while the fuzzer is running and
+	// once after it returned
+	err = s.runWhile(context.Background(), 10*time.Millisecond, func() error {
+		setRunning(true)
+		time.Sleep(100 * time.Millisecond)
+		setRunning(false)
+		return nil
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(snapshots), 2)
+	assert.True(t, snapshots[0])
+	assert.False(t, snapshots[len(snapshots)-1])
+
+	// Without an interval, only the final snapshot is taken
+	snapshots = nil
+	err = s.runWhile(context.Background(), 0, func() error { return nil })
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+}
+
+func TestCoverageSnapshotter_RunWhile_FuzzerError(t *testing.T) {
+	s, err := newCoverageSnapshotter(NewRunner(&RunnerOptions{}))
+	require.NoError(t, err)
+	defer s.cleanup()
+
+	snapshots := 0
+	s.takeSnapshot = func(ctx context.Context) error {
+		snapshots++
+		return errors.New("snapshot failed")
+	}
+
+	// The final snapshot is taken when the fuzzer fails, e.g. because
+	// it found a crash, and the error of the fuzzer is returned
+	fuzzErr := errors.New("crash")
+	err = s.runWhile(context.Background(), 0, func() error { return fuzzErr })
+	assert.Equal(t, fuzzErr, err)
+	assert.Equal(t, 1, snapshots)
+
+	// Errors of the final snapshot are returned if the fuzzer succeeded
+	err = s.runWhile(context.Background(), 0, func() error { return nil })
+	assert.EqualError(t, err, "snapshot failed")
+}
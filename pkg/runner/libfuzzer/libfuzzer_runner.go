@@ -48,6 +48,8 @@ type RunnerOptions struct {
 	LibraryDirs        []string
 	LogOutput          io.Writer
 	ProjectDir         string
+	SourceDir          string
+	LocalSourcePath    func(path string) (string, bool)
 	SourceMap          *sourcemap.SourceMap
 	ReadOnlyBindings   []string
 	ReportHandler      report.Handler
@@ -60,7 +62,21 @@ type RunnerOptions struct {
 	// report is produced.
 	CoverageBinary      string
 	CoverageLibraryDirs []string
-	CoverageOutputPath  string
+	// Additional objects (e.g. shared libraries) to include in the
+	// coverage report
+	CoverageObjects []string
+	// Additional corpus directories which are replayed for coverage,
+	// but not used for fuzzing
+	CoverageCorpusDirs []string
+	CoverageOutputPath string
+	// If set, coverage snapshots are taken in this interval while the
+	// fuzzer is running. Each snapshot replays the corpus entries added
+	// since the last snapshot and updates the LCOV report at
+	// CoverageOutputPath.
+	CoverageSnapshotInterval time.Duration
+	// If set, the line coverage of all snapshots is written as JSON
+	// to this path.
+	CoverageHistoryPath string
 }
 
 func (options *RunnerOptions) ValidateOptions() error {
@@ -119,13 +135,8 @@ func (r *Runner) Run(ctx context.Context) error {
 	timeoutSeconds := strconv.FormatInt(int64(r.Timeout.Seconds()), 10)
 	args = append(args, options.LibFuzzerMaxTotalTimeFlag(timeoutSeconds))
 
-	// Tell libfuzzer which dictionary it should use
-	if r.Dictionary != "" {
-		args = append(args, options.LibFuzzerDictionaryFlag(r.Dictionary))
-	}
-
-	// Add user-specified libfuzzer options
-	args = append(args, r.EngineArgs...)
+	// Add the user-specified dictionary and libfuzzer options
+	args = append(args, r.UserArgs()...)
 
 	// Tell libfuzzer which corpus directory it should use
 	args = append(args, r.GeneratedCorpusDir)
@@ -184,7 +195,29 @@ func (r *Runner) Run(ctx context.Context) error {
 		args = mj.Args
 	}
 
-	return r.RunLibfuzzerAndReport(ctx, args, env)
+	if r.CoverageBinary == "" || r.CoverageOutputPath == "" {
+		return r.RunLibfuzzerAndReport(ctx, args, env)
+	}
+
+	snapshotter, err := newCoverageSnapshotter(r)
+	if err != nil {
+		return err
+	}
+	defer snapshotter.cleanup()
+
+	return snapshotter.runWhile(ctx, r.CoverageSnapshotInterval, func() error {
+		return r.RunLibfuzzerAndReport(ctx, args, env)
+	})
+}
+
+// UserArgs returns the libFuzzer arguments which are specified by the
+// user: the dictionary flag and the engine args.
+func (r *Runner) UserArgs() []string {
+	var args []string
+	if r.Dictionary != "" {
+		args = append(args, options.LibFuzzerDictionaryFlag(r.Dictionary))
+	}
+	return append(args, r.EngineArgs...)
 }
 
 func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env []string) error {
@@ -207,6 +240,11 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 	}
 	defer cancelCmdCtx()
 	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	if r.SupportJazzerJS {
+		// Jest looks up its configuration and the fuzz tests relative
+		// to the working directory
+		r.cmd.Dir = r.ProjectDir
+	}
 	r.cmd.Env, err = envutil.Copy(os.Environ(), env)
 	if err != nil {
 		return err
@@ -269,6 +307,8 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 		KeepColor:           r.KeepColor,
 		StartupOutputWriter: startupOutputWriter,
 		ProjectDir:          r.ProjectDir,
+		SourceDir:           r.SourceDir,
+		LocalSourcePath:     r.LocalSourcePath,
 		SourceMap:           r.SourceMap,
 	})
 	reportsCh := make(chan *report.Report, MaxBufferedReports)
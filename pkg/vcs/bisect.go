@@ -0,0 +1,135 @@
+package vcs
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// BisectVerdict is the classification of a commit during git bisect.
+type BisectVerdict string
+
+const (
+	BisectGood BisectVerdict = "good"
+	BisectBad  BisectVerdict = "bad"
+	// The commit can't be tested, e.g. because it doesn't build
+	BisectSkip BisectVerdict = "skip"
+)
+
+var skippedCommitRegex = regexp.MustCompile(`(?m)^[0-9a-f]{40}$`)
+
+// Worktree is a temporary Git worktree with a detached HEAD, which is
+// used to check out other commits without modifying the working
+// directory of the user.
+type Worktree struct {
+	Dir string
+}
+
+// GitTopLevel returns the root directory of the Git repository which
+// contains dir.
+func GitTopLevel(dir string) (string, error) {
+	return git(dir, "rev-parse", "--show-toplevel")
+}
+
+// GitRevParse returns the full SHA of the commit which ref refers to in
+// the Git repository which contains dir.
+func GitRevParse(dir, ref string) (string, error) {
+	commit, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", errors.Errorf("%q is not a commit in the Git repository", ref)
+	}
+	return commit, nil
+}
+
+// GitCommitSummary returns the abbreviated SHA and the subject of the
+// commit.
+func GitCommitSummary(dir, commit string) (string, error) {
+	return git(dir, "show", "--no-patch", "--format=%h %s", commit)
+}
+
+// AddWorktree creates a worktree of the Git repository which contains
+// repoDir in a temporary directory and checks out ref in it.
+func AddWorktree(repoDir, ref string) (*Worktree, error) {
+	dir, err := os.MkdirTemp("", "cifuzz-worktree-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	_, err = git(repoDir, "worktree", "add", "--detach", dir, ref)
+	if err != nil {
+		fileutil.Cleanup(dir)
+		return nil, err
+	}
+	return &Worktree{Dir: dir}, nil
+}
+
+// Checkout checks out the commit in the worktree.
+func (w *Worktree) Checkout(commit string) error {
+	_, err := git(w.Dir, "checkout", "--quiet", "--detach", commit)
+	return err
+}
+
+// Remove removes the worktree, including untracked files like build
+// artifacts.
+func (w *Worktree) Remove() error {
+	_, err := git(w.Dir, "worktree", "remove", "--force", w.Dir)
+	if err != nil {
+		return err
+	}
+	fileutil.Cleanup(w.Dir)
+	return nil
+}
+
+// Bisect runs git bisect in the worktree to find the first bad commit
+// between the good and the bad commit. The test function is called with
+// each commit which git bisect checks out and classifies it.
+func (w *Worktree) Bisect(good, bad string, test func(commit string) (BisectVerdict, error)) (string, error) {
+	output, err := git(w.Dir, "bisect", "start", bad, good)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_, err := git(w.Dir, "bisect", "reset")
+		if err != nil {
+			log.Debugf("Failed to reset git bisect: %v", err)
+		}
+	}()
+
+	for !strings.Contains(output, "is the first bad commit") {
+		commit, err := git(w.Dir, "rev-parse", "HEAD")
+		if err != nil {
+			return "", err
+		}
+		verdict, err := test(commit)
+		if err != nil {
+			return "", err
+		}
+		output, err = git(w.Dir, "bisect", string(verdict))
+		if strings.Contains(output, "only 'skip'ped commits left") {
+			// git bisect exits with a non-zero exit code in this case
+			return "", errors.Errorf("The first bad commit could not be determined because some commits had to be skipped, it's one of:\n  %s",
+				strings.Join(skippedCommitRegex.FindAllString(output, -1), "\n  "))
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return git(w.Dir, "rev-parse", "refs/bisect/bad")
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	log.Debugf("Command: %s", cmd.String())
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), errors.Wrapf(err, "git %s failed:\n%s", args[0], output)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,116 @@
+package vcs_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+)
+
+func TestWorktree_Bisect(t *testing.T) {
+	repo, commits := createGitRepoWithVersions(t, 8)
+
+	worktree, err := vcs.AddWorktree(repo, commits[7])
+	require.NoError(t, err)
+	defer func() {
+		err := worktree.Remove()
+		require.NoError(t, err)
+	}()
+
+	// The bug was introduced in version 5, version 3 doesn't build
+	firstBad, err := worktree.Bisect(commits[0], commits[7], func(commit string) (vcs.BisectVerdict, error) {
+		version := readVersion(t, worktree.Dir)
+		switch {
+		case version == 3:
+			return vcs.BisectSkip, nil
+		case version >= 5:
+			return vcs.BisectBad, nil
+		default:
+			return vcs.BisectGood, nil
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, commits[5], firstBad)
+
+	summary, err := vcs.GitCommitSummary(repo, firstBad)
+	require.NoError(t, err)
+	assert.Contains(t, summary, "Version 5")
+
+	// The repository is not modified
+	head, err := vcs.GitRevParse(repo, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, commits[7], head)
+}
+
+func TestWorktree_Bisect_OnlySkipped(t *testing.T) {
+	repo, commits := createGitRepoWithVersions(t, 4)
+
+	worktree, err := vcs.AddWorktree(repo, commits[3])
+	require.NoError(t, err)
+	defer func() {
+		err := worktree.Remove()
+		require.NoError(t, err)
+	}()
+
+	// The bug was introduced in version 2 or 3, but version 2 doesn't
+	// build
+	_, err = worktree.Bisect(commits[0], commits[3], func(commit string) (vcs.BisectVerdict, error) {
+		version := readVersion(t, worktree.Dir)
+		switch {
+		case version == 2:
+			return vcs.BisectSkip, nil
+		case version >= 3:
+			return vcs.BisectBad, nil
+		default:
+			return vcs.BisectGood, nil
+		}
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), commits[2])
+	assert.Contains(t, err.Error(), commits[3])
+}
+
+func TestGitRevParse_Invalid(t *testing.T) {
+	repo := createGitRepoWithCommits(t)
+	_, err := vcs.GitRevParse(repo, "no-such-ref")
+	require.Error(t, err)
+}
+
+// createGitRepoWithVersions creates a repository with n commits, each of
+// which writes its index to the file "version". It returns the commits
+// in order.
+func createGitRepoWithVersions(t *testing.T, n int) (string, []string) {
+	t.Helper()
+
+	repo := testutil.MkdirTemp(t, "", "git-test-*")
+	runGit(t, repo, "init")
+	runGit(t, repo, "config", "user.email", "you@example.com")
+	runGit(t, repo, "config", "user.name", "Your Name")
+
+	var commits []string
+	for i := 0; i < n; i++ {
+		err := os.WriteFile(filepath.Join(repo, "version"), []byte(strconv.Itoa(i)), 0o644)
+		require.NoError(t, err)
+		runGit(t, repo, "add", "version")
+		runGit(t, repo, "commit", "-m", fmt.Sprintf("Version %d", i))
+		commit, err := vcs.GitRevParse(repo, "HEAD")
+		require.NoError(t, err)
+		commits = append(commits, commit)
+	}
+	return repo, commits
+}
+
+func readVersion(t *testing.T, dir string) int {
+	content, err := os.ReadFile(filepath.Join(dir, "version"))
+	require.NoError(t, err)
+	version, err := strconv.Atoi(string(content))
+	require.NoError(t, err)
+	return version
+}